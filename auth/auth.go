@@ -0,0 +1,70 @@
+// Package auth authenticates API callers. Verifiers turn a bearer token into a
+// Principal; Middleware tries each configured verifier and stores the caller in the Gin
+// context, where RequireSelf, RequireAdmin and CanAccess check it.
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrMissingToken is returned when a request has no bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken is returned when a token is malformed, has a bad signature or
+	// fails a claim check
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned when a token's exp is in the past
+	ErrExpiredToken = errors.New("token has expired")
+	// ErrUnsupportedToken is returned by a verifier for tokens meant for another
+	// verifier, such as a different signing algorithm or issuer
+	ErrUnsupportedToken = errors.New("token not supported by this verifier")
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	// UID identifies the caller; it is the Firebase UID for Firebase tokens and matches
+	// the user IDs used in routes such as /ai/workout-plans/:user_id
+	UID string `json:"uid"`
+	// Admin callers may act on behalf of any user
+	Admin bool `json:"admin"`
+	// Provider names the verifier that accepted the token, e.g. "firebase"
+	Provider string `json:"provider"`
+	// Claims holds the token's decoded claims
+	Claims map[string]interface{} `json:"-"`
+}
+
+// Verifier checks a bearer token and returns the caller it belongs to. Verifiers return
+// ErrUnsupportedToken for tokens they don't handle so the next one can be tried.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+// principalKey is the Gin context key of the authenticated Principal
+const principalKey = "auth.principal"
+
+// SetPrincipal stores the authenticated caller in the Gin context
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+}
+
+// FromContext returns the authenticated caller, if any
+func FromContext(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok && principal != nil
+}
+
+// CanAccess reports whether the caller may act on data owned by uid
+func CanAccess(c *gin.Context, uid string) bool {
+	principal, ok := FromContext(c)
+	if !ok {
+		return false
+	}
+	return principal.Admin || (uid != "" && principal.UID == uid)
+}
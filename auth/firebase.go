@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"time"
+)
+
+// ProviderFirebase is the Principal.Provider of callers authenticated by Firebase ID tokens
+const ProviderFirebase = "firebase"
+
+// firebaseIssuerPrefix is followed by the project ID in a Firebase ID token's iss claim
+const firebaseIssuerPrefix = "https://securetoken.google.com/"
+
+// clockSkew is the leeway allowed on exp, iat and auth_time
+const clockSkew = time.Minute
+
+// FirebaseVerifier verifies Firebase Auth ID tokens as described in
+// https://firebase.google.com/docs/auth/admin/verify-id-tokens
+type FirebaseVerifier struct {
+	projectID string
+	keys      KeySource
+	now       func() time.Time
+}
+
+// NewFirebaseVerifier creates a verifier for ID tokens issued to projectID, signed by keys.
+// Use NewGoogleKeySource in production and a StaticKeySource in tests.
+func NewFirebaseVerifier(projectID string, keys KeySource) *FirebaseVerifier {
+	return &FirebaseVerifier{
+		projectID: projectID,
+		keys:      keys,
+		now:       time.Now,
+	}
+}
+
+// Verify checks the token's RS256 signature, audience, issuer, subject and times. The
+// caller is an admin when the token carries an admin custom claim set to true.
+func (v *FirebaseVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parsed, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Header.Algorithm != "RS256" {
+		return nil, ErrUnsupportedToken
+	}
+	if parsed.Header.KeyID == "" {
+		return nil, fmt.Errorf("%w: missing kid", ErrInvalidToken)
+	}
+
+	keys, err := v.keys.PublicKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Firebase public keys: %w", err)
+	}
+	key, ok := keys[parsed.Header.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown kid %q", ErrInvalidToken, parsed.Header.KeyID)
+	}
+	digest := sha256.Sum256([]byte(parsed.Signed))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], parsed.Signature); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	if !parsed.hasAudience(v.projectID) {
+		return nil, fmt.Errorf("%w: audience is not %s", ErrInvalidToken, v.projectID)
+	}
+	if iss := parsed.stringClaim("iss"); iss != firebaseIssuerPrefix+v.projectID {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+	uid := parsed.stringClaim("sub")
+	if uid == "" || len(uid) > 128 {
+		return nil, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+	if err := parsed.checkTimes(v.now(), clockSkew); err != nil {
+		return nil, err
+	}
+
+	admin, _ := parsed.Claims["admin"].(bool)
+	return &Principal{
+		UID:      uid,
+		Admin:    admin,
+		Provider: ProviderFirebase,
+		Claims:   parsed.Claims,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFirebaseVerifierAcceptsValidToken(t *testing.T) {
+	key := newTestKey(t)
+	claims := firebaseClaims("user-1")
+	claims["admin"] = true
+
+	principal, err := newTestVerifier(key).Verify(context.Background(), signRS256(t, key, "key-1", claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.UID != "user-1" || !principal.Admin || principal.Provider != ProviderFirebase {
+		t.Errorf("principal = %+v", principal)
+	}
+}
+
+func TestFirebaseVerifierRejectsBadTokens(t *testing.T) {
+	key := newTestKey(t)
+	otherKey := newTestKey(t)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with returns valid claims for user-1 with one claim changed
+	with := func(name string, value interface{}) map[string]interface{} {
+		claims := firebaseClaims("user-1")
+		claims[name] = value
+		return claims
+	}
+	valid := signRS256(t, key, "key-1", firebaseClaims("user-1"))
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"signed by another key", signRS256(t, otherKey, "key-1", firebaseClaims("user-1")), ErrInvalidToken},
+		{"tampered claims", parts[0] + "." + strings.Split(signRS256(t, key, "key-1", firebaseClaims("admin")), ".")[1] + "." + parts[2], ErrInvalidToken},
+		{"alg none", signToken(t, jwtHeader{Algorithm: "none", KeyID: "key-1"}, firebaseClaims("user-1"), func(string) []byte { return nil }), ErrUnsupportedToken},
+		{"HS256 keyed with the public key", signHS256(t, publicKeyDER, "key-1", firebaseClaims("user-1")), ErrUnsupportedToken},
+		{"wrong audience", signRS256(t, key, "key-1", with("aud", "another-project")), ErrInvalidToken},
+		{"wrong issuer", signRS256(t, key, "key-1", with("iss", firebaseIssuerPrefix+"another-project")), ErrInvalidToken},
+		{"expired", signRS256(t, key, "key-1", with("exp", testNow.Add(-2*time.Minute).Unix())), ErrExpiredToken},
+		{"issued in the future", signRS256(t, key, "key-1", with("iat", testNow.Add(time.Hour).Unix())), ErrInvalidToken},
+		{"missing subject", signRS256(t, key, "key-1", with("sub", "")), ErrInvalidToken},
+		{"unknown kid", signRS256(t, key, "key-2", firebaseClaims("user-1")), ErrInvalidToken},
+		{"missing kid", signRS256(t, key, "", firebaseClaims("user-1")), ErrInvalidToken},
+		{"not a JWT", "not-a-token", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := newTestVerifier(key).Verify(context.Background(), tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %+v, %v; want %v", principal, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+const testProjectID = "fit-ai-test"
+
+// testNow is the fixed clock of the verifiers under test
+var testNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestKey generates an RSA key for signing test tokens
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// firebaseClaims returns the claims of a valid Firebase ID token for uid
+func firebaseClaims(uid string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":       firebaseIssuerPrefix + testProjectID,
+		"aud":       testProjectID,
+		"sub":       uid,
+		"iat":       testNow.Add(-time.Minute).Unix(),
+		"auth_time": testNow.Add(-time.Hour).Unix(),
+		"exp":       testNow.Add(time.Hour).Unix(),
+	}
+}
+
+// signToken builds a compact JWT with header, signed by sign over header.payload
+func signToken(t *testing.T, header jwtHeader, claims map[string]interface{}, sign func(signed string) []byte) string {
+	t.Helper()
+	encodedHeader, err := encodeSegment(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedClaims, err := encodeSegment(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := encodedHeader + "." + encodedClaims
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(signed))
+}
+
+// signRS256 signs a token with key under kid
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	return signToken(t, jwtHeader{Algorithm: "RS256", KeyID: kid, Type: "JWT"}, claims, func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	})
+}
+
+// signHS256 signs a token with an HMAC secret
+func signHS256(t *testing.T, secret []byte, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	return signToken(t, jwtHeader{Algorithm: "HS256", KeyID: kid, Type: "JWT"}, claims, func(signed string) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		return mac.Sum(nil)
+	})
+}
+
+// newTestVerifier returns a Firebase verifier trusting key under kid "key-1" at testNow
+func newTestVerifier(key *rsa.PrivateKey) *FirebaseVerifier {
+	verifier := NewFirebaseVerifier(testProjectID, StaticKeySource{"key-1": &key.PublicKey})
+	verifier.now = func() time.Time { return testNow }
+	return verifier
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// jwtHeader is the JOSE header of a compact JWT
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+// jwt is a decoded but not yet verified compact JWT
+type jwt struct {
+	Header    jwtHeader
+	Claims    map[string]interface{}
+	Signed    string // header.payload, the input to the signature
+	Signature []byte
+}
+
+// parseJWT splits and decodes a compact JWT without checking its signature
+func parseJWT(token string) (*jwt, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 segments, got %d", ErrInvalidToken, len(parts))
+	}
+
+	var parsed jwt
+	if err := decodeSegment(parts[0], &parsed.Header); err != nil {
+		return nil, fmt.Errorf("%w: bad header: %v", ErrInvalidToken, err)
+	}
+	if err := decodeSegment(parts[1], &parsed.Claims); err != nil {
+		return nil, fmt.Errorf("%w: bad claims: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrInvalidToken)
+	}
+	parsed.Signed = parts[0] + "." + parts[1]
+	parsed.Signature = signature
+	return &parsed, nil
+}
+
+// decodeSegment decodes a base64url JSON segment
+func decodeSegment(segment string, dst interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// encodeSegment encodes a value as a base64url JSON segment
+func encodeSegment(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// stringClaim returns a string claim, or "" when it is missing or not a string
+func (t *jwt) stringClaim(name string) string {
+	value, _ := t.Claims[name].(string)
+	return value
+}
+
+// timeClaim returns a NumericDate claim such as exp or iat
+func (t *jwt) timeClaim(name string) (time.Time, bool) {
+	value, ok := t.Claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+// hasAudience reports whether the aud claim, a string or a list, contains audience
+func (t *jwt) hasAudience(audience string) bool {
+	switch aud := t.Claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, value := range aud {
+			if value == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkTimes validates exp, iat and (when present) auth_time against now, allowing
+// skew for clock differences
+func (t *jwt) checkTimes(now time.Time, skew time.Duration) error {
+	exp, ok := t.timeClaim("exp")
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if !now.Before(exp.Add(skew)) {
+		return ErrExpiredToken
+	}
+	if iat, ok := t.timeClaim("iat"); !ok || iat.After(now.Add(skew)) {
+		return fmt.Errorf("%w: missing or future iat", ErrInvalidToken)
+	}
+	if _, present := t.Claims["auth_time"]; present {
+		if authTime, ok := t.timeClaim("auth_time"); !ok || authTime.After(now.Add(skew)) {
+			return fmt.Errorf("%w: invalid auth_time", ErrInvalidToken)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// GoogleCertsURL publishes the X.509 certificates that sign Firebase ID tokens
+const GoogleCertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+// defaultKeyTTL is used when the certificate response has no Cache-Control max-age
+const defaultKeyTTL = time.Hour
+
+// KeySource supplies the RSA public keys that sign ID tokens, by key ID
+type KeySource interface {
+	PublicKeys(ctx context.Context) (map[string]*rsa.PublicKey, error)
+}
+
+// StaticKeySource is a fixed set of keys, for tests and offline development
+type StaticKeySource map[string]*rsa.PublicKey
+
+// PublicKeys returns the fixed keys
+func (s StaticKeySource) PublicKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	return s, nil
+}
+
+// HTTPKeySource fetches X.509 certificates published as a JSON object of key ID to PEM,
+// as Google does for Firebase, and caches them for as long as the response's
+// Cache-Control max-age allows
+type HTTPKeySource struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	// fetches shares one certificate download between the callers that find the cache
+	// expired at the same time
+	fetches singleflight.Group
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	expires time.Time
+}
+
+// NewGoogleKeySource creates a key source for Google's Firebase ID token certificates
+func NewGoogleKeySource() *HTTPKeySource {
+	return NewHTTPKeySource(GoogleCertsURL, &http.Client{Timeout: 10 * time.Second})
+}
+
+// NewHTTPKeySource creates a key source for a certificate endpoint
+func NewHTTPKeySource(url string, client *http.Client) *HTTPKeySource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPKeySource{
+		url:    url,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// PublicKeys returns the cached keys, refreshing them once they have expired. If a
+// refresh fails while stale keys are cached, the stale keys are kept so a certificate
+// endpoint outage doesn't lock every caller out. The lock is not held during the
+// download; concurrent callers wait for a single shared refresh instead.
+func (s *HTTPKeySource) PublicKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	if keys, fresh := s.cached(); fresh {
+		return keys, nil
+	}
+
+	keys, err, _ := s.fetches.Do("keys", func() (interface{}, error) {
+		return s.refresh(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys.(map[string]*rsa.PublicKey), nil
+}
+
+// cached returns the cached keys and whether they have not expired yet
+func (s *HTTPKeySource) cached() (map[string]*rsa.PublicKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys, s.keys != nil && s.now().Before(s.expires)
+}
+
+// refresh downloads the keys and caches them, falling back to stale keys on failure
+func (s *HTTPKeySource) refresh(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	// A refresh that finished just before this one started has already done the work
+	if keys, fresh := s.cached(); fresh {
+		return keys, nil
+	}
+
+	keys, ttl, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if s.keys != nil {
+			return s.keys, nil
+		}
+		return nil, err
+	}
+	s.keys = keys
+	s.expires = s.now().Add(ttl)
+	return keys, nil
+}
+
+// fetch downloads and parses the certificates
+func (s *HTTPKeySource) fetch(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create key request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch public keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("public key endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var certs map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode public keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, certPEM := range certs {
+		key, err := parseCertificateKey(certPEM)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid certificate %q: %w", kid, err)
+		}
+		keys[kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, 0, fmt.Errorf("public key endpoint returned no keys")
+	}
+
+	return keys, maxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// parseCertificateKey extracts the RSA public key from a PEM encoded certificate
+func parseCertificateKey(certPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("certificate key is not RSA")
+	}
+	return key, nil
+}
+
+// maxAge reads max-age from a Cache-Control header, defaulting to defaultKeyTTL
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return defaultKeyTTL
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPKeySourceSharesOneFetch(t *testing.T) {
+	key := newTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "securetoken"},
+		NotBefore:    testNow.Add(-time.Hour),
+		NotAfter:     testNow.Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs, err := json.Marshal(map[string]string{
+		"key-1": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=600")
+		w.Write(certs)
+	}))
+	defer server.Close()
+
+	now := testNow
+	source := NewHTTPKeySource(server.URL, server.Client())
+	source.now = func() time.Time { return now }
+
+	// Callers arriving while the certificates download wait for the same fetch
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys, err := source.PublicKeys(context.Background())
+			if err == nil && keys["key-1"] == nil {
+				err = errors.New("key-1 is missing")
+			}
+			errs <- err
+		}()
+	}
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetched the certificates %d times, want 1", n)
+	}
+
+	// Keys are cached for max-age, then kept when the refresh fails
+	if _, err := source.PublicKeys(context.Background()); err != nil || fetches.Load() != 1 {
+		t.Errorf("cached lookup: %v after %d fetches", err, fetches.Load())
+	}
+	now = now.Add(11 * time.Minute)
+	failing.Store(true)
+	keys, err := source.PublicKeys(context.Background())
+	if err != nil || keys["key-1"] == nil || fetches.Load() != 2 {
+		t.Errorf("stale lookup = %v, %v after %d fetches; want the stale keys", keys, err, fetches.Load())
+	}
+}
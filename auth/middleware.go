@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware authenticates requests with the bearer token in the Authorization header,
+// trying each verifier in turn, and stores the caller for FromContext. Requests without
+// a valid token are rejected with 401.
+func Middleware(verifiers ...Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			respondUnauthorized(c, ErrMissingToken)
+			return
+		}
+
+		var err error = ErrUnsupportedToken
+		for _, verifier := range verifiers {
+			var principal *Principal
+			principal, err = verifier.Verify(c.Request.Context(), token)
+			if err == nil {
+				SetPrincipal(c, principal)
+				c.Next()
+				return
+			}
+			if !errors.Is(err, ErrUnsupportedToken) {
+				break
+			}
+		}
+
+		if !errors.Is(err, ErrInvalidToken) && !errors.Is(err, ErrExpiredToken) && !errors.Is(err, ErrUnsupportedToken) {
+			// Not the caller's fault, e.g. the public keys could not be fetched
+			log.Printf("Authentication failed: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Authentication is temporarily unavailable",
+				"code":  "auth_unavailable",
+			})
+			return
+		}
+		respondUnauthorized(c, err)
+	}
+}
+
+// Disabled authenticates every request as an admin. It is meant for local development
+// without a Firebase project and must never be used in production.
+func Disabled() gin.HandlerFunc {
+	log.Println("WARNING: authentication is disabled (AUTH_DISABLED=true); every request is treated as an admin")
+	principal := &Principal{UID: "dev", Admin: true, Provider: "disabled"}
+	return func(c *gin.Context) {
+		SetPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// RequireSelf only lets a request through when the user ID in the param path parameter,
+// or in the query parameter of the same name for routes without it, is the caller's own
+// UID. Admins may act on any user.
+func RequireSelf(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := c.Params.Get(param)
+		if !ok {
+			uid = c.Query(param)
+		}
+		if uid == "" {
+			// Let the handler report the missing parameter
+			c.Next()
+			return
+		}
+		if !CanAccess(c, uid) {
+			RespondForbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin only lets admins through
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := FromContext(c); !ok || !principal.Admin {
+			RespondForbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RespondForbidden aborts with 403 for a caller acting on another user's data
+func RespondForbidden(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error": "You do not have access to this resource",
+		"code":  "forbidden",
+	})
+}
+
+// respondUnauthorized aborts with 401 and a code describing why the token was rejected
+func respondUnauthorized(c *gin.Context, err error) {
+	code := "invalid_token"
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		code = "missing_token"
+	case errors.Is(err, ErrExpiredToken):
+		code = "token_expired"
+	}
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error": "Authentication required: " + err.Error(),
+		"code":  code,
+	})
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// failingKeySource can't load keys, as when the certificate endpoint is down
+type failingKeySource struct{}
+
+func (failingKeySource) PublicKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	return nil, errors.New("certificate endpoint unavailable")
+}
+
+// newTestRouter authenticates with verifiers and serves /users/:user_id to the user
+// themselves and /admin to admins
+func newTestRouter(verifiers ...Verifier) *gin.Engine {
+	r := gin.New()
+	r.Use(Middleware(verifiers...))
+	ok := func(c *gin.Context) {
+		principal, _ := FromContext(c)
+		c.JSON(http.StatusOK, gin.H{"uid": principal.UID})
+	}
+	r.GET("/users/:user_id", RequireSelf("user_id"), ok)
+	r.GET("/admin", RequireAdmin(), ok)
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	key := newTestKey(t)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(newTestVerifier(key), issuer)
+
+	adminClaims := firebaseClaims("admin-1")
+	adminClaims["admin"] = true
+	expiredClaims := firebaseClaims("user-1")
+	expiredClaims["exp"] = testNow.Add(-time.Hour).Unix()
+	user := signRS256(t, key, "key-1", firebaseClaims("user-1"))
+	admin := signRS256(t, key, "key-1", adminClaims)
+
+	tests := []struct {
+		name   string
+		header string
+		path   string
+		status int
+		code   string
+	}{
+		{"own user ID", "Bearer " + user, "/users/user-1", http.StatusOK, ""},
+		{"another user's ID", "Bearer " + user, "/users/user-2", http.StatusForbidden, "forbidden"},
+		{"admin on another user's ID", "Bearer " + admin, "/users/user-2", http.StatusOK, ""},
+		{"admin route as a user", "Bearer " + user, "/admin", http.StatusForbidden, "forbidden"},
+		{"admin route as an admin", "Bearer " + admin, "/admin", http.StatusOK, ""},
+		{"no header", "", "/users/user-1", http.StatusUnauthorized, "missing_token"},
+		{"not a bearer token", "Basic " + user, "/users/user-1", http.StatusUnauthorized, "missing_token"},
+		{"expired token", "Bearer " + signRS256(t, key, "key-1", expiredClaims), "/users/user-1", http.StatusUnauthorized, "token_expired"},
+		{"unknown kid", "Bearer " + signRS256(t, key, "key-9", firebaseClaims("user-1")), "/users/user-1", http.StatusUnauthorized, "invalid_token"},
+		{"alg none", "Bearer " + signToken(t, jwtHeader{Algorithm: "none"}, firebaseClaims("user-1"), func(string) []byte { return nil }), "/users/user-1", http.StatusUnauthorized, "invalid_token"},
+		{"HS256 keyed with the public key", "Bearer " + signHS256(t, publicKeyDER, "key-1", firebaseClaims("user-1")), "/users/user-1", http.StatusUnauthorized, "invalid_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.status, w.Body)
+			}
+			if tt.code != "" && !bytes.Contains(w.Body.Bytes(), []byte(`"code":"`+tt.code+`"`)) {
+				t.Errorf("body = %s, want code %s", w.Body, tt.code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareKeySourceFailure(t *testing.T) {
+	key := newTestKey(t)
+	router := newTestRouter(NewFirebaseVerifier(testProjectID, failingKeySource{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/user-1", nil)
+	req.Header.Set("Authorization", "Bearer "+signRS256(t, key, "key-1", firebaseClaims("user-1")))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 when the keys can't be loaded", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProviderPassword is the Principal.Provider of callers authenticated by access tokens
+// issued at email and password sign-in
+const ProviderPassword = "password"
+
+// MinSecretLength is the shortest JWT_SECRET accepted, in bytes
+const MinSecretLength = 32
+
+// DefaultIssuer is the iss and aud of access tokens issued by this API
+const DefaultIssuer = "fit-ai-api"
+
+// TokenIssuer issues and verifies short-lived HS256 access tokens for first-party sign-in
+type TokenIssuer struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTokenIssuer creates an issuer signing with secret; access tokens expire after ttl
+func NewTokenIssuer(secret []byte, ttl time.Duration) (*TokenIssuer, error) {
+	if len(secret) < MinSecretLength {
+		return nil, fmt.Errorf("JWT secret must be at least %d bytes", MinSecretLength)
+	}
+	if ttl <= 0 {
+		return nil, errors.New("access token lifetime must be positive")
+	}
+	return &TokenIssuer{
+		secret: secret,
+		issuer: DefaultIssuer,
+		ttl:    ttl,
+		now:    time.Now,
+	}, nil
+}
+
+// TTL returns how long issued access tokens are valid
+func (t *TokenIssuer) TTL() time.Duration {
+	return t.ttl
+}
+
+// Issue creates an access token for the user with the given UID
+func (t *TokenIssuer) Issue(uid string, admin bool) (string, time.Time, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := t.now()
+	expires := now.Add(t.ttl)
+	header, err := encodeSegment(jwtHeader{Algorithm: "HS256", Type: "JWT"})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	claims, err := encodeSegment(map[string]interface{}{
+		"iss":   t.issuer,
+		"aud":   t.issuer,
+		"sub":   uid,
+		"iat":   now.Unix(),
+		"exp":   expires.Unix(),
+		"jti":   hex.EncodeToString(jti),
+		"admin": admin,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	signed := header + "." + claims
+	return signed + "." + base64.RawURLEncoding.EncodeToString(t.sign(signed)), expires, nil
+}
+
+// Verify checks an access token issued by Issue
+func (t *TokenIssuer) Verify(ctx context.Context, token string) (*Principal, error) {
+	parsed, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Header.Algorithm != "HS256" {
+		return nil, ErrUnsupportedToken
+	}
+	if !hmac.Equal(parsed.Signature, t.sign(parsed.Signed)) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	if iss := parsed.stringClaim("iss"); iss != t.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+	if !parsed.hasAudience(t.issuer) {
+		return nil, fmt.Errorf("%w: audience is not %s", ErrInvalidToken, t.issuer)
+	}
+	uid := parsed.stringClaim("sub")
+	if uid == "" {
+		return nil, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+	if err := parsed.checkTimes(t.now(), clockSkew); err != nil {
+		return nil, err
+	}
+
+	admin, _ := parsed.Claims["admin"].(bool)
+	return &Principal{
+		UID:      uid,
+		Admin:    admin,
+		Provider: ProviderPassword,
+		Claims:   parsed.Claims,
+	}, nil
+}
+
+// sign returns the HMAC-SHA256 of signed
+func (t *TokenIssuer) sign(signed string) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
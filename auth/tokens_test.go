@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenIssuerRoundTrip(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	issuer, err := NewTokenIssuer(secret, 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := testNow
+	issuer.now = func() time.Time { return now }
+
+	token, expires, err := issuer.Issue("user-1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expires.Equal(testNow.Add(15 * time.Minute)) {
+		t.Errorf("expires = %v, want 15 minutes after issue", expires)
+	}
+	principal, err := issuer.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.UID != "user-1" || !principal.Admin || principal.Provider != ProviderPassword {
+		t.Errorf("principal = %+v", principal)
+	}
+
+	other, err := NewTokenIssuer([]byte(strings.Repeat("x", MinSecretLength)), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.now = issuer.now
+	if _, err := other.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token from another secret: error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := issuer.Verify(context.Background(), signRS256(t, newTestKey(t), "key-1", firebaseClaims("user-1"))); !errors.Is(err, ErrUnsupportedToken) {
+		t.Errorf("RS256 token: error = %v, want ErrUnsupportedToken", err)
+	}
+
+	now = expires.Add(clockSkew)
+	if _, err := issuer.Verify(context.Background(), token); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expired token: error = %v, want ErrExpiredToken", err)
+	}
+
+	if _, err := NewTokenIssuer(secret[:MinSecretLength-1], time.Minute); err == nil {
+		t.Error("NewTokenIssuer accepted a short secret")
+	}
+}
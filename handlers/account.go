@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler handles email and password sign-in and the tokens it issues
+type AccountHandler struct {
+	accounts *services.AccountService
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(accounts *services.AccountService) *AccountHandler {
+	return &AccountHandler{accounts: accounts}
+}
+
+// loginRequest is an email and password login
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// refreshTokenRequest carries a refresh token to exchange or revoke
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// Signup creates an account and returns its first access and refresh token
+func (h *AccountHandler) Signup(c *gin.Context) {
+	var input services.SignupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.accounts.Signup(c.Request.Context(), input)
+	if err != nil {
+		respondAccountError(c, "sign up", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Account created",
+		"data":    result,
+	})
+}
+
+// Login signs in with an email and password
+func (h *AccountHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.accounts.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		respondAccountError(c, "log in", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token
+func (h *AccountHandler) Refresh(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.accounts.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		respondAccountError(c, "refresh", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// Logout revokes a refresh token and the tokens rotated from the same login
+func (h *AccountHandler) Logout(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.accounts.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		respondAccountError(c, "log out", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logged out",
+	})
+}
+
+// RevokeSessions revokes every refresh token of the caller, logging them out on all
+// devices once their access tokens expire
+func (h *AccountHandler) RevokeSessions(c *gin.Context) {
+	principal, ok := auth.FromContext(c)
+	if !ok {
+		auth.RespondForbidden(c)
+		return
+	}
+
+	if err := h.accounts.RevokeAll(c.Request.Context(), principal.UID); err != nil {
+		respondAccountError(c, "revoke sessions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "All sessions revoked",
+	})
+}
+
+// respondAccountError writes the response for a failed account operation
+func respondAccountError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSignup):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"code":  "email_taken",
+		})
+	case errors.Is(err, services.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+			"code":  "invalid_credentials",
+		})
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+			"code":  "invalid_refresh_token",
+		})
+	default:
+		if respondContextError(c, "Account "+action, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to " + action + ": " + err.Error(),
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/repositories"
+	"fit-ai-api/services"
+)
+
+func TestAccountHandlerStatusCodes(t *testing.T) {
+	issuer, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := services.NewAccountService(repositories.NewMemoryUserRepository(), repositories.NewMemoryRefreshTokenRepository(), issuer, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewAccountHandler(accounts)
+	r := gin.New()
+	r.POST("/auth/signup", h.Signup)
+	r.POST("/auth/login", h.Login)
+	r.POST("/auth/refresh", h.Refresh)
+	r.POST("/auth/logout", h.Logout)
+
+	signup := gin.H{"email": "ana@example.com", "password": "correct horse", "name": "Ana"}
+	status, body := doRequest(t, r, http.MethodPost, "/auth/signup", signup)
+	if status != http.StatusCreated {
+		t.Fatalf("signup: status = %d, body %v", status, body)
+	}
+	data, _ := body["data"].(map[string]interface{})
+	refreshToken, _ := data["refreshToken"].(string)
+
+	status, body = doRequest(t, r, http.MethodPost, "/auth/login", gin.H{"email": "ana@example.com", "password": "correct horse"})
+	if status != http.StatusOK {
+		t.Fatalf("login: status = %d, body %v", status, body)
+	}
+
+	status, body = doRequest(t, r, http.MethodPost, "/auth/refresh", gin.H{"refreshToken": refreshToken})
+	if status != http.StatusOK {
+		t.Fatalf("refresh: status = %d, body %v", status, body)
+	}
+	data, _ = body["data"].(map[string]interface{})
+	rotated, _ := data["refreshToken"].(string)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"signup with a taken email", "/auth/signup", signup, http.StatusConflict, "email_taken"},
+		{"signup with a short password", "/auth/signup", gin.H{"email": "bo@example.com", "password": "short", "name": "Bo"}, http.StatusBadRequest, ""},
+		{"signup without a name", "/auth/signup", gin.H{"email": "bo@example.com", "password": "correct horse"}, http.StatusBadRequest, ""},
+		{"login with a wrong password", "/auth/login", gin.H{"email": "ana@example.com", "password": "wrong horse"}, http.StatusUnauthorized, "invalid_credentials"},
+		{"login with an unknown email", "/auth/login", gin.H{"email": "nobody@example.com", "password": "correct horse"}, http.StatusUnauthorized, "invalid_credentials"},
+		{"refresh with a rotated token", "/auth/refresh", gin.H{"refreshToken": refreshToken}, http.StatusUnauthorized, "invalid_refresh_token"},
+		{"refresh without a token", "/auth/refresh", gin.H{}, http.StatusBadRequest, ""},
+		{"logout", "/auth/logout", gin.H{"refreshToken": rotated}, http.StatusOK, ""},
+		{"logout twice", "/auth/logout", gin.H{"refreshToken": rotated}, http.StatusOK, ""},
+		{"refresh after logout", "/auth/refresh", gin.H{"refreshToken": rotated}, http.StatusUnauthorized, "invalid_refresh_token"},
+	}
+	// The cases run in order: the logout ones revoke rotated
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doRequest(t, r, http.MethodPost, tt.path, tt.body)
+			if status != tt.status || (tt.code != "" && body["code"] != tt.code) {
+				t.Errorf("status = %d, body %v; want %d %s", status, body, tt.status, tt.code)
+			}
+		})
+	}
+}
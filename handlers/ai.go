@@ -1,11 +1,16 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
 
+	"fit-ai-api/auth"
 	"fit-ai-api/models"
+	"fit-ai-api/repositories"
 	"fit-ai-api/services"
 
 	"github.com/gin-gonic/gin"
@@ -13,15 +18,23 @@ import (
 
 // AIHandler handles AI-related endpoints
 type AIHandler struct {
-	firebaseService *services.FirebaseService
-	aiService       *services.AIService
+	profiles   repositories.ProfileRepository
+	plans      repositories.WorkoutPlanRepository
+	aiService  *services.AIService
+	catalog    *services.ExerciseCatalog
+	jobService *services.JobService
 }
 
-// NewAIHandler creates a new AI handler instance
-func NewAIHandler(firebaseService *services.FirebaseService, aiService *services.AIService) *AIHandler {
+// NewAIHandler creates a new AI handler instance. catalog may be nil, in which case plan
+// exercises are not resolved to catalog exercises; jobService may be nil, in which case
+// asynchronous generation is unavailable.
+func NewAIHandler(profiles repositories.ProfileRepository, plans repositories.WorkoutPlanRepository, aiService *services.AIService, catalog *services.ExerciseCatalog, jobService *services.JobService) *AIHandler {
 	return &AIHandler{
-		firebaseService: firebaseService,
-		aiService:       aiService,
+		profiles:   profiles,
+		plans:      plans,
+		aiService:  aiService,
+		catalog:    catalog,
+		jobService: jobService,
 	}
 }
 
@@ -36,169 +49,328 @@ func (h *AIHandler) GenerateWorkoutPlan(c *gin.Context) {
 		return
 	}
 
-	// Fetch user data from Firestore
-	userData, err := h.firebaseService.GetDocumentByID("users", userID)
+	// With ?async=true the plan is generated by the worker pool and the caller polls the job
+	if c.Query("async") == "true" {
+		h.enqueueWorkoutPlan(c, userID)
+		return
+	}
+
+	userDataModel, ok := h.fetchUserData(c, userID)
+	if !ok {
+		return
+	}
+
+	// Generate workout plan using AI
+	result, err := h.aiService.GenerateWorkoutPlan(c.Request.Context(), userDataModel)
 	if err != nil {
+		respondAIError(c, err)
+		return
+	}
+
+	if err := h.savePlan(c.Request.Context(), userID, userDataModel, result); err != nil {
+		if respondContextError(c, "Saving workout plan", err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch user data: " + err.Error(),
+			"error": err.Error(),
 		})
 		return
 	}
 
-	// Check if user data was found
-	if userData == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
+	// Return the generated workout plan along with the provider that produced it
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result.Plan,
+		"meta":    result.Metadata,
+		"message": "Workout plan generated successfully",
+	})
+}
+
+// enqueueWorkoutPlan queues a background generation job and responds with 202 Accepted
+func (h *AIHandler) enqueueWorkoutPlan(c *gin.Context, userID string) {
+	if h.jobService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Asynchronous generation is not available",
 		})
 		return
 	}
 
-	// Parse user data into our model
-	var userDataModel models.UserData
-
-	// Convert map to JSON bytes
-	jsonData, err := json.Marshal(userData)
+	job, err := h.jobService.Enqueue(c.Request.Context(), models.JobTypePlanGeneration, userID, nil)
 	if err != nil {
+		if errors.Is(err, services.ErrJobQueueFull) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Too many workout plans are being generated, please try again later",
+				"code":  "unavailable",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to marshal user data: " + err.Error(),
+			"error": "Failed to queue workout plan generation: " + err.Error(),
 		})
 		return
 	}
 
-	// Unmarshal JSON to our model
-	err = json.Unmarshal(jsonData, &userDataModel)
+	c.Header("Location", "/api/v1/ai/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    job,
+		"message": "Workout plan generation queued",
+	})
+}
+
+// StreamWorkoutPlan generates a workout plan and streams progress as Server-Sent Events.
+// "status", "session" and "repair" events report progress while the model is writing;
+// the stream ends with a "plan" event carrying the validated plan or an "error" event.
+func (h *AIHandler) StreamWorkoutPlan(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	userDataModel, ok := h.fetchUserData(c, userID)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering so events arrive immediately
+
+	ctx := c.Request.Context()
+	events := make(chan services.StreamEvent, 16)
+
+	var (
+		result *services.GenerationResult
+		genErr error
+	)
+	go func() {
+		defer close(events)
+		result, genErr = h.aiService.GenerateWorkoutPlanStream(ctx, userDataModel, func(event services.StreamEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		})
+		if genErr == nil {
+			genErr = h.savePlan(ctx, userID, userDataModel, result)
+		}
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if ok {
+			c.SSEvent(event.Type, event)
+			return true
+		}
+
+		// The channel is closed once generation has finished
+		if genErr != nil {
+			status, body := aiErrorResponse(c, genErr)
+			body["status"] = status
+			c.SSEvent("error", body)
+			return false
+		}
+
+		c.SSEvent("plan", gin.H{
+			"success": true,
+			"data":    result.Plan,
+			"meta":    result.Metadata,
+		})
+		return false
+	})
+}
+
+// savePlan resolves the exercises of a generated plan to the catalog, replaces those
+// needing equipment the user doesn't have, stores the plan for its owner and replaces
+// result.Plan with the stored copy, which carries the IDs assigned by the database
+func (h *AIHandler) savePlan(ctx context.Context, userID string, userData models.UserData, result *services.GenerationResult) error {
+	result.Metadata.Catalog = h.catalog.Annotate(ctx, result.Plan.Sessions)
+	result.Metadata.Equipment = h.catalog.Enforce(ctx, result.Plan.Sessions, userData.Data.UserProfile)
+	plan, err := h.plans.Create(ctx, userID, result.Plan)
+	if err != nil {
+		return err
+	}
+	result.Plan = plan
+	return nil
+}
+
+// fetchUserData loads the user's profile, writing an error response and
+// returning false when it cannot
+func (h *AIHandler) fetchUserData(c *gin.Context, userID string) (models.UserData, bool) {
+	userData, err := h.profiles.Get(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to parse user data: " + err.Error(),
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+		case respondContextError(c, "Fetching user data", err):
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch user data: " + err.Error(),
+			})
+		}
+		return userData, false
+	}
+
+	return userData, true
+}
+
+// RunGenerationJob is the background job handler for asynchronous plan generation
+func (h *AIHandler) RunGenerationJob(ctx context.Context, job *models.Job) (interface{}, error) {
+	userData, err := h.profiles.Get(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.aiService.GenerateWorkoutPlan(ctx, userData)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.savePlan(ctx, job.UserID, userData, result); err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"plan": result.Plan,
+		"meta": result.Metadata,
+	}, nil
+}
+
+// GetJob reports the status of a background generation job, including its result
+// once it has succeeded or its error once it has failed
+func (h *AIHandler) GetJob(c *gin.Context) {
+	jobID := c.Param("id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Job ID is required",
 		})
 		return
 	}
 
-	// Generate workout plan using AI
-	workoutPlan, err := h.aiService.GenerateWorkoutPlan(userDataModel)
+	job, err := h.jobService.Get(c.Request.Context(), jobID)
 	if err != nil {
+		if errors.Is(err, services.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+		if respondContextError(c, "Fetching job", err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate workout plan: " + err.Error(),
+			"error": "Failed to fetch job: " + err.Error(),
 		})
 		return
 	}
+	if !auth.CanAccess(c, job.UserID) {
+		auth.RespondForbidden(c)
+		return
+	}
 
-	// Return the generated workout plan
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    workoutPlan,
-		"message": "Workout plan generated successfully",
+		"data":    job,
 	})
 }
 
 // GetWorkoutPlanByID retrieves a specific workout plan by ID
 func (h *AIHandler) GetWorkoutPlanByID(c *gin.Context) {
-	planID := c.Param("plan_id")
-	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Plan ID is required",
-		})
+	id, ok := parsePlanID(c)
+	if !ok {
 		return
 	}
 
-	// Convert plan ID to integer
-	id, err := strconv.Atoi(planID)
+	plan, err := h.plans.Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid plan ID format",
-		})
+		respondPlanError(c, "fetch", err)
 		return
 	}
 
-	// For now, we'll return a mock workout plan
-	// In a real implementation, you would fetch this from a database
-	mockPlan := &models.WorkoutPlan{
-		ID:                   id,
-		Name:                 "Push Pull Legs",
-		Description:          "Classic 3-day split focusing on push, pull, and leg movements",
-		AIFeedbackCycle:      12,
-		PlanValidityPeriod:   28,
-		SessionsCompleted:    8,
-		HasNewPlanSuggestion: true,
-		Sessions: []models.WorkoutSession{
-			{
-				ID:   "session_1",
-				Name: "Push Day",
-				Note: "Focus on chest, shoulders, and triceps",
-				Exercises: []models.Exercise{
-					{
-						ID:   1,
-						Name: "Bench Press",
-						Sets: 4,
-						Reps: 8,
-						Weight: models.WeightInfo{
-							Value: 185,
-							Unit:  "LB",
-						},
-						Type: "weight",
-					},
-					{
-						ID:   2,
-						Name: "Overhead Press",
-						Sets: 3,
-						Reps: 10,
-						Weight: models.WeightInfo{
-							Value: 135,
-							Unit:  "LB",
-						},
-						Type: "weight",
-					},
-				},
-			},
-		},
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    mockPlan,
+		"data":    plan,
 	})
 }
 
-// UpdateWorkoutPlan updates an existing workout plan
+// updatePlanRequest holds the fields of a plan users may edit. Progress, the start date
+// and the suggested plan are owned by the server and can't be sent.
+type updatePlanRequest struct {
+	Name               string                  `json:"name"`
+	Description        string                  `json:"description"`
+	AIFeedbackCycle    int                     `json:"aiFeedbackCycle"`
+	PlanValidityPeriod int                     `json:"planValidityPeriod"`
+	Sessions           []models.WorkoutSession `json:"sessions"`
+}
+
+// UpdateWorkoutPlan saves an edit of a workout plan. Sessions and exercises sent with
+// their IDs are updated in place, so logged workouts keep referring to them.
 func (h *AIHandler) UpdateWorkoutPlan(c *gin.Context) {
-	planID := c.Param("plan_id")
-	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Plan ID is required",
-		})
+	id, ok := parsePlanID(c)
+	if !ok {
 		return
 	}
 
 	// Parse the request body
-	var workoutPlan models.WorkoutPlan
-	if err := c.ShouldBindJSON(&workoutPlan); err != nil {
+	var req updatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid request body: " + err.Error(),
 		})
 		return
 	}
+	workoutPlan := models.WorkoutPlan{
+		Name:               req.Name,
+		Description:        req.Description,
+		AIFeedbackCycle:    req.AIFeedbackCycle,
+		PlanValidityPeriod: req.PlanValidityPeriod,
+		Sessions:           req.Sessions,
+	}
 
-	// In a real implementation, you would update the plan in the database
-	// For now, we'll just return success
-	c.JSON(http.StatusOK, gin.H{
+	// Edited plans must follow the same rules as generated ones
+	if violations := services.ValidateWorkoutPlan(&workoutPlan); len(violations) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      "Workout plan failed validation",
+			"code":       "invalid_plan",
+			"violations": violations,
+		})
+		return
+	}
+
+	report := h.catalog.Annotate(c.Request.Context(), workoutPlan.Sessions)
+	plan, err := h.plans.Update(c.Request.Context(), id, &workoutPlan)
+	if err != nil {
+		respondPlanError(c, "update", err)
+		return
+	}
+
+	response := gin.H{
 		"success": true,
 		"message": "Workout plan updated successfully",
-		"data":    workoutPlan,
-	})
+		"data":    plan,
+	}
+	if report != nil {
+		response["catalog"] = report
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteWorkoutPlan deletes a workout plan
 func (h *AIHandler) DeleteWorkoutPlan(c *gin.Context) {
-	planID := c.Param("plan_id")
-	if planID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Plan ID is required",
-		})
+	id, ok := parsePlanID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.plans.Delete(c.Request.Context(), id); err != nil {
+		respondPlanError(c, "delete", err)
 		return
 	}
 
-	// In a real implementation, you would delete the plan from the database
-	// For now, we'll just return success
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Workout plan deleted successfully",
@@ -215,32 +387,132 @@ func (h *AIHandler) GetUserWorkoutPlans(c *gin.Context) {
 		return
 	}
 
-	// In a real implementation, you would fetch all plans for this user from the database
-	// For now, we'll return a mock list
-	mockPlans := []models.WorkoutPlan{
-		{
-			ID:                   1,
-			Name:                 "Push Pull Legs",
-			Description:          "Classic 3-day split",
-			AIFeedbackCycle:      12,
-			PlanValidityPeriod:   28,
-			SessionsCompleted:    8,
-			HasNewPlanSuggestion: true,
-		},
-		{
-			ID:                   2,
-			Name:                 "Full Body",
-			Description:          "Full body workout for beginners",
-			AIFeedbackCycle:      8,
-			PlanValidityPeriod:   21,
-			SessionsCompleted:    5,
-			HasNewPlanSuggestion: false,
-		},
+	plans, err := h.plans.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		respondPlanError(c, "fetch", err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    mockPlans,
-		"count":   len(mockPlans),
+		"data":    plans,
+		"count":   len(plans),
+	})
+}
+
+// RequirePlanOwner is middleware for routes on a single plan that only lets the plan's
+// owner, or an admin, through
+func (h *AIHandler) RequirePlanOwner(c *gin.Context) {
+	id, ok := parsePlanID(c)
+	if !ok {
+		c.Abort()
+		return
+	}
+
+	plan, err := h.plans.Get(c.Request.Context(), id)
+	if err != nil {
+		respondPlanError(c, "fetch", err)
+		c.Abort()
+		return
+	}
+	if !auth.CanAccess(c, plan.UserID) {
+		auth.RespondForbidden(c)
+		return
+	}
+	c.Next()
+}
+
+// parsePlanID reads the plan_id parameter, writing a 400 response when it is not a
+// positive integer
+func parsePlanID(c *gin.Context) (uint, bool) {
+	planID := c.Param("plan_id")
+	if planID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Plan ID is required",
+		})
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(planID, 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid plan ID format",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondPlanError writes the response for a failed plan lookup or change
+func respondPlanError(c *gin.Context, action string, err error) {
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Workout plan not found",
+		})
+		return
+	}
+	if respondContextError(c, "Workout plan "+action, err) {
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "Failed to " + action + " workout plan: " + err.Error(),
 	})
 }
+
+// respondAIError writes the response for a workout plan generation failure
+func respondAIError(c *gin.Context, err error) {
+	status, body := aiErrorResponse(c, err)
+	c.JSON(status, body)
+}
+
+// aiErrorResponse maps a workout plan generation failure to an HTTP status:
+// rate limits become 429, provider outages and bad output 502, exhausted quota or
+// no available provider 503, and timeouts 504. Plans that break domain rules get 422
+// with the list of violations, and client cancellations get 499.
+func aiErrorResponse(c *gin.Context, err error) (int, gin.H) {
+	if errors.Is(err, context.Canceled) {
+		status, body, _ := contextErrorResponse(c, "Workout plan generation", err)
+		return status, body
+	}
+
+	status := http.StatusInternalServerError
+	code := "internal"
+
+	var providerErr *services.ProviderError
+	var validationErr *services.PlanValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity, gin.H{
+			"error":      "Generated workout plan failed validation",
+			"code":       "invalid_plan",
+			"violations": validationErr.Violations,
+		}
+	case errors.As(err, &providerErr):
+		code = string(providerErr.Kind)
+		switch providerErr.Kind {
+		case services.ErrorKindRateLimit:
+			status = http.StatusTooManyRequests
+			if providerErr.RetryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(providerErr.RetryAfter.Seconds()))))
+			}
+		case services.ErrorKindQuota:
+			status = http.StatusServiceUnavailable
+		case services.ErrorKindTimeout:
+			status = http.StatusGatewayTimeout
+		default:
+			status = http.StatusBadGateway
+		}
+	case errors.Is(err, services.ErrNoProviderAvailable), errors.Is(err, services.ErrProviderNotConfigured):
+		status = http.StatusServiceUnavailable
+		code = "unavailable"
+	default:
+		if status, body, ok := contextErrorResponse(c, "Workout plan generation", err); ok {
+			return status, body
+		}
+	}
+
+	return status, gin.H{
+		"error": "Failed to generate workout plan: " + err.Error(),
+		"code":  code,
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/llmstub"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+	"fit-ai-api/services"
+)
+
+// newAIRouter serves the plan routes on in-memory repositories as principal, with the
+// model answered by an llmstub server replaying responses. user-1 has a profile.
+func newAIRouter(t *testing.T, principal *auth.Principal, responses ...llmstub.Response) (*gin.Engine, *repositories.MemoryWorkoutPlanRepository) {
+	t.Helper()
+	stub := llmstub.NewServer(responses...)
+	t.Cleanup(stub.Close)
+	t.Setenv("SELECTED_AI", string(services.OpenAI))
+	t.Setenv("AI_PROVIDERS", "")
+	t.Setenv("OPEN_AI_API_KEY", "test-key")
+	t.Setenv("AI_MAX_RETRIES", "0")
+	t.Setenv("AI_MAX_REPAIR_ATTEMPTS", "0")
+
+	profiles := repositories.NewMemoryProfileRepository()
+	profiles.Put("user-1", models.FirestoreUser{UID: "user-1", UserProfile: models.UserProfile{
+		FullName:     "Ana Tester",
+		FitnessLevel: "intermediate",
+		Goals:        []string{"muscle gain"},
+		Equipment:    []string{"barbell", "dumbbells", "cable machine"},
+	}})
+	plans := repositories.NewMemoryWorkoutPlanRepository()
+	h := NewAIHandler(profiles, plans, services.NewAIServiceWithBaseURL(stub.BaseURL()), nil, nil)
+
+	r := gin.New()
+	r.Use(as(principal))
+	asUserID := AliasParam("id", "user_id")
+	asPlanID := AliasParam("id", "plan_id")
+	self := auth.RequireSelf("user_id")
+	r.POST("/ai/workout-plan/:id", asUserID, self, h.GenerateWorkoutPlan)
+	r.GET("/ai/workout-plan/:id", asPlanID, h.RequirePlanOwner, h.GetWorkoutPlanByID)
+	r.PUT("/ai/workout-plan/:id", asPlanID, h.RequirePlanOwner, h.UpdateWorkoutPlan)
+	r.DELETE("/ai/workout-plan/:id", asPlanID, h.RequirePlanOwner, h.DeleteWorkoutPlan)
+	r.GET("/ai/workout-plans/:user_id", self, h.GetUserWorkoutPlans)
+	return r, plans
+}
+
+func TestAIHandlerPlanLifecycle(t *testing.T) {
+	r, _ := newAIRouter(t, &auth.Principal{UID: "user-1"}, llmstub.MustFixture("openai_plan"))
+
+	status, body := doRequest(t, r, http.MethodPost, "/ai/workout-plan/user-1", nil)
+	if status != http.StatusOK {
+		t.Fatalf("generate: status = %d, want 200 (body %v)", status, body)
+	}
+	generated, _ := body["data"].(map[string]interface{})
+	if generated["id"] != float64(1) || generated["userId"] != "user-1" {
+		t.Fatalf("generated plan = %v, want plan 1 stored for user-1", generated)
+	}
+
+	status, body = doRequest(t, r, http.MethodGet, "/ai/workout-plans/user-1", nil)
+	if status != http.StatusOK || body["count"] != float64(1) {
+		t.Errorf("list: status = %d, body %v", status, body)
+	}
+
+	status, body = doRequest(t, r, http.MethodGet, "/ai/workout-plan/1", nil)
+	if status != http.StatusOK {
+		t.Fatalf("get: status = %d, body %v", status, body)
+	}
+	plan, _ := body["data"].(map[string]interface{})
+	sessions, _ := plan["sessions"].([]interface{})
+	first, _ := sessions[0].(map[string]interface{})
+	exercises, _ := first["exercises"].([]interface{})
+	exercise, _ := exercises[0].(map[string]interface{})
+
+	// Edits keep row IDs, and the client can't set progress or the suggestion
+	plan["name"] = "Renamed Plan"
+	plan["sessionsCompleted"] = 9
+	plan["hasNewPlanSuggestion"] = true
+	plan["suggestedPlan"] = gin.H{"name": "Injected", "reason": "Not from the server"}
+	exercise["reps"] = 12
+	status, body = doRequest(t, r, http.MethodPut, "/ai/workout-plan/1", plan)
+	if status != http.StatusOK {
+		t.Fatalf("update: status = %d, body %v", status, body)
+	}
+	updated, _ := body["data"].(map[string]interface{})
+	if updated["name"] != "Renamed Plan" || updated["sessionsCompleted"] != float64(0) || updated["hasNewPlanSuggestion"] != false || updated["suggestedPlan"] != nil {
+		t.Errorf("updated plan = %v, want only the name changed", updated)
+	}
+	updatedSessions, _ := updated["sessions"].([]interface{})
+	updatedFirst, _ := updatedSessions[0].(map[string]interface{})
+	updatedExercise, _ := updatedFirst["exercises"].([]interface{})[0].(map[string]interface{})
+	if updatedFirst["id"] != first["id"] || updatedExercise["id"] != exercise["id"] || updatedExercise["reps"] != float64(12) {
+		t.Errorf("updated session = %v, want the same IDs with 12 reps", updatedFirst)
+	}
+
+	exercise["sets"] = 0
+	status, body = doRequest(t, r, http.MethodPut, "/ai/workout-plan/1", plan)
+	if status != http.StatusUnprocessableEntity || body["code"] != "invalid_plan" {
+		t.Errorf("invalid update: status = %d, body %v; want 422 invalid_plan", status, body)
+	}
+
+	if status, body = doRequest(t, r, http.MethodDelete, "/ai/workout-plan/1", nil); status != http.StatusOK {
+		t.Fatalf("delete: status = %d, body %v", status, body)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		if status, body = doRequest(t, r, method, "/ai/workout-plan/1", plan); status != http.StatusNotFound {
+			t.Errorf("%s after delete: status = %d, body %v; want 404", method, status, body)
+		}
+	}
+}
+
+func TestAIHandlerAccess(t *testing.T) {
+	plan := &models.WorkoutPlan{Name: "Owned", Sessions: []models.WorkoutSession{{Name: "Day 1", Exercises: []models.Exercise{{
+		Name: "Push-Up", Sets: 3, Reps: 10, Weight: models.WeightInfo{Unit: services.WeightUnitBodyweight}, Type: "bodyweight",
+	}}}}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		path      string
+		want      int
+	}{
+		{"owner reads their plan", &auth.Principal{UID: "user-1"}, http.MethodGet, "/ai/workout-plan/1", http.StatusOK},
+		{"admin reads any plan", &auth.Principal{UID: "admin", Admin: true}, http.MethodGet, "/ai/workout-plan/1", http.StatusOK},
+		{"other user reads the plan", &auth.Principal{UID: "user-2"}, http.MethodGet, "/ai/workout-plan/1", http.StatusForbidden},
+		{"other user deletes the plan", &auth.Principal{UID: "user-2"}, http.MethodDelete, "/ai/workout-plan/1", http.StatusForbidden},
+		{"other user lists the plans", &auth.Principal{UID: "user-2"}, http.MethodGet, "/ai/workout-plans/user-1", http.StatusForbidden},
+		{"other user generates a plan", &auth.Principal{UID: "user-2"}, http.MethodPost, "/ai/workout-plan/user-1", http.StatusForbidden},
+		{"unknown plan", &auth.Principal{UID: "user-1"}, http.MethodGet, "/ai/workout-plan/99", http.StatusNotFound},
+		{"invalid plan ID", &auth.Principal{UID: "user-1"}, http.MethodGet, "/ai/workout-plan/abc", http.StatusBadRequest},
+		{"user without a profile", &auth.Principal{UID: "ghost"}, http.MethodPost, "/ai/workout-plan/ghost", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, plans := newAIRouter(t, tt.principal)
+			if _, err := plans.Create(context.Background(), "user-1", plan); err != nil {
+				t.Fatal(err)
+			}
+			status, body := doRequest(t, r, tt.method, tt.path, nil)
+			if status != tt.want {
+				t.Errorf("status = %d, want %d (body %v)", status, tt.want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+	"fit-ai-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultCatalogPageSize is the number of exercises returned when no limit is given
+	defaultCatalogPageSize = 50
+	// maxCatalogPageSize caps the limit query parameter
+	maxCatalogPageSize = 200
+	// defaultResolveCandidates is the number of candidates returned by ResolveExercise
+	defaultResolveCandidates = 5
+)
+
+// CatalogHandler serves the exercise catalog
+type CatalogHandler struct {
+	catalog *services.ExerciseCatalog
+}
+
+// NewCatalogHandler creates a new catalog handler
+func NewCatalogHandler(catalog *services.ExerciseCatalog) *CatalogHandler {
+	return &CatalogHandler{catalog: catalog}
+}
+
+// SearchExercises lists catalog exercises. The optional query parameters q (name or
+// alias), muscle, pattern and difficulty filter the list; equipment is a comma-separated
+// list of available equipment, and an empty value leaves only bodyweight exercises.
+// limit and offset page through the results.
+func (h *CatalogHandler) SearchExercises(c *gin.Context) {
+	filter := repositories.ExerciseCatalogFilter{
+		Query:      strings.TrimSpace(c.Query("q")),
+		Muscle:     c.Query("muscle"),
+		Pattern:    c.Query("pattern"),
+		Difficulty: c.Query("difficulty"),
+		Limit:      defaultCatalogPageSize,
+	}
+	if !validTaxonomyValue(c, "muscle", filter.Muscle, models.MuscleGroups) ||
+		!validTaxonomyValue(c, "pattern", filter.Pattern, models.MovementPatterns) ||
+		!validTaxonomyValue(c, "difficulty", filter.Difficulty, models.Difficulties) {
+		return
+	}
+	if equipment, ok := c.GetQuery("equipment"); ok {
+		filter.Equipment = []string{}
+		for _, item := range strings.Split(equipment, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			if !validTaxonomyValue(c, "equipment", item, models.EquipmentTypes) {
+				return
+			}
+			filter.Equipment = append(filter.Equipment, item)
+		}
+	}
+
+	var ok bool
+	if filter.Limit, ok = intQuery(c, "limit", defaultCatalogPageSize, 1, maxCatalogPageSize); !ok {
+		return
+	}
+	if filter.Offset, ok = intQuery(c, "offset", 0, 0, -1); !ok {
+		return
+	}
+
+	exercises, err := h.catalog.Search(c.Request.Context(), filter)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    exercises,
+		"count":   len(exercises),
+	})
+}
+
+// GetExercise returns a catalog exercise by ID
+func (h *CatalogHandler) GetExercise(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid exercise ID format",
+		})
+		return
+	}
+
+	exercise, err := h.catalog.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    exercise,
+	})
+}
+
+// ResolveExercise matches the free-text name query parameter against the catalog and
+// returns the closest exercises, best first; data is the resolved exercise or null when
+// no candidate is close enough
+func (h *CatalogHandler) ResolveExercise(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name query parameter is required",
+		})
+		return
+	}
+	limit, ok := intQuery(c, "limit", defaultResolveCandidates, 1, maxCatalogPageSize)
+	if !ok {
+		return
+	}
+
+	candidates, err := h.catalog.Rank(c.Request.Context(), name, limit)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	var resolved *models.CatalogExercise
+	if len(candidates) > 0 && candidates[0].Resolved {
+		resolved = &candidates[0].Exercise
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       resolved,
+		"candidates": candidates,
+	})
+}
+
+// GetTaxonomy lists the muscle groups, movement patterns, equipment and difficulty
+// levels used by the catalog
+func (h *CatalogHandler) GetTaxonomy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"muscles":      models.MuscleGroups,
+			"patterns":     models.MovementPatterns,
+			"equipment":    models.EquipmentTypes,
+			"difficulties": models.Difficulties,
+		},
+	})
+}
+
+// validTaxonomyValue checks that a filter value is empty or one of allowed, writing a 400
+// response and returning false when it isn't
+func validTaxonomyValue(c *gin.Context, param, value string, allowed []string) bool {
+	if value == "" {
+		return true
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": "Unknown " + param + " " + strconv.Quote(value) + "; see /exercises/taxonomy",
+		"code":  "invalid_filter",
+	})
+	return false
+}
+
+// intQuery parses an integer query parameter between lowest and highest (no upper bound
+// when highest is negative), writing a 400 response and returning false when it is invalid
+func intQuery(c *gin.Context, param string, def, lowest, highest int) (int, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return def, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < lowest || (highest >= 0 && parsed > highest) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + param + " query parameter",
+		})
+		return 0, false
+	}
+	return parsed, true
+}
+
+// respondCatalogError writes the response for a failed catalog lookup
+func respondCatalogError(c *gin.Context, err error) {
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Exercise not found",
+		})
+		return
+	}
+	if respondContextError(c, "Exercise catalog lookup", err) {
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "Failed to read exercise catalog: " + err.Error(),
+	})
+}
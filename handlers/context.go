@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusClientClosedRequest is the non-standard status (popularised by nginx) used when
+// the client went away before the response was ready
+const statusClientClosedRequest = 499
+
+// contextErrorResponse builds the response for a cancelled or timed out operation and
+// reports whether err was one. Cancellations are logged separately from failures so
+// client disconnects don't look like backend errors.
+func contextErrorResponse(c *gin.Context, operation string, err error) (int, gin.H, bool) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		log.Printf("%s canceled: client disconnected (%s %s)", operation, c.Request.Method, c.Request.URL.Path)
+		return statusClientClosedRequest, gin.H{
+			"error": operation + " canceled by client",
+			"code":  "canceled",
+		}, true
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("%s timed out (%s %s)", operation, c.Request.Method, c.Request.URL.Path)
+		return http.StatusGatewayTimeout, gin.H{
+			"error": operation + " timed out",
+			"code":  "deadline_exceeded",
+		}, true
+	default:
+		return 0, nil, false
+	}
+}
+
+// respondContextError writes the response for a cancelled or timed out operation and
+// reports whether err was one
+func respondContextError(c *gin.Context, operation string, err error) bool {
+	status, body, ok := contextErrorResponse(c, operation, err)
+	if ok {
+		c.JSON(status, body)
+	}
+	return ok
+}
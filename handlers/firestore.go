@@ -1,20 +1,36 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 
+	"fit-ai-api/auth"
 	"fit-ai-api/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// FirestoreHandler serves documents from the allowlisted Firestore collections. Callers
+// may only read documents whose uid is their own, unless they are admins, and only the
+// fields configured for the collection are returned.
 type FirestoreHandler struct {
-	firebaseService *services.FirebaseService
+	documents DocumentSource
+	allowlist services.FirestoreAllowlist
 }
 
-func NewFirestoreHandler(firebaseService *services.FirebaseService) *FirestoreHandler {
+// DocumentSource fetches Firestore documents by collection and ID; it is implemented by
+// *services.FirebaseService
+type DocumentSource interface {
+	GetDocumentByID(ctx context.Context, collection, docID string) (map[string]interface{}, error)
+}
+
+// NewFirestoreHandler creates a Firestore handler exposing the collections in allowlist
+func NewFirestoreHandler(documents DocumentSource, allowlist services.FirestoreAllowlist) *FirestoreHandler {
 	return &FirestoreHandler{
-		firebaseService: firebaseService,
+		documents: documents,
+		allowlist: allowlist,
 	}
 }
 
@@ -31,21 +47,7 @@ func (h *FirestoreHandler) GetDocumentByID(c *gin.Context) {
 	// Default collection name, can be overridden by query parameter
 	collection := c.DefaultQuery("collection", "users")
 
-	data, err := h.firebaseService.GetDocumentByIDFromCollection(collection, docID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Document not found",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success":     true,
-		"data":        data,
-		"document_id": docID,
-		"collection":  collection,
-	})
+	h.serveDocument(c, collection, docID)
 }
 
 // GetDocumentByIDWithCollection retrieves a document from a specific collection
@@ -60,18 +62,49 @@ func (h *FirestoreHandler) GetDocumentByIDWithCollection(c *gin.Context) {
 		return
 	}
 
-	data, err := h.firebaseService.GetDocumentByIDFromCollection(collection, docID)
+	h.serveDocument(c, collection, docID)
+}
+
+// serveDocument writes the projected document, or 403 for a collection that isn't
+// allowlisted or a document owned by someone else, 404 for a missing document and 502
+// when Firestore fails
+func (h *FirestoreHandler) serveDocument(c *gin.Context, collection, docID string) {
+	rules, err := h.allowlist.Collection(collection)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Document not found",
-			"details": err.Error(),
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Collection " + collection + " is not readable through the API",
+			"code":  "collection_not_allowed",
 		})
 		return
 	}
 
+	data, err := h.documents.GetDocumentByID(c.Request.Context(), collection, docID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Document not found",
+				"code":  "not_found",
+			})
+		case respondContextError(c, "Fetching document", err):
+		default:
+			log.Printf("Fetching %s/%s failed: %v", collection, docID, err)
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error": "Failed to fetch document from Firestore",
+				"code":  "backend_error",
+			})
+		}
+		return
+	}
+
+	if !auth.CanAccess(c, rules.Owner(data)) {
+		auth.RespondForbidden(c)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":     true,
-		"data":        data,
+		"data":        rules.Project(data),
 		"document_id": docID,
 		"collection":  collection,
 	})
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/services"
+)
+
+// fakeDocuments serves documents by collection/ID, failing with err when it is set
+type fakeDocuments struct {
+	docs map[string]map[string]interface{}
+	err  error
+}
+
+func (f *fakeDocuments) GetDocumentByID(ctx context.Context, collection, docID string) (map[string]interface{}, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	doc, ok := f.docs[collection+"/"+docID]
+	if !ok {
+		return nil, services.ErrDocumentNotFound
+	}
+	return doc, nil
+}
+
+// newFirestoreRouter serves the Firestore routes as principal, exposing the uid and
+// displayName of users and every field of badges
+func newFirestoreRouter(t *testing.T, principal *auth.Principal, documents DocumentSource) *gin.Engine {
+	t.Helper()
+	allowlist, err := services.ParseFirestoreAllowlist("users:uid,displayName;badges:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewFirestoreHandler(documents, allowlist)
+
+	r := gin.New()
+	r.Use(as(principal))
+	r.GET("/firestore/:id", h.GetDocumentByID)
+	r.GET("/firestore/collection/:collection/:id", h.GetDocumentByIDWithCollection)
+	return r
+}
+
+func TestFirestoreHandler(t *testing.T) {
+	documents := &fakeDocuments{docs: map[string]map[string]interface{}{
+		"users/user-1":   {"uid": "user-1", "displayName": "Sam", "dateOfBirth": "1990-01-01"},
+		"badges/first":   {"uid": "user-1", "title": "First workout"},
+		"workouts/w1":    {"uid": "user-1"},
+		"users/orphaned": {"displayName": "No owner"},
+	}}
+	user := &auth.Principal{UID: "user-1"}
+	other := &auth.Principal{UID: "user-2"}
+	admin := &auth.Principal{UID: "admin", Admin: true}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		documents DocumentSource
+		path      string
+		want      int
+		wantCode  string
+		wantData  map[string]interface{}
+	}{
+		{"own document, projected", user, documents, "/firestore/user-1", http.StatusOK, "", map[string]interface{}{"uid": "user-1", "displayName": "Sam"}},
+		{"every field of a * collection", user, documents, "/firestore/collection/badges/first", http.StatusOK, "", map[string]interface{}{"uid": "user-1", "title": "First workout"}},
+		{"admin reads another user's document", admin, documents, "/firestore/collection/users/user-1", http.StatusOK, "", map[string]interface{}{"uid": "user-1", "displayName": "Sam"}},
+		{"another user's document", other, documents, "/firestore/user-1", http.StatusForbidden, "", nil},
+		{"document without an owner", user, documents, "/firestore/orphaned", http.StatusForbidden, "", nil},
+		{"collection not allowlisted", admin, documents, "/firestore/collection/workouts/w1", http.StatusForbidden, "collection_not_allowed", nil},
+		{"collection query not allowlisted", admin, documents, "/firestore/w1?collection=workouts", http.StatusForbidden, "collection_not_allowed", nil},
+		{"missing document", user, documents, "/firestore/collection/users/nobody", http.StatusNotFound, "not_found", nil},
+		{"Firestore failure", user, &fakeDocuments{err: errors.New("unavailable")}, "/firestore/user-1", http.StatusBadGateway, "backend_error", nil},
+		{"Firestore timeout", user, &fakeDocuments{err: context.DeadlineExceeded}, "/firestore/user-1", http.StatusGatewayTimeout, "deadline_exceeded", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newFirestoreRouter(t, tt.principal, tt.documents)
+
+			status, body := doRequest(t, r, http.MethodGet, tt.path, nil)
+			if status != tt.want {
+				t.Fatalf("status = %d, want %d (body %v)", status, tt.want, body)
+			}
+			if tt.wantCode != "" && body["code"] != tt.wantCode {
+				t.Errorf("code = %v, want %s", body["code"], tt.wantCode)
+			}
+			if tt.wantData == nil {
+				return
+			}
+			data, _ := body["data"].(map[string]interface{})
+			if len(data) != len(tt.wantData) {
+				t.Errorf("data = %v, want %v", data, tt.wantData)
+			}
+			for field, value := range tt.wantData {
+				if data[field] != value {
+					t.Errorf("data = %v, want %v", data, tt.wantData)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// as authenticates every request as principal, standing in for auth.Middleware
+func as(principal *auth.Principal) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth.SetPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// doRequest sends a request with body, encoded as JSON unless it is nil, through router
+// and decodes the JSON response
+func doRequest(t *testing.T, router http.Handler, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(content)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s: decoding response %q: %v", method, path, w.Body.String(), err)
+	}
+	return w.Code, decoded
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// AliasParam exposes the path parameter `from` under the name `to` as well.
+// Gin requires routes that share a path prefix to use the same wildcard name, so
+// routes such as /ai/workout-plan/:id (a plan) and /ai/workout-plan/:id/stream (a user)
+// register a neutral name and alias it to the one the handler reads.
+func AliasParam(from, to string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Params.Get(from); ok {
+			c.Params = append(c.Params, gin.Param{Key: to, Value: value})
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"fit-ai-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SuggestionHandler lets users accept or reject the plan suggested by the AI feedback
+// cycle
+type SuggestionHandler struct {
+	reviews *services.PlanReviewService
+}
+
+// NewSuggestionHandler creates a new suggestion handler
+func NewSuggestionHandler(reviews *services.PlanReviewService) *SuggestionHandler {
+	return &SuggestionHandler{reviews: reviews}
+}
+
+// AcceptSuggestion replaces a workout plan with its suggested plan
+func (h *SuggestionHandler) AcceptSuggestion(c *gin.Context) {
+	id, ok := parsePlanID(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.reviews.AcceptSuggestion(c.Request.Context(), id)
+	if err != nil {
+		respondSuggestionError(c, "accept", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Suggested plan accepted",
+		"data":    plan,
+	})
+}
+
+// RejectSuggestion dismisses the suggested plan and keeps the current one
+func (h *SuggestionHandler) RejectSuggestion(c *gin.Context) {
+	id, ok := parsePlanID(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.reviews.RejectSuggestion(c.Request.Context(), id)
+	if err != nil {
+		respondSuggestionError(c, "reject", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Suggested plan rejected",
+		"data":    plan,
+	})
+}
+
+// respondSuggestionError writes the response for a failed accept or reject
+func respondSuggestionError(c *gin.Context, action string, err error) {
+	if errors.Is(err, services.ErrNoSuggestion) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Workout plan has no suggested plan to " + action,
+			"code":  "no_suggestion",
+		})
+		return
+	}
+	respondPlanError(c, "update", err)
+}
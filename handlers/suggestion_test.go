@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+	"fit-ai-api/services"
+)
+
+// newSuggestionRouter serves the suggestion routes on an in-memory repository holding
+// plan 1, which has a suggestion when suggested is set
+func newSuggestionRouter(t *testing.T, suggested bool) *gin.Engine {
+	t.Helper()
+	plans := repositories.NewMemoryWorkoutPlanRepository()
+	plan := &models.WorkoutPlan{Name: "Current", AIFeedbackCycle: 12, PlanValidityPeriod: 28, Sessions: []models.WorkoutSession{{
+		Name: "Day 1", Exercises: []models.Exercise{{Name: "Push-Up", Sets: 3, Reps: 10, Weight: models.WeightInfo{Unit: services.WeightUnitBodyweight}, Type: "bodyweight"}},
+	}}}
+	if _, err := plans.Create(context.Background(), "user-1", plan); err != nil {
+		t.Fatal(err)
+	}
+	if suggested {
+		suggestion := &models.SuggestedPlan{Name: "Suggested", Reason: "Progress stalled", Sessions: []models.WorkoutSession{{
+			Name: "Day A", Exercises: []models.Exercise{{Name: "Dip", Sets: 4, Reps: 8, Weight: models.WeightInfo{Unit: services.WeightUnitBodyweight}, Type: "bodyweight"}},
+		}}}
+		if _, err := plans.SetSuggestion(context.Background(), 1, suggestion); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewSuggestionHandler(services.NewPlanReviewService(plans, repositories.NewMemoryWorkoutLogRepository(), repositories.NewMemoryProfileRepository(), nil, nil, nil))
+	r := gin.New()
+	asPlanID := AliasParam("id", "plan_id")
+	r.POST("/ai/workout-plan/:id/suggestion/accept", asPlanID, h.AcceptSuggestion)
+	r.POST("/ai/workout-plan/:id/suggestion/reject", asPlanID, h.RejectSuggestion)
+	return r
+}
+
+func TestSuggestionHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		suggested bool
+		action    string
+		planID    string
+		want      int
+		wantName  string
+	}{
+		{"accept", true, "accept", "1", http.StatusOK, "Suggested"},
+		{"reject", true, "reject", "1", http.StatusOK, "Current"},
+		{"accept without a suggestion", false, "accept", "1", http.StatusConflict, ""},
+		{"reject without a suggestion", false, "reject", "1", http.StatusConflict, ""},
+		{"unknown plan", true, "accept", "99", http.StatusNotFound, ""},
+		{"invalid plan ID", true, "reject", "abc", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSuggestionRouter(t, tt.suggested)
+
+			status, body := doRequest(t, r, http.MethodPost, "/ai/workout-plan/"+tt.planID+"/suggestion/"+tt.action, nil)
+			if status != tt.want {
+				t.Fatalf("status = %d, want %d (body %v)", status, tt.want, body)
+			}
+			if status == http.StatusConflict && body["code"] != "no_suggestion" {
+				t.Errorf("body %v, want code no_suggestion", body)
+			}
+			if tt.wantName == "" {
+				return
+			}
+			plan, _ := body["data"].(map[string]interface{})
+			if plan["name"] != tt.wantName || plan["hasNewPlanSuggestion"] != false || plan["suggestedPlan"] != nil {
+				t.Errorf("plan = %v, want %q without a suggestion", plan, tt.wantName)
+			}
+		})
+	}
+}
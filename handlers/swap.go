@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"fit-ai-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultAlternatives is the number of alternatives returned when no limit is given
+	defaultAlternatives = 5
+	// maxAlternatives caps the limit query parameter
+	maxAlternatives = 10
+)
+
+// SwapHandler suggests substitutes for the exercises of saved plans and swaps them in
+type SwapHandler struct {
+	swaps *services.ExerciseSwapService
+}
+
+// NewSwapHandler creates a new swap handler
+func NewSwapHandler(swaps *services.ExerciseSwapService) *SwapHandler {
+	return &SwapHandler{swaps: swaps}
+}
+
+// alternativesRequest optionally says why the user wants to swap an exercise
+type alternativesRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GetAlternatives returns ranked substitutes for an exercise of a plan that work the
+// same muscles with the same movement pattern and fit the user's equipment. The body is
+// optional; limit caps the number of alternatives.
+func (h *SwapHandler) GetAlternatives(c *gin.Context) {
+	planID, sessionID, exerciseID, ok := parseExercisePath(c)
+	if !ok {
+		return
+	}
+
+	var req alternativesRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	limit, ok := intQuery(c, "limit", defaultAlternatives, 1, maxAlternatives)
+	if !ok {
+		return
+	}
+
+	alternatives, err := h.swaps.Alternatives(c.Request.Context(), planID, sessionID, exerciseID, req.Reason, limit)
+	if err != nil {
+		respondSwapError(c, "fetch", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    alternatives,
+		"count":   len(alternatives.Alternatives),
+	})
+}
+
+// SwapExercise replaces an exercise of a plan with the one named in the body, keeping
+// its sets and reps
+func (h *SwapHandler) SwapExercise(c *gin.Context) {
+	planID, sessionID, exerciseID, ok := parseExercisePath(c)
+	if !ok {
+		return
+	}
+
+	var req services.SwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.swaps.Swap(c.Request.Context(), planID, sessionID, exerciseID, req)
+	if err != nil {
+		respondSwapError(c, "update", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Exercise swapped",
+		"data":    result,
+	})
+}
+
+// parseExercisePath reads the plan_id, session_id and exercise_id parameters, writing a
+// 400 response when they are invalid
+func parseExercisePath(c *gin.Context) (uint, string, int, bool) {
+	planID, ok := parsePlanID(c)
+	if !ok {
+		return 0, "", 0, false
+	}
+	sessionID := c.Param("session_id")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Session ID is required",
+		})
+		return 0, "", 0, false
+	}
+	exerciseID, err := strconv.Atoi(c.Param("exercise_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid exercise ID format",
+		})
+		return 0, "", 0, false
+	}
+	return planID, sessionID, exerciseID, true
+}
+
+// respondSwapError writes the response for a failed alternatives lookup or swap
+func respondSwapError(c *gin.Context, action string, err error) {
+	var providerErr *services.ProviderError
+	var validationErr *services.PlanValidationError
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound), errors.Is(err, services.ErrExerciseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidSwap):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "invalid_swap",
+		})
+	case errors.As(err, &providerErr), errors.As(err, &validationErr),
+		errors.Is(err, services.ErrNoProviderAvailable), errors.Is(err, services.ErrProviderNotConfigured):
+		respondAIError(c, err)
+	default:
+		respondPlanError(c, action, err)
+	}
+}
@@ -1,32 +1,34 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
-	
+
+	"fit-ai-api/auth"
 	"fit-ai-api/models"
+	"fit-ai-api/repositories"
 )
 
 type UserHandler struct {
-	db *gorm.DB
+	users repositories.UserRepository
 }
 
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{db: db}
+func NewUserHandler(users repositories.UserRepository) *UserHandler {
+	return &UserHandler{users: users}
 }
 
 // GetUsers returns all users
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	var users []models.User
-	
-	if err := h.db.Find(&users).Error; err != nil {
+	users, err := h.users.List(c.Request.Context())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"users": users,
 	})
@@ -40,13 +42,13 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
-	
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+
+	user, err := h.users.Get(c.Request.Context(), uint(userID))
+	if err != nil {
+		respondUserError(c, err, "Failed to fetch user")
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"user": user,
 	})
@@ -55,17 +57,20 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 // CreateUser creates a new user
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var user models.User
-	
+
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
 		return
 	}
-	
-	if err := h.db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+
+	user.FirebaseUID = normalizeUID(user.FirebaseUID)
+	user.Email = normalizeEmail(user.Email)
+
+	if err := h.users.Create(c.Request.Context(), &user); err != nil {
+		respondUserError(c, err, "Failed to create user")
 		return
 	}
-	
+
 	c.JSON(http.StatusCreated, gin.H{
 		"user": user,
 	})
@@ -79,28 +84,38 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
-	
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+
+	user, err := h.users.Get(c.Request.Context(), uint(userID))
+	if err != nil {
+		respondUserError(c, err, "Failed to fetch user")
 		return
 	}
-	
+
 	var updateData models.User
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
 		return
 	}
-	
-	// Update only the allowed fields
+
+	// Update only the allowed fields. Only admins may relink the Firebase UID or grant
+	// admin rights, so users editing their own record can't take over another account.
 	user.Name = updateData.Name
 	user.Age = updateData.Age
-	
-	if err := h.db.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	user.Email = normalizeEmail(updateData.Email)
+	// Stats are maintained by the server as workouts are completed
+	stats := user.Profile.Stats
+	user.Profile = updateData.Profile
+	user.Profile.Stats = stats
+	if principal, ok := auth.FromContext(c); ok && principal.Admin {
+		user.FirebaseUID = normalizeUID(updateData.FirebaseUID)
+		user.Admin = updateData.Admin
+	}
+
+	if err := h.users.Update(c.Request.Context(), user); err != nil {
+		respondUserError(c, err, "Failed to update user")
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"user": user,
 	})
@@ -114,17 +129,70 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
-	
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+
+	if err := h.users.Delete(c.Request.Context(), uint(userID)); err != nil {
+		respondUserError(c, err, "Failed to delete user")
 		return
 	}
-	
-	if err := h.db.Delete(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// RequireUserOwner is middleware for /users/:id that only lets the user whose Firebase
+// UID matches the caller, and admins, through
+func (h *UserHandler) RequireUserOwner(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
-	
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
-} 
\ No newline at end of file
+
+	user, err := h.users.Get(c.Request.Context(), uint(userID))
+	if err != nil {
+		respondUserError(c, err, "Failed to fetch user")
+		c.Abort()
+		return
+	}
+	uid := ""
+	if user.FirebaseUID != nil {
+		uid = *user.FirebaseUID
+	}
+	if !auth.CanAccess(c, uid) {
+		auth.RespondForbidden(c)
+		return
+	}
+	c.Next()
+}
+
+// respondUserError writes 404 for a missing user, 409 for a Firebase UID or email that is
+// already linked to another user and 500 with message otherwise
+func respondUserError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repositories.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Firebase UID or email is already linked to another user"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// normalizeUID treats an empty Firebase UID as no link
+func normalizeUID(uid *string) *string {
+	if uid == nil || *uid == "" {
+		return nil
+	}
+	return uid
+}
+
+// normalizeEmail lowercases an email and treats an empty one as unset
+func normalizeEmail(email *string) *string {
+	if email == nil {
+		return nil
+	}
+	normalized := strings.ToLower(strings.TrimSpace(*email))
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// newUserRouter serves the user routes on users as principal, guarded the way main.go
+// guards them
+func newUserRouter(principal *auth.Principal, users repositories.UserRepository) *gin.Engine {
+	h := NewUserHandler(users)
+	requireAdmin := auth.RequireAdmin()
+	r := gin.New()
+	r.Use(as(principal))
+	r.GET("/users", requireAdmin, h.GetUsers)
+	r.GET("/users/:id", h.RequireUserOwner, h.GetUser)
+	r.POST("/users", requireAdmin, h.CreateUser)
+	r.PUT("/users/:id", h.RequireUserOwner, h.UpdateUser)
+	r.DELETE("/users/:id", requireAdmin, h.DeleteUser)
+	return r
+}
+
+// adminPrincipal may act on any user
+var adminPrincipal = &auth.Principal{UID: "admin", Admin: true}
+
+func TestUserHandlerCRUD(t *testing.T) {
+	r := newUserRouter(adminPrincipal, repositories.NewMemoryUserRepository())
+
+	status, body := doRequest(t, r, http.MethodPost, "/users", gin.H{
+		"name": "Ada", "age": 36, "firebase_uid": "uid-1", "email": " Ada@Example.com ",
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("create: status = %d, want 201 (body %v)", status, body)
+	}
+	user, _ := body["user"].(map[string]interface{})
+	if user["id"] != float64(1) || user["firebase_uid"] != "uid-1" || user["email"] != "ada@example.com" {
+		t.Errorf("created user = %v, want ID 1 linked to uid-1 with a normalized email", user)
+	}
+
+	status, body = doRequest(t, r, http.MethodGet, "/users/1", nil)
+	if user, _ := body["user"].(map[string]interface{}); status != http.StatusOK || user["name"] != "Ada" {
+		t.Errorf("get: status = %d, body %v", status, body)
+	}
+
+	status, body = doRequest(t, r, http.MethodPut, "/users/1", gin.H{
+		"name": "Ada Lovelace", "age": 37, "firebase_uid": "", "email": "ada@example.com",
+	})
+	user, _ = body["user"].(map[string]interface{})
+	if status != http.StatusOK || user["name"] != "Ada Lovelace" || user["age"] != float64(37) || user["firebase_uid"] != nil {
+		t.Errorf("update: status = %d, body %v; want the UID unlinked", status, body)
+	}
+
+	status, body = doRequest(t, r, http.MethodGet, "/users", nil)
+	if users, _ := body["users"].([]interface{}); status != http.StatusOK || len(users) != 1 {
+		t.Errorf("list: status = %d, body %v", status, body)
+	}
+
+	if status, body = doRequest(t, r, http.MethodDelete, "/users/1", nil); status != http.StatusOK {
+		t.Errorf("delete: status = %d, body %v", status, body)
+	}
+	if status, _ = doRequest(t, r, http.MethodGet, "/users/1", nil); status != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d, want 404", status)
+	}
+}
+
+func TestUserHandlerErrors(t *testing.T) {
+	r := newUserRouter(adminPrincipal, repositories.NewMemoryUserRepository())
+	if status, body := doRequest(t, r, http.MethodPost, "/users", gin.H{"name": "Ada", "firebase_uid": "uid-1", "email": "ada@example.com"}); status != http.StatusCreated {
+		t.Fatalf("create: status = %d, body %v", status, body)
+	}
+	if status, body := doRequest(t, r, http.MethodPost, "/users", gin.H{"name": "Grace", "firebase_uid": "uid-2", "email": "grace@example.com"}); status != http.StatusCreated {
+		t.Fatalf("create: status = %d, body %v", status, body)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		{"duplicate firebase UID", http.MethodPost, "/users", gin.H{"name": "Copy", "firebase_uid": "uid-1"}, http.StatusConflict},
+		{"update to a taken firebase UID", http.MethodPut, "/users/2", gin.H{"name": "Grace", "firebase_uid": "uid-1"}, http.StatusConflict},
+		{"duplicate email", http.MethodPost, "/users", gin.H{"name": "Copy", "email": "ADA@example.com"}, http.StatusConflict},
+		{"update to a taken email", http.MethodPut, "/users/2", gin.H{"name": "Grace", "email": "ada@example.com"}, http.StatusConflict},
+		{"invalid body", http.MethodPost, "/users", "not an object", http.StatusBadRequest},
+		{"invalid ID", http.MethodGet, "/users/abc", nil, http.StatusBadRequest},
+		{"get unknown user", http.MethodGet, "/users/99", nil, http.StatusNotFound},
+		{"update unknown user", http.MethodPut, "/users/99", gin.H{"name": "Nobody"}, http.StatusNotFound},
+		{"delete unknown user", http.MethodDelete, "/users/99", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doRequest(t, r, tt.method, tt.path, tt.body)
+			if status != tt.want {
+				t.Errorf("status = %d, want %d (body %v)", status, tt.want, body)
+			}
+			if body["error"] == nil {
+				t.Errorf("body %v has no error", body)
+			}
+		})
+	}
+}
+
+func TestUserHandlerAccess(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		path      string
+		body      interface{}
+		want      int
+	}{
+		{"user reads their own record", &auth.Principal{UID: "uid-1"}, http.MethodGet, "/users/1", nil, http.StatusOK},
+		{"user updates their own record", &auth.Principal{UID: "uid-1"}, http.MethodPut, "/users/1", gin.H{"name": "Ada Lovelace"}, http.StatusOK},
+		{"user reads another record", &auth.Principal{UID: "uid-2"}, http.MethodGet, "/users/1", nil, http.StatusForbidden},
+		{"user updates another record", &auth.Principal{UID: "uid-2"}, http.MethodPut, "/users/1", gin.H{"name": "Mallory"}, http.StatusForbidden},
+		{"user lists users", &auth.Principal{UID: "uid-1"}, http.MethodGet, "/users", nil, http.StatusForbidden},
+		{"user creates a user", &auth.Principal{UID: "uid-1"}, http.MethodPost, "/users", gin.H{"name": "Copy"}, http.StatusForbidden},
+		{"user deletes their own record", &auth.Principal{UID: "uid-1"}, http.MethodDelete, "/users/1", nil, http.StatusForbidden},
+		{"admin reads any record", adminPrincipal, http.MethodGet, "/users/1", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := repositories.NewMemoryUserRepository()
+			uid := "uid-1"
+			if err := users.Create(context.Background(), &models.User{Name: "Ada", FirebaseUID: &uid}); err != nil {
+				t.Fatal(err)
+			}
+			r := newUserRouter(tt.principal, users)
+
+			status, body := doRequest(t, r, tt.method, tt.path, tt.body)
+			if status != tt.want {
+				t.Errorf("status = %d, want %d (body %v)", status, tt.want, body)
+			}
+		})
+	}
+}
+
+func TestUserHandlerSelfUpdateKeepsServerFields(t *testing.T) {
+	users := repositories.NewMemoryUserRepository()
+	uid := "uid-1"
+	user := &models.User{Name: "Ada", FirebaseUID: &uid}
+	user.Profile.Stats = models.UserStats{TotalWorkouts: 12, TotalVolume: 5400}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	r := newUserRouter(&auth.Principal{UID: uid}, users)
+
+	status, body := doRequest(t, r, http.MethodPut, "/users/1", gin.H{
+		"name": "Ada Lovelace", "admin": true, "firebase_uid": "uid-2",
+		"profile": gin.H{"fitnessLevel": "advanced", "stats": gin.H{"totalWorkouts": 999}},
+	})
+	updated, _ := body["user"].(map[string]interface{})
+	if status != http.StatusOK || updated["name"] != "Ada Lovelace" || updated["admin"] != false || updated["firebase_uid"] != uid {
+		t.Errorf("self update: status = %d, body %v; want the name changed but not admin or firebase_uid", status, body)
+	}
+
+	stored, err := users.Get(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Profile.FitnessLevel != "advanced" || stored.Profile.Stats != user.Profile.Stats {
+		t.Errorf("stored profile = %+v, want the new fitness level with the stats kept", stored.Profile)
+	}
+}
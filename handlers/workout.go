@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/repositories"
+	"fit-ai-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkoutHandler handles logging of workouts performed from saved plans
+type WorkoutHandler struct {
+	workouts *services.WorkoutService
+}
+
+// NewWorkoutHandler creates a new workout handler
+func NewWorkoutHandler(workouts *services.WorkoutService) *WorkoutHandler {
+	return &WorkoutHandler{workouts: workouts}
+}
+
+// startWorkoutRequest selects the plan session being performed
+type startWorkoutRequest struct {
+	PlanID    uint   `json:"planId" binding:"required"`
+	SessionID string `json:"sessionId" binding:"required"`
+}
+
+// StartWorkout starts logging a session of a workout plan
+func (h *WorkoutHandler) StartWorkout(c *gin.Context) {
+	var req startWorkoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	plan, err := h.workouts.Plan(c.Request.Context(), req.PlanID)
+	if err != nil {
+		respondPlanError(c, "fetch", err)
+		return
+	}
+	if !auth.CanAccess(c, plan.UserID) {
+		auth.RespondForbidden(c)
+		return
+	}
+
+	workout, err := h.workouts.Start(c.Request.Context(), req.PlanID, req.SessionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Workout plan not found",
+			})
+			return
+		}
+		respondWorkoutError(c, "start", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Workout started",
+		"data":    workout,
+	})
+}
+
+// GetWorkouts lists the workouts of the user given by the user_id query parameter
+func (h *WorkoutHandler) GetWorkouts(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "user_id query parameter is required",
+		})
+		return
+	}
+
+	workouts, err := h.workouts.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		respondWorkoutError(c, "fetch", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    workouts,
+		"count":   len(workouts),
+	})
+}
+
+// GetWorkout returns a workout with its logged sets
+func (h *WorkoutHandler) GetWorkout(c *gin.Context) {
+	id, ok := parseWorkoutID(c)
+	if !ok {
+		return
+	}
+
+	workout, err := h.workouts.Get(c.Request.Context(), id)
+	if err != nil {
+		respondWorkoutError(c, "fetch", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    workout,
+	})
+}
+
+// LogSet records a performed set for an exercise of the workout's session
+func (h *WorkoutHandler) LogSet(c *gin.Context) {
+	id, ok := parseWorkoutID(c)
+	if !ok {
+		return
+	}
+
+	var input services.SetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	set, err := h.workouts.LogSet(c.Request.Context(), id, input)
+	if err != nil {
+		respondWorkoutError(c, "update", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    set,
+	})
+}
+
+// CompleteWorkout finishes a workout and updates the plan progress and user statistics.
+// The body is optional.
+func (h *WorkoutHandler) CompleteWorkout(c *gin.Context) {
+	id, ok := parseWorkoutID(c)
+	if !ok {
+		return
+	}
+
+	var input services.CompleteInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request body: " + err.Error(),
+			})
+			return
+		}
+	}
+	if input.DurationSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "durationSeconds cannot be negative",
+		})
+		return
+	}
+
+	result, err := h.workouts.Complete(c.Request.Context(), id, input)
+	if err != nil {
+		respondWorkoutError(c, "complete", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Workout completed",
+		"data":    result,
+	})
+}
+
+// RequireWorkoutOwner is middleware for routes on a single workout that only lets the
+// workout's owner, or an admin, through
+func (h *WorkoutHandler) RequireWorkoutOwner(c *gin.Context) {
+	id, ok := parseWorkoutID(c)
+	if !ok {
+		c.Abort()
+		return
+	}
+
+	workout, err := h.workouts.Get(c.Request.Context(), id)
+	if err != nil {
+		respondWorkoutError(c, "fetch", err)
+		c.Abort()
+		return
+	}
+	if !auth.CanAccess(c, workout.UserID) {
+		auth.RespondForbidden(c)
+		return
+	}
+	c.Next()
+}
+
+// parseWorkoutID reads the id parameter, writing a 400 response when it is not a
+// positive integer
+func parseWorkoutID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid workout ID format",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondWorkoutError writes the response for a failed workout operation
+func respondWorkoutError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Workout not found",
+		})
+	case errors.Is(err, services.ErrSessionNotFound), errors.Is(err, services.ErrExerciseNotFound):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidSet):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrWorkoutCompleted):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		if respondContextError(c, "Workout "+action, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to " + action + " workout: " + err.Error(),
+		})
+	}
+}
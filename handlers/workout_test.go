@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+	"fit-ai-api/services"
+)
+
+// newWorkoutRouter serves the workout routes as principal, guarded the way main.go guards
+// them, on in-memory repositories holding plan 1 of user-1: session "1" with exercises 1
+// and 2
+func newWorkoutRouter(t *testing.T, principal *auth.Principal) *gin.Engine {
+	t.Helper()
+	plans := repositories.NewMemoryWorkoutPlanRepository()
+	plan := &models.WorkoutPlan{Name: "Strength", Sessions: []models.WorkoutSession{{Name: "Day 1", Exercises: []models.Exercise{
+		{Name: "Barbell Back Squat", Sets: 3, Reps: 5, Weight: models.WeightInfo{Value: 100, Unit: services.WeightUnitKilograms}, Type: "weight"},
+		{Name: "Push-Up", Sets: 3, Reps: 10, Weight: models.WeightInfo{Unit: services.WeightUnitBodyweight}, Type: "bodyweight"},
+	}}}}
+	if _, err := plans.Create(context.Background(), "user-1", plan); err != nil {
+		t.Fatal(err)
+	}
+	profiles := repositories.NewMemoryProfileRepository()
+	profiles.Put("user-1", models.FirestoreUser{UID: "user-1"})
+	workouts := services.NewWorkoutService(plans, repositories.NewMemoryWorkoutLogRepository(), profiles, nil, services.ProgressionConfig{Scheme: services.ProgressionOff})
+	h := NewWorkoutHandler(workouts)
+
+	r := gin.New()
+	r.Use(as(principal))
+	workoutOwner := h.RequireWorkoutOwner
+	r.POST("/workouts", h.StartWorkout)
+	r.GET("/workouts", auth.RequireSelf("user_id"), h.GetWorkouts)
+	r.GET("/workouts/:id", workoutOwner, h.GetWorkout)
+	r.POST("/workouts/:id/sets", workoutOwner, h.LogSet)
+	r.POST("/workouts/:id/complete", workoutOwner, h.CompleteWorkout)
+	return r
+}
+
+func TestWorkoutHandlerLifecycle(t *testing.T) {
+	r := newWorkoutRouter(t, &auth.Principal{UID: "user-1"})
+
+	status, body := doRequest(t, r, http.MethodPost, "/workouts", gin.H{"planId": 1, "sessionId": "1"})
+	if status != http.StatusCreated {
+		t.Fatalf("start: status = %d, want 201 (body %v)", status, body)
+	}
+
+	sets := "/workouts/1/sets"
+	for i := 1; i <= 2; i++ {
+		status, body = doRequest(t, r, http.MethodPost, sets, gin.H{"exerciseId": 1, "reps": 5, "weight": gin.H{"value": 100}})
+		set, _ := body["data"].(map[string]interface{})
+		if status != http.StatusCreated || set["setNumber"] != float64(i) {
+			t.Errorf("set %d: status = %d, body %v", i, status, body)
+		}
+	}
+	status, body = doRequest(t, r, http.MethodPost, sets, gin.H{"exerciseId": 999, "reps": 10})
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("unknown exercise: status = %d, want 422 (body %v)", status, body)
+	}
+	status, body = doRequest(t, r, http.MethodPost, sets, gin.H{"exerciseId": 1, "reps": -1})
+	if status != http.StatusBadRequest {
+		t.Errorf("negative reps: status = %d, want 400 (body %v)", status, body)
+	}
+
+	status, body = doRequest(t, r, http.MethodPost, "/workouts/1/complete", gin.H{"durationSeconds": 1800})
+	data, _ := body["data"].(map[string]interface{})
+	stats, _ := data["stats"].(map[string]interface{})
+	if status != http.StatusOK || stats["totalWorkouts"] != float64(1) || stats["totalVolume"] != float64(1000) {
+		t.Errorf("complete: status = %d, body %v; want 1 workout of 1000 KG", status, body)
+	}
+	status, body = doRequest(t, r, http.MethodPost, "/workouts/1/complete", nil)
+	if status != http.StatusConflict {
+		t.Errorf("complete twice: status = %d, want 409 (body %v)", status, body)
+	}
+
+	status, body = doRequest(t, r, http.MethodGet, "/workouts?user_id=user-1", nil)
+	if status != http.StatusOK || body["count"] != float64(1) {
+		t.Errorf("list: status = %d, body %v", status, body)
+	}
+}
+
+func TestWorkoutHandlerAccess(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		path      string
+		body      interface{}
+		want      int
+	}{
+		{"owner reads the workout", &auth.Principal{UID: "user-1"}, http.MethodGet, "/workouts/1", nil, http.StatusOK},
+		{"admin reads the workout", &auth.Principal{UID: "admin", Admin: true}, http.MethodGet, "/workouts/1", nil, http.StatusOK},
+		{"other user starts a workout on the plan", &auth.Principal{UID: "user-2"}, http.MethodPost, "/workouts", gin.H{"planId": 1, "sessionId": "1"}, http.StatusForbidden},
+		{"other user reads the workout", &auth.Principal{UID: "user-2"}, http.MethodGet, "/workouts/1", nil, http.StatusForbidden},
+		{"other user logs a set", &auth.Principal{UID: "user-2"}, http.MethodPost, "/workouts/1/sets", gin.H{"exerciseId": 1, "reps": 5}, http.StatusForbidden},
+		{"other user completes the workout", &auth.Principal{UID: "user-2"}, http.MethodPost, "/workouts/1/complete", nil, http.StatusForbidden},
+		{"other user lists the workouts", &auth.Principal{UID: "user-2"}, http.MethodGet, "/workouts?user_id=user-1", nil, http.StatusForbidden},
+		{"unknown workout", &auth.Principal{UID: "user-1"}, http.MethodGet, "/workouts/99", nil, http.StatusNotFound},
+		{"invalid workout ID", &auth.Principal{UID: "user-1"}, http.MethodGet, "/workouts/abc", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// user-1 starts workout 1, then the request is sent as the principal under test
+			caller := &auth.Principal{UID: "user-1"}
+			r := newWorkoutRouter(t, caller)
+			if status, body := doRequest(t, r, http.MethodPost, "/workouts", gin.H{"planId": 1, "sessionId": "1"}); status != http.StatusCreated {
+				t.Fatalf("start: status = %d, body %v", status, body)
+			}
+			*caller = *tt.principal
+
+			status, body := doRequest(t, r, tt.method, tt.path, tt.body)
+			if status != tt.want {
+				t.Errorf("status = %d, want %d (body %v)", status, tt.want, body)
+			}
+		})
+	}
+}
@@ -4,38 +4,5 @@
 -- Create extensions if needed
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
--- Create tables for fitness tracking
--- Users table (simplified version)
-CREATE TABLE IF NOT EXISTS users (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    age INTEGER NOT NULL,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    deleted_at TIMESTAMP WITH TIME ZONE
-);
-
--- Note: Additional tables will be created by GORM when you're ready to add them
--- For now, we only have the simple users table
-
--- Create indexes for better performance
-CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
-
--- Insert some sample users (optional)
-INSERT INTO users (name, age) VALUES
-('John Doe', 30),
-('Jane Smith', 25),
-('Mike Johnson', 35)
-ON CONFLICT DO NOTHING;
-
--- Create a function to update the updated_at timestamp
-CREATE OR REPLACE FUNCTION update_updated_at_column()
-RETURNS TRIGGER AS $$
-BEGIN
-    NEW.updated_at = CURRENT_TIMESTAMP;
-    RETURN NEW;
-END;
-$$ language 'plpgsql';
-
--- Create triggers to automatically update updated_at
-CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION update_updated_at_column(); 
\ No newline at end of file
+-- Tables are created by the versioned migrations in migrations/sql.
+-- Run `make migrate-up` (or `go run main.go migrate up`) after the database starts.
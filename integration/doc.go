@@ -0,0 +1,8 @@
+// Package integration holds tests that run against a Firestore emulator. They are skipped
+// unless FIRESTORE_EMULATOR_HOST is set, e.g.
+//
+//	gcloud emulators firestore start --host-port=localhost:8090
+//	FIRESTORE_EMULATOR_HOST=localhost:8090 go test ./integration/...
+//
+// LLM calls go to an llmstub server, so no API keys or network access are needed.
+package integration
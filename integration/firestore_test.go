@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/handlers"
+	"fit-ai-api/services"
+)
+
+func TestGetDocumentByID(t *testing.T) {
+	fs := newFirebase(t)
+	uid := uniqueUID("get")
+	seedUser(t, fs, uid, map[string]interface{}{"fitnessLevel": "advanced"})
+
+	data, err := fs.GetDocumentByID(context.Background(), "users", uid)
+	if err != nil {
+		t.Fatalf("GetDocumentByID: %v", err)
+	}
+	if data["uid"] != uid || data["fitnessLevel"] != "advanced" {
+		t.Errorf("unexpected document: %v", data)
+	}
+
+	_, err = fs.GetDocumentByID(context.Background(), "users", uniqueUID("missing"))
+	if !errors.Is(err, services.ErrDocumentNotFound) {
+		t.Errorf("missing document: got %v, want ErrDocumentNotFound", err)
+	}
+}
+
+func TestFirestoreHandlers(t *testing.T) {
+	fs := newFirebase(t)
+	owner := uniqueUID("owner")
+	seedUser(t, fs, owner, nil)
+	orphan := uniqueUID("orphan")
+	seedUser(t, fs, orphan, map[string]interface{}{"uid": nil})
+
+	allowlist, err := services.ParseFirestoreAllowlist("users:uid,fullName,fitnessLevel,goals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewFirestoreHandler(fs, allowlist)
+
+	router := func(principal *auth.Principal) *gin.Engine {
+		r := gin.New()
+		r.Use(as(principal))
+		r.GET("/firestore/:id", h.GetDocumentByID)
+		r.GET("/firestore/collection/:collection/:id", h.GetDocumentByIDWithCollection)
+		return r
+	}
+	asOwner := router(&auth.Principal{UID: owner})
+	asStranger := router(&auth.Principal{UID: uniqueUID("stranger")})
+	asAdmin := router(&auth.Principal{UID: "admin", Admin: true})
+
+	tests := []struct {
+		name   string
+		router *gin.Engine
+		path   string
+		status int
+		code   string
+	}{
+		{"owner by default collection", asOwner, "/firestore/" + owner, http.StatusOK, ""},
+		{"owner by collection path", asOwner, "/firestore/collection/users/" + owner, http.StatusOK, ""},
+		{"admin reads any user", asAdmin, "/firestore/" + owner, http.StatusOK, ""},
+		{"other user", asStranger, "/firestore/" + owner, http.StatusForbidden, "forbidden"},
+		{"document without uid", asOwner, "/firestore/" + orphan, http.StatusForbidden, "forbidden"},
+		{"missing document", asOwner, "/firestore/" + uniqueUID("missing"), http.StatusNotFound, "not_found"},
+		{"collection not allowed by path", asAdmin, "/firestore/collection/secrets/" + owner, http.StatusForbidden, "collection_not_allowed"},
+		{"collection not allowed by query", asAdmin, "/firestore/" + owner + "?collection=secrets", http.StatusForbidden, "collection_not_allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doRequest(t, tt.router, http.MethodGet, tt.path)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d (body %v)", status, tt.status, body)
+			}
+			if tt.code != "" && body["code"] != tt.code {
+				t.Errorf("code = %v, want %s", body["code"], tt.code)
+			}
+		})
+	}
+
+	t.Run("fields are projected", func(t *testing.T) {
+		_, body := doRequest(t, asOwner, http.MethodGet, "/firestore/"+owner)
+		data, _ := body["data"].(map[string]interface{})
+		if data["fullName"] != "Integration Tester" || data["fitnessLevel"] != "intermediate" {
+			t.Errorf("allowed fields missing: %v", data)
+		}
+		for _, field := range []string{"dateOfBirth", "gender", "location", "equipment"} {
+			if _, ok := data[field]; ok {
+				t.Errorf("field %s was not stripped: %v", field, data)
+			}
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/handlers"
+	"fit-ai-api/llmstub"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+	"fit-ai-api/services"
+)
+
+// stubPlan is a valid three-session plan for the stub LLM to return
+func stubPlan(t *testing.T) string {
+	t.Helper()
+	plan := models.WorkoutPlan{
+		Name:               "Dumbbell Strength",
+		Description:        "Three full-body dumbbell sessions a week",
+		AIFeedbackCycle:    6,
+		PlanValidityPeriod: 28,
+	}
+	for day := 1; day <= 3; day++ {
+		session := models.WorkoutSession{Name: fmt.Sprintf("Day %d", day)}
+		for i, name := range []string{"Goblet Squat", "Dumbbell Bench Press", "One-Arm Row", "Romanian Deadlift"} {
+			session.Exercises = append(session.Exercises, models.Exercise{
+				Name:   name,
+				Sets:   3,
+				Reps:   8 + i,
+				Weight: models.WeightInfo{Value: 20, Unit: services.WeightUnitKilograms},
+				Type:   "weight",
+			})
+		}
+		plan.Sessions = append(plan.Sessions, session)
+	}
+
+	content, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestGenerateWorkoutPlan(t *testing.T) {
+	fs := newFirebase(t)
+	uid := uniqueUID("generate")
+	seedUser(t, fs, uid, map[string]interface{}{
+		"fitnessLevel": "beginner",
+		"goals":        []interface{}{"fat loss"},
+		"equipment":    []interface{}{"kettlebell"},
+	})
+
+	stub := llmstub.NewServer(llmstub.Completion(stubPlan(t)))
+	t.Cleanup(stub.Close)
+	t.Setenv("SELECTED_AI", string(services.OpenAI))
+	t.Setenv("AI_PROVIDERS", "")
+	t.Setenv("OPEN_AI_API_KEY", "test-key")
+	t.Setenv("AI_MAX_RETRIES", "0")
+
+	profiles := repositories.NewFirestoreProfileRepository(fs.Client(), fs.Timeout())
+	plans := repositories.NewMemoryWorkoutPlanRepository()
+	h := handlers.NewAIHandler(profiles, plans, services.NewAIServiceWithBaseURL(stub.BaseURL()), nil, nil)
+
+	r := gin.New()
+	r.Use(as(&auth.Principal{UID: uid}))
+	r.POST("/ai/workout-plan/:id", handlers.AliasParam("id", "user_id"), auth.RequireSelf("user_id"), h.GenerateWorkoutPlan)
+
+	status, body := doRequest(t, r, http.MethodPost, "/ai/workout-plan/"+uid)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %v)", status, body)
+	}
+
+	data, _ := body["data"].(map[string]interface{})
+	if data["name"] != "Dumbbell Strength" || data["userId"] != uid {
+		t.Errorf("unexpected plan: %v", data)
+	}
+	meta, _ := body["meta"].(map[string]interface{})
+	if meta["provider"] != string(services.OpenAI) {
+		t.Errorf("provider = %v, want %s", meta["provider"], services.OpenAI)
+	}
+
+	// The plan was saved for the user with fresh IDs
+	saved, err := plans.ListByUser(context.Background(), uid)
+	if err != nil || len(saved) != 1 {
+		t.Fatalf("saved plans = %v, %v; want one plan", saved, err)
+	}
+	if len(saved[0].Sessions) != 3 || saved[0].Sessions[0].ID == "" || saved[0].Sessions[0].Exercises[0].ID == 0 {
+		t.Errorf("saved plan is missing sessions or IDs: %+v", saved[0])
+	}
+
+	// The prompt was built from the seeded Firestore profile
+	req, _ := stub.LastRequest()
+	for _, want := range []string{"beginner", "fat loss", "kettlebell"} {
+		if !strings.Contains(req.Prompt(), want) {
+			t.Errorf("prompt does not mention %q", want)
+		}
+	}
+
+	t.Run("missing profile", func(t *testing.T) {
+		missing := uniqueUID("missing")
+		r := gin.New()
+		r.Use(as(&auth.Principal{UID: missing}))
+		r.POST("/ai/workout-plan/:id", handlers.AliasParam("id", "user_id"), h.GenerateWorkoutPlan)
+
+		status, body := doRequest(t, r, http.MethodPost, "/ai/workout-plan/"+missing)
+		if status != http.StatusNotFound {
+			t.Errorf("status = %d, want 404 (body %v)", status, body)
+		}
+	})
+}
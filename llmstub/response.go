@@ -0,0 +1,165 @@
+package llmstub
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed fixtures/*.json
+var fixtureFS embed.FS
+
+// Response is a reply the server plays back for one request
+type Response struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+	// Delay holds the reply back, e.g. to run into a client timeout; the server gives up
+	// waiting when the client disconnects
+	Delay time.Duration
+}
+
+// WithDelay returns a copy of the response sent after d
+func (r Response) WithDelay(d time.Duration) Response {
+	r.Delay = d
+	return r
+}
+
+// fixture is the file format of a recorded response. Body holds a JSON payload as
+// recorded; RawBody holds a payload that is not valid JSON and is sent verbatim.
+type fixture struct {
+	Description string            `json:"description"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers"`
+	Delay       string            `json:"delay"`
+	Body        json.RawMessage   `json:"body"`
+	RawBody     string            `json:"rawBody"`
+}
+
+// Fixture loads a recorded response from the fixtures directory by name, without the
+// .json extension
+func Fixture(name string) (Response, error) {
+	data, err := fixtureFS.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return Response{}, fmt.Errorf("unknown fixture %q: %w", name, err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Response{}, fmt.Errorf("fixture %q: %w", name, err)
+	}
+
+	response := Response{
+		Status: f.Status,
+		Header: f.Headers,
+		Body:   []byte(f.Body),
+	}
+	if f.RawBody != "" {
+		response.Body = []byte(f.RawBody)
+	}
+	if response.Status == 0 {
+		response.Status = http.StatusOK
+	}
+	if f.Delay != "" {
+		if response.Delay, err = time.ParseDuration(f.Delay); err != nil {
+			return Response{}, fmt.Errorf("fixture %q: invalid delay: %w", name, err)
+		}
+	}
+	return response, nil
+}
+
+// MustFixture is like Fixture but panics when the fixture cannot be loaded
+func MustFixture(name string) Response {
+	response, err := Fixture(name)
+	if err != nil {
+		panic(err)
+	}
+	return response
+}
+
+// Fixtures returns the names of the recorded responses
+func Fixtures() []string {
+	entries, _ := fixtureFS.ReadDir("fixtures")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Completion returns a successful chat completion whose first choice has content
+func Completion(content string) Response {
+	return completion(map[string]interface{}{
+		"role":    "assistant",
+		"content": content,
+	})
+}
+
+// ToolCall returns a successful chat completion whose first choice calls the named
+// function with arguments, as replies forced through tool_choice do
+func ToolCall(name, arguments string) Response {
+	return completion(map[string]interface{}{
+		"role":    "assistant",
+		"content": nil,
+		"tool_calls": []interface{}{map[string]interface{}{
+			"id":   "call_stub",
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":      name,
+				"arguments": arguments,
+			},
+		}},
+	})
+}
+
+// Error returns an OpenAI-style error reply with the given status
+func Error(status int, errType, code, message string) Response {
+	return jsonResponse(status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    errType,
+			"code":    code,
+		},
+	})
+}
+
+func completion(message map[string]interface{}) Response {
+	finishReason := "stop"
+	if _, ok := message["tool_calls"]; ok {
+		finishReason = "tool_calls"
+	}
+	return jsonResponse(http.StatusOK, map[string]interface{}{
+		"id":      "chatcmpl-stub",
+		"object":  "chat.completion",
+		"created": 1700000000,
+		"model":   "stub-model",
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"message":       message,
+			"finish_reason": finishReason,
+		}},
+		"usage": map[string]interface{}{
+			"prompt_tokens":     100,
+			"completion_tokens": 200,
+			"total_tokens":      300,
+		},
+	})
+}
+
+func jsonResponse(status int, payload interface{}) Response {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+	return Response{
+		Status: status,
+		Header: map[string]string{"Content-Type": "application/json"},
+		Body:   body,
+	}
+}
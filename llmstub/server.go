@@ -0,0 +1,292 @@
+// Package llmstub is an in-process stand-in for OpenAI-compatible chat completion APIs
+// such as OpenAI and DeepSeek. A Server replays scripted responses, either built with
+// Completion, ToolCall and Error or recorded in the fixtures directory, and records the
+// requests it receives so tests can check prompts and request options offline:
+//
+//	stub := llmstub.NewServer(llmstub.MustFixture("openai_rate_limited"), llmstub.Completion(plan))
+//	defer stub.Close()
+//	t.Setenv("OPEN_AI_BASE_URL", stub.BaseURL())
+//
+// Requests with stream: true get successful completions replayed as server-sent events.
+package llmstub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamChunkSize is the number of characters sent per server-sent event
+const streamChunkSize = 16
+
+// Message is a chat message sent to the server
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a chat completion request received by the server
+type Request struct {
+	Path   string      `json:"-"`
+	Header http.Header `json:"-"`
+	// Body is the raw request payload
+	Body []byte `json:"-"`
+
+	Model          string                   `json:"model"`
+	Messages       []Message                `json:"messages"`
+	Temperature    float64                  `json:"temperature"`
+	MaxTokens      int                      `json:"max_tokens"`
+	Stream         bool                     `json:"stream"`
+	ResponseFormat map[string]interface{}   `json:"response_format"`
+	Tools          []map[string]interface{} `json:"tools"`
+	ToolChoice     interface{}              `json:"tool_choice"`
+}
+
+// Prompt returns the content of every message, one per line
+func (r Request) Prompt() string {
+	var b strings.Builder
+	for _, message := range r.Messages {
+		b.WriteString(message.Content)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Server is a fake chat completions API. Each request to a path ending in
+// /chat/completions takes the next scripted response; once the script is used up the
+// default response, if any, is sent, and otherwise a 500 error.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	script   []Response
+	fallback *Response
+	requests []Request
+	done     chan struct{}
+}
+
+// NewServer starts a server that replies with responses in order
+func NewServer(responses ...Response) *Server {
+	s := &Server{
+		script: responses,
+		done:   make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// BaseURL returns the URL to configure as a provider's base URL
+func (s *Server) BaseURL() string {
+	return s.URL + "/v1"
+}
+
+// Enqueue appends responses to the script
+func (s *Server) Enqueue(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, responses...)
+}
+
+// SetDefault sets the response sent once the script is used up
+func (s *Server) SetDefault(response Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = &response
+}
+
+// Requests returns the chat completion requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest returns the most recent request, or false when none was received
+func (s *Server) LastRequest() (Request, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return Request{}, false
+	}
+	return s.requests[len(s.requests)-1], true
+}
+
+// Close releases delayed replies and shuts the server down
+func (s *Server) Close() {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.mu.Unlock()
+	s.Server.Close()
+}
+
+// next records req and returns the response to send
+func (s *Server) next(req Request) Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	if len(s.script) > 0 {
+		response := s.script[0]
+		s.script = s.script[1:]
+		return response
+	}
+	if s.fallback != nil {
+		return *s.fallback
+	}
+	return Error(http.StatusInternalServerError, "server_error", "", "llmstub: no response scripted")
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := Request{Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeResponse(w, Error(http.StatusBadRequest, "invalid_request_error", "", "llmstub: invalid JSON body: "+err.Error()))
+		return
+	}
+	response := s.next(req)
+
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+
+	if req.Stream && response.Status == http.StatusOK {
+		writeStream(w, response)
+		return
+	}
+	writeResponse(w, response)
+}
+
+func writeResponse(w http.ResponseWriter, response Response) {
+	for key, value := range response.Header {
+		w.Header().Set(key, value)
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(response.Status)
+	w.Write(response.Body)
+}
+
+// writeStream replays a completion as chat.completion.chunk events, splitting the reply
+// into small deltas and finishing with a usage chunk and [DONE]. A body that is not a
+// completion is sent as a single event.
+func writeStream(w http.ResponseWriter, response Response) {
+	for key, value := range response.Header {
+		w.Header().Set(key, value)
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	send := func(data string) {
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	var completion struct {
+		ID      string          `json:"id"`
+		Model   string          `json:"model"`
+		Usage   json.RawMessage `json:"usage"`
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(response.Body, &completion); err != nil || completion.Choices == nil {
+		send(string(response.Body))
+		return
+	}
+
+	chunk := func(delta interface{}) string {
+		data, _ := json.Marshal(map[string]interface{}{
+			"id":      completion.ID,
+			"object":  "chat.completion.chunk",
+			"model":   completion.Model,
+			"choices": []interface{}{map[string]interface{}{"index": 0, "delta": delta}},
+		})
+		return string(data)
+	}
+
+	if len(completion.Choices) > 0 {
+		message := completion.Choices[0].Message
+		text := message.Content
+		var toolName string
+		if len(message.ToolCalls) > 0 {
+			toolName = message.ToolCalls[0].Function.Name
+			text = message.ToolCalls[0].Function.Arguments
+		}
+
+		for _, piece := range split(text, streamChunkSize) {
+			if toolName == "" {
+				send(chunk(map[string]string{"content": piece}))
+				continue
+			}
+			send(chunk(map[string]interface{}{
+				"tool_calls": []interface{}{map[string]interface{}{
+					"index":    0,
+					"function": map[string]string{"name": toolName, "arguments": piece},
+				}},
+			}))
+		}
+	}
+
+	if len(completion.Usage) > 0 {
+		data, _ := json.Marshal(map[string]interface{}{
+			"id":      completion.ID,
+			"object":  "chat.completion.chunk",
+			"model":   completion.Model,
+			"choices": []interface{}{},
+			"usage":   completion.Usage,
+		})
+		send(string(data))
+	}
+	send("[DONE]")
+}
+
+// split cuts text into pieces of at most size characters
+func split(text string, size int) []string {
+	runes := []rune(text)
+	var pieces []string
+	for start := 0; start < len(runes); start += size {
+		end := start + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		pieces = append(pieces, string(runes[start:end]))
+	}
+	return pieces
+}
@@ -0,0 +1,88 @@
+package llmstub
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFixturesLoad(t *testing.T) {
+	names := Fixtures()
+	if len(names) == 0 {
+		t.Fatal("no fixtures found")
+	}
+	for _, name := range names {
+		response, err := Fixture(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(response.Body) == 0 {
+			t.Errorf("%s: empty body", name)
+		}
+	}
+
+	if _, err := Fixture("missing"); err == nil {
+		t.Error("loading an unknown fixture succeeded")
+	}
+}
+
+func post(t *testing.T, url string, payload string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestServerReplaysScript(t *testing.T) {
+	stub := NewServer(MustFixture("openai_rate_limited"), Completion("hello"))
+	defer stub.Close()
+
+	resp, _ := post(t, stub.BaseURL()+"/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1" {
+		t.Errorf("first reply: status %d, Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	resp, body := post(t, stub.BaseURL()+"/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"again"}]}`)
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(body), &completion); err != nil || resp.StatusCode != http.StatusOK || completion.Choices[0].Message.Content != "hello" {
+		t.Errorf("second reply: status %d, body %s", resp.StatusCode, body)
+	}
+
+	// Once the script is used up the server reports an error
+	if resp, _ := post(t, stub.BaseURL()+"/chat/completions", `{}`); resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("unscripted reply: status %d, want 500", resp.StatusCode)
+	}
+
+	requests := stub.Requests()
+	if len(requests) != 3 || requests[1].Model != "gpt-4o" || requests[1].Prompt() != "again\n" {
+		t.Errorf("unexpected recorded requests: %+v", requests)
+	}
+}
+
+func TestServerStreamsCompletions(t *testing.T) {
+	stub := NewServer(Completion(strings.Repeat("x", 40)))
+	defer stub.Close()
+
+	resp, body := post(t, stub.BaseURL()+"/chat/completions", `{"stream":true}`)
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+	// Three content chunks, a usage chunk and [DONE]
+	if events := strings.Count(body, "data: "); events != 5 || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Errorf("unexpected stream:\n%s", body)
+	}
+}
@@ -1,19 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"fit-ai-api/auth"
 	"fit-ai-api/handlers"
+	"fit-ai-api/migrations"
 	"fit-ai-api/models"
+	"fit-ai-api/repositories"
 	"fit-ai-api/services"
 )
 
+// shutdownTimeout bounds how long in-flight requests and background jobs get to finish
+// after SIGINT or SIGTERM
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
@@ -26,11 +41,28 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Run database migrations
-	if err := models.AutoMigrate(db); err != nil {
-		log.Fatal("Failed to run database migrations:", err)
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatal("Failed to load database migrations:", err)
+	}
+
+	// `migrate up|down|status` manages the schema instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(migrator, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Refuse to serve against a schema that is missing migrations
+	if err := migrator.EnsureCurrent(context.Background()); err != nil {
+		log.Fatalf("%v; run `go run main.go migrate up` first", err)
 	}
 
+	// ctx is cancelled on SIGINT or SIGTERM, starting a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize Gin router
 	r := gin.Default()
 
@@ -67,24 +99,138 @@ func main() {
 	// Initialize AI service
 	aiService := services.NewAIService()
 
+	// Initialize repositories; USER_STORE, PLAN_STORE, PROFILE_STORE and CATALOG_STORE
+	// select the backend
+	repoConfig := repositories.Config{DB: db}
+	if firebaseService != nil {
+		repoConfig.Firestore = firebaseService.Client()
+		repoConfig.FirestoreTimeout = firebaseService.Timeout()
+	}
+	userRepo, err := openRepository("USER_STORE", repositories.BackendPostgres, repoConfig, repositories.NewUserRepository)
+	if err != nil {
+		log.Fatal("Failed to initialize user repository:", err)
+	}
+	planRepo, err := openRepository("PLAN_STORE", repositories.BackendPostgres, repoConfig, repositories.NewWorkoutPlanRepository)
+	if err != nil {
+		log.Fatal("Failed to initialize workout plan repository:", err)
+	}
+	// Workout logs reference plans by ID, so they are kept in the same store
+	logRepo, err := openRepository("PLAN_STORE", repositories.BackendPostgres, repoConfig, repositories.NewWorkoutLogRepository)
+	if err != nil {
+		log.Fatal("Failed to initialize workout log repository:", err)
+	}
+	// Profiles come from Firestore, falling back to the users table, or only from the
+	// users table when Firestore isn't configured
+	profileBackend := repositories.BackendPostgres
+	if firebaseService != nil {
+		profileBackend = repositories.BackendFirestore
+	}
+	profileRepo, err := openRepository("PROFILE_STORE", profileBackend, repoConfig, repositories.NewProfileRepository)
+	if err != nil {
+		log.Printf("Warning: profile repository unavailable: %v", err)
+		log.Println("AI endpoints will not be available")
+	}
+
+	// The exercise catalog is seeded from the built-in library on every start; plan
+	// exercises are resolved against it
+	catalogRepo, err := openRepository("CATALOG_STORE", repositories.BackendPostgres, repoConfig, repositories.NewExerciseCatalogRepository)
+	if err != nil {
+		log.Fatal("Failed to initialize exercise catalog:", err)
+	}
+	catalog := services.NewExerciseCatalog(catalogRepo)
+	if err := catalog.Seed(context.Background()); err != nil {
+		log.Fatal("Failed to seed exercise catalog:", err)
+	}
+
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(db)
+	userHandler := handlers.NewUserHandler(userRepo)
+	catalogHandler := handlers.NewCatalogHandler(catalog)
 	var firestoreHandler *handlers.FirestoreHandler
 	var aiHandler *handlers.AIHandler
+	var swapHandler *handlers.SwapHandler
+	var suggestionHandler *handlers.SuggestionHandler
+	var reviewService *services.PlanReviewService
+	var jobService *services.JobService
 	if firebaseService != nil {
-		firestoreHandler = handlers.NewFirestoreHandler(firebaseService)
-		aiHandler = handlers.NewAIHandler(firebaseService, aiService)
+		// Only allowlisted collections and fields are readable through /firestore
+		allowlist, err := services.FirestoreAllowlistFromEnv()
+		if err != nil {
+			log.Fatal("Invalid FIRESTORE_COLLECTIONS:", err)
+		}
+		log.Printf("Firestore endpoints expose collections: %v", allowlist.Names())
+		firestoreHandler = handlers.NewFirestoreHandler(firebaseService, allowlist)
+	}
+	if profileRepo != nil {
+		// Background plan generation runs on a bounded worker pool backed by the jobs table
+		jobService = services.NewJobService(repositories.NewGormJobRepository(db))
+		aiHandler = handlers.NewAIHandler(profileRepo, planRepo, aiService, catalog, jobService)
+		swapHandler = handlers.NewSwapHandler(services.NewExerciseSwapService(planRepo, profileRepo, catalog, aiService))
+		jobService.Handle(models.JobTypePlanGeneration, aiHandler.RunGenerationJob)
+		// Completed workouts queue plan reviews (the AI feedback cycle) on the same pool
+		reviewService = services.NewPlanReviewService(planRepo, logRepo, profileRepo, aiService, catalog, jobService)
+		suggestionHandler = handlers.NewSuggestionHandler(reviewService)
+		jobService.Handle(models.JobTypePlanSuggestion, reviewService.RunSuggestionJob)
+		if err := jobService.Start(ctx); err != nil {
+			log.Fatal("Failed to start background jobs:", err)
+		}
+	}
+
+	progression, err := services.ProgressionConfigFromEnv()
+	if err != nil {
+		log.Fatal("Invalid progression settings:", err)
+	}
+	workoutHandler := handlers.NewWorkoutHandler(services.NewWorkoutService(planRepo, logRepo, profileRepo, reviewService, progression))
+
+	// Email and password sign-in is enabled by JWT_SECRET; refresh tokens are stored with
+	// the users they belong to
+	var accountHandler *handlers.AccountHandler
+	var accessTokens auth.Verifier
+	if os.Getenv("JWT_SECRET") != "" {
+		tokenRepo, err := openRepository("USER_STORE", repositories.BackendPostgres, repoConfig, repositories.NewRefreshTokenRepository)
+		if err != nil {
+			log.Printf("Warning: refresh token repository unavailable: %v", err)
+			log.Println("Email and password sign-in will not be available")
+		} else {
+			accountService, err := services.NewAccountServiceFromEnv(userRepo, tokenRepo)
+			if err != nil {
+				log.Fatal("Invalid JWT settings:", err)
+			}
+			accountHandler = handlers.NewAccountHandler(accountService)
+			accessTokens = accountService.Verifier()
+		}
+	}
+
+	// Every other API route requires a Firebase ID token or an access token; /health stays
+	// public
+	authMiddleware, err := newAuthMiddleware(accessTokens)
+	if err != nil {
+		log.Fatal("Failed to configure authentication:", err)
+	}
+	requireAdmin := auth.RequireAdmin()
+
+	// Sign-in endpoints
+	if accountHandler != nil {
+		public := r.Group("/api/v1/auth")
+		public.POST("/signup", accountHandler.Signup)
+		public.POST("/login", accountHandler.Login)
+		public.POST("/refresh", accountHandler.Refresh)
+		public.POST("/logout", accountHandler.Logout)
 	}
 
 	// API routes group
-	api := r.Group("/api/v1")
+	api := r.Group("/api/v1", authMiddleware)
 	{
-		// User endpoints
-		api.GET("/users", userHandler.GetUsers)
-		api.GET("/users/:id", userHandler.GetUser)
-		api.POST("/users", userHandler.CreateUser)
-		api.PUT("/users/:id", userHandler.UpdateUser)
-		api.DELETE("/users/:id", userHandler.DeleteUser)
+		if accountHandler != nil {
+			api.POST("/auth/revoke", accountHandler.RevokeSessions)
+		}
+
+		// User endpoints; users may read and edit their own record
+		userOwner := userHandler.RequireUserOwner
+		api.GET("/users", requireAdmin, userHandler.GetUsers)
+		api.GET("/users/:id", userOwner, userHandler.GetUser)
+		api.POST("/users", requireAdmin, userHandler.CreateUser)
+		api.PUT("/users/:id", userOwner, userHandler.UpdateUser)
+		api.DELETE("/users/:id", requireAdmin, userHandler.DeleteUser)
 
 		// Firestore endpoints
 		if firestoreHandler != nil {
@@ -93,20 +239,41 @@ func main() {
 		}
 
 		// AI Workout Plan endpoints
+		// /ai/workout-plan/:id is a user ID for generation and a plan ID otherwise
 		if aiHandler != nil {
-			api.POST("/ai/workout-plan/:user_id", aiHandler.GenerateWorkoutPlan)
-			api.GET("/ai/workout-plan/:plan_id", aiHandler.GetWorkoutPlanByID)
-			api.PUT("/ai/workout-plan/:plan_id", aiHandler.UpdateWorkoutPlan)
-			api.DELETE("/ai/workout-plan/:plan_id", aiHandler.DeleteWorkoutPlan)
-			api.GET("/ai/workout-plans/:user_id", aiHandler.GetUserWorkoutPlans)
-		}
-
-		// TODO: Add your fitness-related endpoints here
-		api.GET("/workouts", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"message": "Workouts endpoint - coming soon!",
-			})
-		})
+			// Callers may only act on their own user ID and plans unless they are admins
+			asUserID := handlers.AliasParam("id", "user_id")
+			asPlanID := handlers.AliasParam("id", "plan_id")
+			self := auth.RequireSelf("user_id")
+			planOwner := aiHandler.RequirePlanOwner
+
+			api.POST("/ai/workout-plan/:id", asUserID, self, aiHandler.GenerateWorkoutPlan)
+			api.GET("/ai/workout-plan/:id/stream", asUserID, self, aiHandler.StreamWorkoutPlan)
+			api.POST("/ai/workout-plan/:id/stream", asUserID, self, aiHandler.StreamWorkoutPlan)
+			api.GET("/ai/workout-plan/:id", asPlanID, planOwner, aiHandler.GetWorkoutPlanByID)
+			api.PUT("/ai/workout-plan/:id", asPlanID, planOwner, aiHandler.UpdateWorkoutPlan)
+			api.DELETE("/ai/workout-plan/:id", asPlanID, planOwner, aiHandler.DeleteWorkoutPlan)
+			api.POST("/ai/workout-plan/:id/suggestion/accept", asPlanID, planOwner, suggestionHandler.AcceptSuggestion)
+			api.POST("/ai/workout-plan/:id/suggestion/reject", asPlanID, planOwner, suggestionHandler.RejectSuggestion)
+			api.POST("/ai/workout-plan/:id/sessions/:session_id/exercises/:exercise_id/alternatives", asPlanID, planOwner, swapHandler.GetAlternatives)
+			api.POST("/ai/workout-plan/:id/sessions/:session_id/exercises/:exercise_id/swap", asPlanID, planOwner, swapHandler.SwapExercise)
+			api.GET("/ai/workout-plans/:user_id", self, aiHandler.GetUserWorkoutPlans)
+			api.GET("/ai/jobs/:id", aiHandler.GetJob)
+		}
+
+		// Exercise catalog endpoints
+		api.GET("/exercises", catalogHandler.SearchExercises)
+		api.GET("/exercises/resolve", catalogHandler.ResolveExercise)
+		api.GET("/exercises/taxonomy", catalogHandler.GetTaxonomy)
+		api.GET("/exercises/:id", catalogHandler.GetExercise)
+
+		// Workout logging endpoints
+		workoutOwner := workoutHandler.RequireWorkoutOwner
+		api.POST("/workouts", workoutHandler.StartWorkout)
+		api.GET("/workouts", auth.RequireSelf("user_id"), workoutHandler.GetWorkouts)
+		api.GET("/workouts/:id", workoutOwner, workoutHandler.GetWorkout)
+		api.POST("/workouts/:id/sets", workoutOwner, workoutHandler.LogSet)
+		api.POST("/workouts/:id/complete", workoutOwner, workoutHandler.CompleteWorkout)
 	}
 
 	// Get port from environment or use default
@@ -115,9 +282,94 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: server shutdown: %v", err)
+	}
+	// The workers stopped taking jobs when ctx was cancelled; wait for the running ones to
+	// be queued again
+	if jobService != nil {
+		stopped := make(chan struct{})
+		go func() {
+			jobService.Wait()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			log.Println("Warning: background jobs did not stop in time")
+		}
+	}
+}
+
+// runMigrate implements the migrate subcommand: `up` applies all pending migrations,
+// `down [n]` rolls back the last n (default 1) and `status` lists them
+func runMigrate(migrator *migrations.Migrator, args []string) error {
+	ctx := context.Background()
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [n]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("Applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("Database schema is up to date")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations to roll back: %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			log.Printf("Rolled back %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			log.Println("No migrations to roll back")
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected up, down or status)", args[0])
 	}
 }
 
@@ -128,7 +380,7 @@ func initDB() (*gorm.DB, error) {
 		dsn = "host=localhost user=postgres password=postgres dbname=fit_ai_db port=5432 sslmode=disable TimeZone=UTC"
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
@@ -136,3 +388,39 @@ func initDB() (*gorm.DB, error) {
 	log.Println("Database connected successfully")
 	return db, nil
 }
+
+// newAuthMiddleware verifies Firebase ID tokens issued to FIREBASE_PROJECT_ID, or to
+// GOOGLE_CLOUD_PROJECT when it is unset, and first-party access tokens when accessTokens
+// is not nil. AUTH_DISABLED=true turns authentication off for local development.
+func newAuthMiddleware(accessTokens auth.Verifier) (gin.HandlerFunc, error) {
+	if os.Getenv("AUTH_DISABLED") == "true" {
+		return auth.Disabled(), nil
+	}
+
+	var verifiers []auth.Verifier
+	projectID := os.Getenv("FIREBASE_PROJECT_ID")
+	if projectID == "" {
+		projectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if projectID != "" {
+		verifiers = append(verifiers, auth.NewFirebaseVerifier(projectID, auth.NewGoogleKeySource()))
+	}
+	if accessTokens != nil {
+		verifiers = append(verifiers, accessTokens)
+	}
+	if len(verifiers) == 0 {
+		return nil, errors.New("neither FIREBASE_PROJECT_ID nor JWT_SECRET is set (use AUTH_DISABLED=true to run without authentication)")
+	}
+	return auth.Middleware(verifiers...), nil
+}
+
+// openRepository builds a repository on the backend named by the envKey variable,
+// or def when it is unset
+func openRepository[T any](envKey string, def repositories.Backend, cfg repositories.Config, build func(repositories.Backend, repositories.Config) (T, error)) (T, error) {
+	backend, err := repositories.ParseBackend(os.Getenv(envKey), def)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("%s: %w", envKey, err)
+	}
+	return build(backend, cfg)
+}
@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// sqlFiles returns a file system with an sql directory holding a file per name
+func sqlFiles(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys["sql/"+name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+	return fsys
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := load(sqlFiles(
+		"0010_add_index.down.sql",
+		"0002_create_jobs.up.sql",
+		"0010_add_index.up.sql",
+		"0002_create_jobs.down.sql",
+		"0001_create_users.up.sql",
+		"0001_create_users.down.sql",
+		"README.md",
+	), "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range migrations {
+		got = append(got, m.Name)
+		base := fmt.Sprintf("-- %04d_%s", m.Version, m.Name)
+		if m.Up != base+".up.sql" || m.Down != base+".down.sql" {
+			t.Errorf("migration %d: up %q, down %q", m.Version, m.Up, m.Down)
+		}
+	}
+	if strings.Join(got, ", ") != "create_users, create_jobs, add_index" {
+		t.Errorf("migrations = %v, want them sorted by version", got)
+	}
+	if migrations[2].Version != 10 {
+		t.Errorf("version = %d, want 10", migrations[2].Version)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"no migrations", []string{"notes.txt"}, "no migrations found"},
+		{"missing name", []string{"0001.up.sql"}, "must be named NNNN_name.up.sql"},
+		{"missing version", []string{"create_users.up.sql", "create_users.down.sql"}, "invalid version"},
+		{"zero version", []string{"0000_init.up.sql", "0000_init.down.sql"}, "invalid version"},
+		{"negative version", []string{"-1_init.up.sql", "-1_init.down.sql"}, "invalid version"},
+		{"missing down", []string{"0001_create_users.up.sql"}, "0001_create_users needs both an up and a down file"},
+		{"missing up", []string{"0001_create_users.down.sql"}, "0001_create_users needs both an up and a down file"},
+		{"duplicate version", []string{
+			"0001_create_users.up.sql", "0001_create_users.down.sql",
+			"0001_create_jobs.up.sql", "0001_create_jobs.down.sql",
+		}, "migration version 1 is used by both"},
+		{"duplicate file", []string{
+			"0001_create_users.up.sql", "0001_create_users.down.sql", "1_create_users.up.sql",
+		}, "migration version 1 has more than one up file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(sqlFiles(tt.files...), "sql")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := load(sqlFiles(), "sql"); err == nil || !strings.Contains(err.Error(), "failed to read migrations") {
+		t.Errorf("missing directory: error = %v", err)
+	}
+}
+
+// TestEmbeddedMigrations checks the shipped files load and have consecutive versions
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files, "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d is %04d_%s, want version %d", i, m.Version, m.Name, i+1)
+		}
+	}
+}
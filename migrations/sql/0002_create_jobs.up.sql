@@ -0,0 +1,18 @@
+-- Background jobs such as asynchronous plan generation
+CREATE TABLE IF NOT EXISTS jobs (
+    id VARCHAR(32) PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    user_id TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    result JSONB,
+    error TEXT,
+    error_code VARCHAR(64),
+    attempts BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_user_id ON jobs (user_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
@@ -0,0 +1,42 @@
+-- Saved workout plans owned by a Firebase UID, with their sessions and exercises
+CREATE TABLE IF NOT EXISTS workout_plans (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    ai_feedback_cycle BIGINT NOT NULL DEFAULT 0,
+    plan_validity_period BIGINT NOT NULL DEFAULT 0,
+    sessions_completed BIGINT NOT NULL DEFAULT 0,
+    plan_start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    has_new_plan_suggestion BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_plans_user_id ON workout_plans (user_id);
+CREATE INDEX IF NOT EXISTS idx_workout_plans_deleted_at ON workout_plans (deleted_at);
+
+CREATE TABLE IF NOT EXISTS workout_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    plan_id BIGINT NOT NULL REFERENCES workout_plans (id) ON DELETE CASCADE,
+    position BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    note TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_plan_id ON workout_sessions (plan_id);
+
+CREATE TABLE IF NOT EXISTS workout_exercises (
+    id BIGSERIAL PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES workout_sessions (id) ON DELETE CASCADE,
+    position BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    sets BIGINT NOT NULL,
+    reps BIGINT NOT NULL,
+    weight_value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    weight_unit VARCHAR(16),
+    type VARCHAR(32)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_exercises_session_id ON workout_exercises (session_id);
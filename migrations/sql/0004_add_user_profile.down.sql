@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_users_firebase_uid;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS firebase_uid,
+    DROP COLUMN IF EXISTS profile_activity_level,
+    DROP COLUMN IF EXISTS profile_date_of_birth,
+    DROP COLUMN IF EXISTS profile_display_name,
+    DROP COLUMN IF EXISTS profile_equipment,
+    DROP COLUMN IF EXISTS profile_fitness_level,
+    DROP COLUMN IF EXISTS profile_full_name,
+    DROP COLUMN IF EXISTS profile_gender,
+    DROP COLUMN IF EXISTS profile_goals,
+    DROP COLUMN IF EXISTS profile_height_unit,
+    DROP COLUMN IF EXISTS profile_height_value,
+    DROP COLUMN IF EXISTS profile_location,
+    DROP COLUMN IF EXISTS profile_preferences,
+    DROP COLUMN IF EXISTS profile_stats,
+    DROP COLUMN IF EXISTS profile_weight_unit,
+    DROP COLUMN IF EXISTS profile_weight_value;
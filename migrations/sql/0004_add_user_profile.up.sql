@@ -0,0 +1,20 @@
+-- Link API users to their Firebase account and store the canonical fitness profile
+ALTER TABLE users
+    ADD COLUMN firebase_uid TEXT,
+    ADD COLUMN profile_activity_level TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_date_of_birth TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_equipment JSONB,
+    ADD COLUMN profile_fitness_level TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_full_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_gender TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_goals JSONB,
+    ADD COLUMN profile_height_unit TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_height_value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN profile_location TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_preferences JSONB,
+    ADD COLUMN profile_stats JSONB,
+    ADD COLUMN profile_weight_unit TEXT NOT NULL DEFAULT '',
+    ADD COLUMN profile_weight_value DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX idx_users_firebase_uid ON users (firebase_uid);
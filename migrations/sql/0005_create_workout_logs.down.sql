@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS set_logs;
+DROP TABLE IF EXISTS workout_logs;
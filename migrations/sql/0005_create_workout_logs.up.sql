@@ -0,0 +1,35 @@
+-- Performed workouts and the sets logged during them
+CREATE TABLE workout_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    plan_id BIGINT NOT NULL,
+    session_id TEXT NOT NULL,
+    session_name TEXT,
+    status VARCHAR(16) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    duration_seconds BIGINT NOT NULL DEFAULT 0,
+    total_volume DOUBLE PRECISION NOT NULL DEFAULT 0,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_workout_logs_user_id ON workout_logs (user_id);
+CREATE INDEX idx_workout_logs_plan_id ON workout_logs (plan_id);
+
+CREATE TABLE set_logs (
+    id BIGSERIAL PRIMARY KEY,
+    workout_log_id BIGINT NOT NULL REFERENCES workout_logs (id) ON DELETE CASCADE,
+    exercise_id BIGINT NOT NULL,
+    exercise_name TEXT,
+    set_number BIGINT NOT NULL,
+    reps BIGINT NOT NULL,
+    weight_value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    weight_unit TEXT,
+    rpe DOUBLE PRECISION,
+    duration_seconds BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_set_logs_workout_log_id ON set_logs (workout_log_id);
@@ -0,0 +1,3 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS payload;
+
+ALTER TABLE workout_plans DROP COLUMN IF EXISTS suggested_plan;
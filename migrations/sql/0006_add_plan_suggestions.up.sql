@@ -0,0 +1,4 @@
+-- Suggested plans produced by the AI feedback cycle, and job inputs for queuing them
+ALTER TABLE workout_plans ADD COLUMN suggested_plan JSONB;
+
+ALTER TABLE jobs ADD COLUMN payload JSONB;
@@ -0,0 +1,3 @@
+ALTER TABLE set_logs DROP COLUMN IF EXISTS target_weight_unit;
+ALTER TABLE set_logs DROP COLUMN IF EXISTS target_weight_value;
+ALTER TABLE set_logs DROP COLUMN IF EXISTS target_reps;
@@ -0,0 +1,4 @@
+-- The prescription each set was logged against, used by the progression engine
+ALTER TABLE set_logs ADD COLUMN target_reps BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE set_logs ADD COLUMN target_weight_value DOUBLE PRECISION;
+ALTER TABLE set_logs ADD COLUMN target_weight_unit TEXT;
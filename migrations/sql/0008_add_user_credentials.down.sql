@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS refresh_tokens;
+DROP INDEX IF EXISTS idx_users_email;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS password_hash,
+    DROP COLUMN IF EXISTS admin;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_workout_exercises_catalog_exercise_id;
+ALTER TABLE workout_exercises DROP COLUMN IF EXISTS catalog_exercise_id;
+
+DROP TABLE IF EXISTS exercise_catalog;
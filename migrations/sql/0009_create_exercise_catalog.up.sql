@@ -0,0 +1,27 @@
+-- The exercise library generated plans are resolved against. Rows are seeded by the
+-- server on startup from repositories/seed/exercises.json, keyed by slug.
+CREATE TABLE exercise_catalog (
+    id BIGSERIAL PRIMARY KEY,
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    aliases JSONB NOT NULL DEFAULT '[]',
+    primary_muscles JSONB NOT NULL DEFAULT '[]',
+    secondary_muscles JSONB NOT NULL DEFAULT '[]',
+    movement_pattern TEXT NOT NULL,
+    equipment JSONB NOT NULL DEFAULT '[]',
+    difficulty TEXT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_exercise_catalog_slug ON exercise_catalog (slug);
+CREATE INDEX idx_exercise_catalog_movement_pattern ON exercise_catalog (movement_pattern);
+CREATE INDEX idx_exercise_catalog_primary_muscles ON exercise_catalog USING GIN (primary_muscles);
+CREATE INDEX idx_exercise_catalog_equipment ON exercise_catalog USING GIN (equipment);
+
+-- Plan exercises keep the catalog entry their name resolved to
+ALTER TABLE workout_exercises
+    ADD COLUMN catalog_exercise_id BIGINT REFERENCES exercise_catalog (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_workout_exercises_catalog_exercise_id ON workout_exercises (catalog_exercise_id);
@@ -0,0 +1,162 @@
+package models
+
+import "time"
+
+// Muscle groups used for the primary and secondary muscles of catalog exercises
+const (
+	MuscleChest      = "chest"
+	MuscleFrontDelts = "front_delts"
+	MuscleSideDelts  = "side_delts"
+	MuscleRearDelts  = "rear_delts"
+	MuscleTriceps    = "triceps"
+	MuscleBiceps     = "biceps"
+	MuscleForearms   = "forearms"
+	MuscleLats       = "lats"
+	MuscleUpperBack  = "upper_back"
+	MuscleTraps      = "traps"
+	MuscleLowerBack  = "lower_back"
+	MuscleAbs        = "abs"
+	MuscleObliques   = "obliques"
+	MuscleGlutes     = "glutes"
+	MuscleQuadriceps = "quadriceps"
+	MuscleHamstrings = "hamstrings"
+	MuscleAdductors  = "adductors"
+	MuscleCalves     = "calves"
+	MuscleHipFlexors = "hip_flexors"
+)
+
+// MuscleGroups lists every muscle group in the taxonomy
+var MuscleGroups = []string{
+	MuscleChest, MuscleFrontDelts, MuscleSideDelts, MuscleRearDelts, MuscleTriceps,
+	MuscleBiceps, MuscleForearms, MuscleLats, MuscleUpperBack, MuscleTraps, MuscleLowerBack,
+	MuscleAbs, MuscleObliques, MuscleGlutes, MuscleQuadriceps, MuscleHamstrings,
+	MuscleAdductors, MuscleCalves, MuscleHipFlexors,
+}
+
+// Movement patterns; exercises with the same pattern can usually replace each other
+const (
+	PatternSquat          = "squat"
+	PatternHinge          = "hinge"
+	PatternLunge          = "lunge"
+	PatternHorizontalPush = "horizontal_push"
+	PatternVerticalPush   = "vertical_push"
+	PatternHorizontalPull = "horizontal_pull"
+	PatternVerticalPull   = "vertical_pull"
+	PatternElbowFlexion   = "elbow_flexion"
+	PatternElbowExtension = "elbow_extension"
+	PatternShoulderRaise  = "shoulder_raise"
+	PatternChestFly       = "chest_fly"
+	PatternKneeExtension  = "knee_extension"
+	PatternKneeFlexion    = "knee_flexion"
+	PatternCalfRaise      = "calf_raise"
+	PatternCoreFlexion    = "core_flexion"
+	PatternCoreStability  = "core_stability"
+	PatternRotation       = "rotation"
+	PatternCarry          = "carry"
+	PatternCardio         = "cardio"
+	PatternMobility       = "mobility"
+)
+
+// MovementPatterns lists every movement pattern in the taxonomy
+var MovementPatterns = []string{
+	PatternSquat, PatternHinge, PatternLunge, PatternHorizontalPush, PatternVerticalPush,
+	PatternHorizontalPull, PatternVerticalPull, PatternElbowFlexion, PatternElbowExtension,
+	PatternShoulderRaise, PatternChestFly, PatternKneeExtension, PatternKneeFlexion,
+	PatternCalfRaise, PatternCoreFlexion, PatternCoreStability, PatternRotation,
+	PatternCarry, PatternCardio, PatternMobility,
+}
+
+// Equipment an exercise can require. Bodyweight exercises require none.
+const (
+	EquipmentBarbell        = "barbell"
+	EquipmentEZBar          = "ez_bar"
+	EquipmentDumbbell       = "dumbbell"
+	EquipmentKettlebell     = "kettlebell"
+	EquipmentBench          = "bench"
+	EquipmentSquatRack      = "squat_rack"
+	EquipmentPullUpBar      = "pull_up_bar"
+	EquipmentDipStation     = "dip_station"
+	EquipmentCableMachine   = "cable_machine"
+	EquipmentMachine        = "machine"
+	EquipmentResistanceBand = "resistance_band"
+	EquipmentMedicineBall   = "medicine_ball"
+	EquipmentTreadmill      = "treadmill"
+	EquipmentStationaryBike = "stationary_bike"
+	EquipmentRowingMachine  = "rowing_machine"
+	EquipmentJumpRope       = "jump_rope"
+)
+
+// EquipmentTypes lists every kind of equipment in the taxonomy
+var EquipmentTypes = []string{
+	EquipmentBarbell, EquipmentEZBar, EquipmentDumbbell, EquipmentKettlebell, EquipmentBench,
+	EquipmentSquatRack, EquipmentPullUpBar, EquipmentDipStation, EquipmentCableMachine,
+	EquipmentMachine, EquipmentResistanceBand, EquipmentMedicineBall, EquipmentTreadmill,
+	EquipmentStationaryBike, EquipmentRowingMachine, EquipmentJumpRope,
+}
+
+// Exercise difficulty levels, matching the fitness levels of user profiles
+const (
+	DifficultyBeginner     = "beginner"
+	DifficultyIntermediate = "intermediate"
+	DifficultyAdvanced     = "advanced"
+)
+
+// Difficulties lists the difficulty levels from easiest to hardest
+var Difficulties = []string{DifficultyBeginner, DifficultyIntermediate, DifficultyAdvanced}
+
+// CatalogExercise is an exercise of the exercise library. Plans reference it through
+// Exercise.CatalogID so workouts can be aggregated by muscle and checked against the
+// user's equipment.
+type CatalogExercise struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// Slug identifies the exercise across environments and in the seed data
+	Slug             string   `json:"slug" gorm:"uniqueIndex;not null"`
+	Name             string   `json:"name" gorm:"not null"`
+	Aliases          []string `json:"aliases" gorm:"serializer:json;type:jsonb"`
+	PrimaryMuscles   []string `json:"primaryMuscles" gorm:"serializer:json;type:jsonb"`
+	SecondaryMuscles []string `json:"secondaryMuscles" gorm:"serializer:json;type:jsonb"`
+	MovementPattern  string   `json:"movementPattern" gorm:"index;not null"`
+	// Equipment lists everything the exercise needs; it is empty for bodyweight exercises
+	Equipment  []string  `json:"equipment" gorm:"serializer:json;type:jsonb"`
+	Difficulty string    `json:"difficulty" gorm:"not null"`
+	Type       string    `json:"type" gorm:"size:32;not null"`
+	CreatedAt  time.Time `json:"-"`
+	UpdatedAt  time.Time `json:"-"`
+}
+
+// TableName sets the table name for CatalogExercise
+func (CatalogExercise) TableName() string {
+	return "exercise_catalog"
+}
+
+// RequiresOnly reports whether every piece of equipment the exercise needs is in available
+func (e *CatalogExercise) RequiresOnly(available []string) bool {
+	for _, needed := range e.Equipment {
+		found := false
+		for _, have := range available {
+			if have == needed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// WorksMuscle reports whether muscle is one of the exercise's primary or secondary muscles
+func (e *CatalogExercise) WorksMuscle(muscle string) bool {
+	for _, m := range e.PrimaryMuscles {
+		if m == muscle {
+			return true
+		}
+	}
+	for _, m := range e.SecondaryMuscles {
+		if m == muscle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// JobType identifies the work a background job performs
+type JobType string
+
+const (
+	JobTypePlanGeneration JobType = "plan_generation"
+	JobTypePlanSuggestion JobType = "plan_suggestion"
+)
+
+// JobStatus is the lifecycle state of a background job
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job is a long-running request (such as AI plan generation) processed in the background.
+// Jobs are persisted so queued and interrupted work survives restarts. Payload carries
+// any input beyond the user, such as the plan to review.
+type Job struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:32"`
+	Type       JobType    `json:"type" gorm:"size:64;not null"`
+	UserID     string     `json:"user_id" gorm:"index;not null"`
+	Status     JobStatus  `json:"status" gorm:"size:16;index;not null"`
+	Payload    JSON       `json:"payload,omitempty"`
+	Result     JSON       `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	ErrorCode  string     `json:"error_code,omitempty" gorm:"size:64"`
+	Attempts   int        `json:"attempts" gorm:"not null;default:0"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkoutPlanRecord is the database row for a workout plan owned by a user.
+// UserID is the Firebase UID of the owner.
+type WorkoutPlanRecord struct {
+	ID                   uint                   `gorm:"primaryKey"`
+	UserID               string                 `gorm:"index;not null"`
+	Name                 string                 `gorm:"not null"`
+	Description          string                 `gorm:"type:text"`
+	AIFeedbackCycle      int                    `gorm:"not null;default:0"`
+	PlanValidityPeriod   int                    `gorm:"not null;default:0"`
+	SessionsCompleted    int                    `gorm:"not null;default:0"`
+	PlanStartDate        time.Time              `gorm:"not null"`
+	HasNewPlanSuggestion bool                   `gorm:"not null;default:false"`
+	SuggestedPlan        *SuggestedPlan         `gorm:"serializer:json;type:jsonb"`
+	Sessions             []WorkoutSessionRecord `gorm:"foreignKey:PlanID;constraint:OnDelete:CASCADE"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            gorm.DeletedAt `gorm:"index"`
+}
+
+// TableName sets the table name for WorkoutPlanRecord
+func (WorkoutPlanRecord) TableName() string {
+	return "workout_plans"
+}
+
+// WorkoutSessionRecord is the database row for one session of a workout plan
+type WorkoutSessionRecord struct {
+	ID        uint             `gorm:"primaryKey"`
+	PlanID    uint             `gorm:"index;not null"`
+	Position  int              `gorm:"not null"`
+	Name      string           `gorm:"not null"`
+	Note      string           `gorm:"type:text"`
+	Exercises []ExerciseRecord `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName sets the table name for WorkoutSessionRecord
+func (WorkoutSessionRecord) TableName() string {
+	return "workout_sessions"
+}
+
+// ExerciseRecord is the database row for one exercise of a session. The weight is stored
+// inline in weight_value and weight_unit columns.
+type ExerciseRecord struct {
+	ID        uint         `gorm:"primaryKey"`
+	SessionID uint         `gorm:"index;not null"`
+	Position  int          `gorm:"not null"`
+	Name      string       `gorm:"not null"`
+	Sets      int          `gorm:"not null"`
+	Reps      int          `gorm:"not null"`
+	Weight    WeightRecord `gorm:"embedded;embeddedPrefix:weight_"`
+	Type      string       `gorm:"size:32"`
+	// CatalogExerciseID references the exercise_catalog entry the name resolved to
+	CatalogExerciseID *uint `gorm:"index"`
+}
+
+// TableName sets the table name for ExerciseRecord
+func (ExerciseRecord) TableName() string {
+	return "workout_exercises"
+}
+
+// WeightRecord is the stored form of WeightInfo
+type WeightRecord struct {
+	Value float64 `gorm:"not null;default:0"`
+	Unit  string  `gorm:"size:16"`
+}
+
+// NewWorkoutPlanRecord converts a plan into its database rows for the given owner.
+// Session and exercise IDs in the plan are ignored; the stored rows get new ones.
+func NewWorkoutPlanRecord(userID string, plan *WorkoutPlan) *WorkoutPlanRecord {
+	record := &WorkoutPlanRecord{UserID: userID}
+	record.Apply(plan)
+	return record
+}
+
+// Apply copies every field and the sessions of plan onto the record, including the
+// server-owned progress and suggestion fields. Use ApplyEdits for changes made by users.
+func (r *WorkoutPlanRecord) Apply(plan *WorkoutPlan) {
+	r.ApplyEdits(plan)
+	r.SessionsCompleted = plan.SessionsCompleted
+	r.PlanStartDate = plan.PlanStartDate
+	if r.PlanStartDate.IsZero() {
+		r.PlanStartDate = time.Now().UTC()
+	}
+	r.HasNewPlanSuggestion = plan.HasNewPlanSuggestion
+	r.SuggestedPlan = plan.SuggestedPlan
+
+	r.Sessions = make([]WorkoutSessionRecord, len(plan.Sessions))
+	for i, session := range plan.Sessions {
+		exercises := make([]ExerciseRecord, len(session.Exercises))
+		for j, exercise := range session.Exercises {
+			exercises[j] = NewExerciseRecord(j, exercise)
+		}
+		r.Sessions[i] = WorkoutSessionRecord{
+			PlanID:    r.ID,
+			Position:  i,
+			Name:      session.Name,
+			Note:      session.Note,
+			Exercises: exercises,
+		}
+	}
+}
+
+// ApplyEdits copies the fields users may edit onto the record: the name, description and
+// review settings. Sessions are not touched; SessionsCompleted, PlanStartDate and the
+// suggested plan are owned by the server and never taken from a client.
+func (r *WorkoutPlanRecord) ApplyEdits(plan *WorkoutPlan) {
+	r.Name = plan.Name
+	r.Description = plan.Description
+	r.AIFeedbackCycle = plan.AIFeedbackCycle
+	r.PlanValidityPeriod = plan.PlanValidityPeriod
+}
+
+// NewExerciseRecord converts an exercise into its database row at the given position.
+// The exercise ID and session are left for the caller to set.
+func NewExerciseRecord(position int, exercise Exercise) ExerciseRecord {
+	return ExerciseRecord{
+		Position: position,
+		Name:     exercise.Name,
+		Sets:     exercise.Sets,
+		Reps:     exercise.Reps,
+		Weight: WeightRecord{
+			Value: exercise.Weight.Value,
+			Unit:  exercise.Weight.Unit,
+		},
+		Type:              exercise.Type,
+		CatalogExerciseID: exercise.CatalogID,
+	}
+}
+
+// ToModel converts the record back into the API representation. Sessions and
+// exercises must already be sorted by Position.
+func (r *WorkoutPlanRecord) ToModel() *WorkoutPlan {
+	plan := &WorkoutPlan{
+		ID:                   int(r.ID),
+		UserID:               r.UserID,
+		Name:                 r.Name,
+		Description:          r.Description,
+		CreatedAt:            r.CreatedAt,
+		AIFeedbackCycle:      r.AIFeedbackCycle,
+		PlanValidityPeriod:   r.PlanValidityPeriod,
+		SessionsCompleted:    r.SessionsCompleted,
+		PlanStartDate:        r.PlanStartDate,
+		HasNewPlanSuggestion: r.HasNewPlanSuggestion,
+		SuggestedPlan:        r.SuggestedPlan,
+		Sessions:             make([]WorkoutSession, len(r.Sessions)),
+	}
+
+	for i, session := range r.Sessions {
+		exercises := make([]Exercise, len(session.Exercises))
+		for j, exercise := range session.Exercises {
+			exercises[j] = Exercise{
+				ID:   int(exercise.ID),
+				Name: exercise.Name,
+				Sets: exercise.Sets,
+				Reps: exercise.Reps,
+				Weight: WeightInfo{
+					Value: exercise.Weight.Value,
+					Unit:  exercise.Weight.Unit,
+				},
+				Type:      exercise.Type,
+				CatalogID: exercise.CatalogExerciseID,
+			}
+		}
+		plan.Sessions[i] = WorkoutSession{
+			ID:        strconv.FormatUint(uint64(session.ID), 10),
+			Name:      session.Name,
+			Note:      session.Note,
+			Exercises: exercises,
+		}
+	}
+
+	return plan
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RefreshToken is a refresh token issued at login. Only the SHA-256 hash of the token is
+// stored. Tokens are single use: each refresh revokes the token and issues a new one in
+// the same family, so reuse of a revoked token can revoke the whole family.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -7,10 +7,45 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Name      string         `json:"name" gorm:"not null"`
-	Age       int            `json:"age" gorm:"not null"`
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"not null"`
+	Age  int    `json:"age" gorm:"not null"`
+	// FirebaseUID links the user to their Firebase account and Firestore profile. Users who
+	// sign up with an email and password get a generated UID, so plans, workouts and
+	// profiles are keyed the same way for both kinds of account.
+	FirebaseUID *string `json:"firebase_uid,omitempty" gorm:"uniqueIndex"`
+	// Email and PasswordHash (bcrypt) let the user sign in without Firebase
+	Email        *string `json:"email,omitempty" gorm:"uniqueIndex"`
+	PasswordHash string  `json:"-"`
+	// Admin users may act on behalf of any user
+	Admin     bool           `json:"admin" gorm:"not null;default:false"`
+	Profile   UserProfile    `json:"profile" gorm:"embedded;embeddedPrefix:profile_"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
-} 
\ No newline at end of file
+}
+
+// ProfileData returns the user in the shape plan generation reads from Firestore
+func (u *User) ProfileData() UserData {
+	profile := FirestoreUser{
+		UserProfile: u.Profile,
+		CreatedAt:   u.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   u.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if u.FirebaseUID != nil {
+		profile.UID = *u.FirebaseUID
+	}
+	if profile.FullName == "" {
+		profile.FullName = u.Name
+	}
+	if profile.DisplayName == "" {
+		profile.DisplayName = u.Name
+	}
+
+	return UserData{
+		Collection: "users",
+		Data:       profile,
+		DocumentID: profile.UID,
+		Success:    true,
+	}
+}
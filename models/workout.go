@@ -5,6 +5,7 @@ import "time"
 // WorkoutPlan represents the complete workout plan structure
 type WorkoutPlan struct {
 	ID                   int              `json:"id"`
+	UserID               string           `json:"userId,omitempty" jsonschema:"-"`
 	Name                 string           `json:"name"`
 	Description          string           `json:"description"`
 	CreatedAt            time.Time        `json:"createdAt"`
@@ -19,7 +20,7 @@ type WorkoutPlan struct {
 
 // SuggestedPlan represents a suggested workout plan
 type SuggestedPlan struct {
-	ID           string           `json:"id"`
+	ID           string           `json:"id" jsonschema:"-"`
 	Name         string           `json:"name"`
 	Description  string           `json:"description"`
 	Reason       string           `json:"reason"`
@@ -57,13 +58,16 @@ type Exercise struct {
 	Sets   int        `json:"sets"`
 	Reps   int        `json:"reps"`
 	Weight WeightInfo `json:"weight"`
-	Type   string     `json:"type"`
+	Type   string     `json:"type" enum:"weight,bodyweight,cardio,flexibility"`
+	// CatalogID is the exercise library entry the name was resolved to; it is unset for
+	// exercises the catalog doesn't know
+	CatalogID *uint `json:"catalogId,omitempty" jsonschema:"-"`
 }
 
 // WeightInfo represents weight information for an exercise
 type WeightInfo struct {
 	Value float64 `json:"value"`
-	Unit  string  `json:"unit"`
+	Unit  string  `json:"unit" enum:"LB,KG,BODYWEIGHT"`
 }
 
 // UserData represents the user data structure from Firestore
@@ -76,22 +80,28 @@ type UserData struct {
 
 // FirestoreUser represents the user information from Firestore
 type FirestoreUser struct {
+	UserProfile
+	CreatedAt string `json:"createdAt"`
+	UID       string `json:"uid"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// UserProfile is the canonical fitness profile used to personalize workout plans.
+// It is stored in Firestore by the mobile app and in the users table for API users.
+type UserProfile struct {
 	ActivityLevel string          `json:"activityLevel"`
-	CreatedAt     string          `json:"createdAt"`
 	DateOfBirth   string          `json:"dateOfBirth"`
 	DisplayName   string          `json:"displayName"`
-	Equipment     []string        `json:"equipment"`
+	Equipment     []string        `json:"equipment" gorm:"serializer:json;type:jsonb"`
 	FitnessLevel  string          `json:"fitnessLevel"`
 	FullName      string          `json:"fullName"`
 	Gender        string          `json:"gender"`
-	Goals         []string        `json:"goals"`
-	Height        Measurement     `json:"height"`
+	Goals         []string        `json:"goals" gorm:"serializer:json;type:jsonb"`
+	Height        Measurement     `json:"height" gorm:"embedded;embeddedPrefix:height_"`
 	Location      string          `json:"location"`
-	Preferences   UserPreferences `json:"preferences"`
-	Stats         UserStats       `json:"stats"`
-	UID           string          `json:"uid"`
-	UpdatedAt     string          `json:"updatedAt"`
-	Weight        Measurement     `json:"weight"`
+	Preferences   UserPreferences `json:"preferences" gorm:"serializer:json;type:jsonb"`
+	Stats         UserStats       `json:"stats" gorm:"serializer:json;type:jsonb"`
+	Weight        Measurement     `json:"weight" gorm:"embedded;embeddedPrefix:weight_"`
 }
 
 // Measurement represents height or weight measurement
@@ -127,6 +137,7 @@ type UserStats struct {
 	CurrentStreak int `json:"currentStreak"`
 	LongestStreak int `json:"longestStreak"`
 	TotalTime     int `json:"totalTime"`
+	// TotalVolume is the volume of all completed workouts, in kilograms
 	TotalVolume   int `json:"totalVolume"`
 	TotalWorkouts int `json:"totalWorkouts"`
 }
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// WorkoutLogStatus is the lifecycle state of a performed workout
+type WorkoutLogStatus string
+
+const (
+	WorkoutLogInProgress WorkoutLogStatus = "in_progress"
+	WorkoutLogCompleted  WorkoutLogStatus = "completed"
+)
+
+// WorkoutLog records one performance of a WorkoutSession from a plan
+type WorkoutLog struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	UserID      string           `json:"userId" gorm:"index;not null"`
+	PlanID      uint             `json:"planId" gorm:"index;not null"`
+	SessionID   string           `json:"sessionId" gorm:"not null"`
+	SessionName string           `json:"sessionName"`
+	Status      WorkoutLogStatus `json:"status" gorm:"size:16;not null"`
+	StartedAt   time.Time        `json:"startedAt" gorm:"not null"`
+	CompletedAt *time.Time       `json:"completedAt,omitempty"`
+	// DurationSeconds is the total time spent, set when the workout is completed
+	DurationSeconds int `json:"durationSeconds" gorm:"not null;default:0"`
+	// TotalVolume is the sum of the volume of all logged sets, in kilograms
+	TotalVolume float64   `json:"totalVolume" gorm:"not null;default:0"`
+	Notes       string    `json:"notes"`
+	Sets        []SetLog  `json:"sets" gorm:"foreignKey:WorkoutLogID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// SetLog is a single set actually performed for an Exercise of the session
+type SetLog struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	WorkoutLogID uint       `json:"-" gorm:"index;not null"`
+	ExerciseID   int        `json:"exerciseId" gorm:"not null"`
+	ExerciseName string     `json:"exerciseName"`
+	SetNumber    int        `json:"setNumber" gorm:"not null"`
+	Reps         int        `json:"reps" gorm:"not null"`
+	Weight       WeightInfo `json:"weight" gorm:"embedded;embeddedPrefix:weight_"`
+	// TargetReps and TargetWeight are what the plan prescribed when the set was logged
+	TargetReps   int        `json:"targetReps" gorm:"not null;default:0"`
+	TargetWeight WeightInfo `json:"targetWeight" gorm:"embedded;embeddedPrefix:target_weight_"`
+	// RPE is the rate of perceived exertion, 1-10
+	RPE             *float64  `json:"rpe,omitempty"`
+	DurationSeconds int       `json:"durationSeconds" gorm:"not null;default:0"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// kilogramsPerPound converts weights logged in pounds
+const kilogramsPerPound = 0.45359237
+
+// Volume returns reps x weight for the set in kilograms, so that sets logged in pounds
+// and kilograms add up. Bodyweight sets carry no load and have no volume.
+func (s SetLog) Volume() float64 {
+	switch s.Weight.Unit {
+	case "BODYWEIGHT":
+		return 0
+	case "LB":
+		return float64(s.Reps) * s.Weight.Value * kilogramsPerPound
+	default:
+		return float64(s.Reps) * s.Weight.Value
+	}
+}
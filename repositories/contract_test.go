@@ -0,0 +1,310 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"fit-ai-api/migrations"
+	"fit-ai-api/models"
+)
+
+// backend is a set of repositories the contract tests run against
+type backend struct {
+	name  string
+	users UserRepository
+	plans WorkoutPlanRepository
+	jobs  JobRepository
+}
+
+// backends returns the in-memory repositories and, when TEST_DATABASE_URL points at a
+// Postgres database, the GORM ones. The database is migrated to the latest version;
+// rows are never cleaned up, so use a throwaway database.
+func backends(t *testing.T) []backend {
+	t.Helper()
+	list := []backend{{
+		name:  "memory",
+		users: NewMemoryUserRepository(),
+		plans: NewMemoryWorkoutPlanRepository(),
+		jobs:  NewMemoryJobRepository(),
+	}}
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Log("TEST_DATABASE_URL is not set; skipping the GORM backend")
+		return list
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrating the test database: %v", err)
+	}
+	return append(list, backend{
+		name:  "gorm",
+		users: NewGormUserRepository(db),
+		plans: NewGormWorkoutPlanRepository(db),
+		jobs:  NewGormJobRepository(db),
+	})
+}
+
+// uniqueValue returns a string no earlier test run used, so runs can share a database
+func uniqueValue(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			uid := uniqueValue("uid")
+			email := uniqueValue("user") + "@example.com"
+
+			user := &models.User{Name: "Ada", Age: 36, FirebaseUID: &uid, Email: &email}
+			if err := b.users.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			if user.ID == 0 || user.CreatedAt.IsZero() {
+				t.Fatalf("Create didn't set the ID and timestamps: %+v", user)
+			}
+
+			byUID, err := b.users.GetByFirebaseUID(ctx, uid)
+			if err != nil || byUID.ID != user.ID {
+				t.Fatalf("GetByFirebaseUID = %+v, %v", byUID, err)
+			}
+			byEmail, err := b.users.GetByEmail(ctx, email)
+			if err != nil || byEmail.ID != user.ID {
+				t.Fatalf("GetByEmail = %+v, %v", byEmail, err)
+			}
+
+			// A second user can't take the first one's UID or email
+			if err := b.users.Create(ctx, &models.User{Name: "Copy", FirebaseUID: &uid}); !errors.Is(err, ErrConflict) {
+				t.Errorf("Create with a taken UID: error = %v, want ErrConflict", err)
+			}
+			if err := b.users.Create(ctx, &models.User{Name: "Copy", Email: &email}); !errors.Is(err, ErrConflict) {
+				t.Errorf("Create with a taken email: error = %v, want ErrConflict", err)
+			}
+			other := &models.User{Name: "Grace"}
+			if err := b.users.Create(ctx, other); err != nil {
+				t.Fatal(err)
+			}
+			other.FirebaseUID = &uid
+			if err := b.users.Update(ctx, other); !errors.Is(err, ErrConflict) {
+				t.Errorf("Update to a taken UID: error = %v, want ErrConflict", err)
+			}
+			other.FirebaseUID = nil
+			other.Email = &email
+			if err := b.users.Update(ctx, other); !errors.Is(err, ErrConflict) {
+				t.Errorf("Update to a taken email: error = %v, want ErrConflict", err)
+			}
+
+			users, err := b.users.List(ctx)
+			if err != nil || !containsUser(users, user.ID) {
+				t.Fatalf("List = %v, %v; want the new user", users, err)
+			}
+
+			user.Name = "Ada Lovelace"
+			if err := b.users.Update(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := b.users.Get(ctx, user.ID)
+			if err != nil || stored.Name != "Ada Lovelace" {
+				t.Fatalf("Get after Update = %+v, %v", stored, err)
+			}
+
+			if err := b.users.Delete(ctx, user.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.users.Get(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete: error = %v, want ErrNotFound", err)
+			}
+			if err := b.users.Delete(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("second Delete: error = %v, want ErrNotFound", err)
+			}
+			if err := b.users.Update(ctx, user); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Update after Delete: error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func containsUser(users []models.User, id uint) bool {
+	for _, user := range users {
+		if user.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// contractPlan returns a two-session plan with two exercises per session
+func contractPlan() *models.WorkoutPlan {
+	plan := &models.WorkoutPlan{
+		Name:               "Upper/Lower",
+		Description:        "Two sessions a week",
+		AIFeedbackCycle:    4,
+		PlanValidityPeriod: 28,
+	}
+	for _, name := range []string{"Upper", "Lower"} {
+		session := models.WorkoutSession{Name: name}
+		for i := 1; i <= 2; i++ {
+			session.Exercises = append(session.Exercises, models.Exercise{
+				Name:   fmt.Sprintf("%s %d", name, i),
+				Sets:   3,
+				Reps:   8,
+				Weight: models.WeightInfo{Value: 20, Unit: "KG"},
+				Type:   "weight",
+			})
+		}
+		plan.Sessions = append(plan.Sessions, session)
+	}
+	return plan
+}
+
+func TestWorkoutPlanRepositoryContract(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			userID := uniqueValue("owner")
+
+			plan, err := b.plans.Create(ctx, userID, contractPlan())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.ID == 0 || plan.UserID != userID || len(plan.Sessions) != 2 || plan.Sessions[0].ID == "" || plan.Sessions[0].Exercises[0].ID == 0 {
+				t.Fatalf("Create = %+v", plan)
+			}
+			plans, err := b.plans.ListByUser(ctx, userID)
+			if err != nil || len(plans) != 1 || plans[0].ID != plan.ID {
+				t.Fatalf("ListByUser = %v, %v", plans, err)
+			}
+
+			if _, err := b.plans.IncrementSessionsCompleted(ctx, uint(plan.ID)); err != nil {
+				t.Fatal(err)
+			}
+			suggestion := &models.SuggestedPlan{Name: "Heavier", Reason: "Progress stalled"}
+			if _, err := b.plans.SetSuggestion(ctx, uint(plan.ID), suggestion); err != nil {
+				t.Fatal(err)
+			}
+
+			// Update keeps the IDs of the rows it is sent and the server-owned fields
+			edit := *plan
+			edit.Name = "Upper/Lower v2"
+			edit.SessionsCompleted = 0
+			edit.HasNewPlanSuggestion = false
+			edit.SuggestedPlan = nil
+			edit.Sessions = []models.WorkoutSession{plan.Sessions[0]}
+			edit.Sessions[0].Exercises = []models.Exercise{plan.Sessions[0].Exercises[1], {
+				Name: "Face Pull", Sets: 3, Reps: 15, Weight: models.WeightInfo{Value: 10, Unit: "KG"}, Type: "weight",
+			}}
+			edit.Sessions[0].Exercises[0].Reps = 10
+			updated, err := b.plans.Update(ctx, uint(plan.ID), &edit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Name != "Upper/Lower v2" || updated.SessionsCompleted != 1 || !updated.HasNewPlanSuggestion || updated.SuggestedPlan == nil {
+				t.Errorf("Update changed server-owned fields: %+v", updated)
+			}
+			if len(updated.Sessions) != 1 || len(updated.Sessions[0].Exercises) != 2 {
+				t.Fatalf("Update sessions = %+v", updated.Sessions)
+			}
+			session := updated.Sessions[0]
+			kept, added := session.Exercises[0], session.Exercises[1]
+			if session.ID != plan.Sessions[0].ID || kept.ID != plan.Sessions[0].Exercises[1].ID || kept.Reps != 10 {
+				t.Errorf("Update didn't edit the session and exercise in place: %+v", session)
+			}
+			for _, old := range append(plan.Sessions[0].Exercises, plan.Sessions[1].Exercises...) {
+				if added.ID == old.ID {
+					t.Errorf("added exercise reused ID %d", old.ID)
+				}
+			}
+
+			// Replace gives every row a new ID and drops progress and the suggestion
+			replaced, err := b.plans.Replace(ctx, uint(plan.ID), contractPlan())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replaced.SessionsCompleted != 0 || replaced.HasNewPlanSuggestion || replaced.SuggestedPlan != nil || replaced.UserID != userID {
+				t.Errorf("Replace = %+v", replaced)
+			}
+			for _, replacedSession := range replaced.Sessions {
+				for _, exercise := range replacedSession.Exercises {
+					if exercise.ID == kept.ID || exercise.ID == added.ID {
+						t.Errorf("Replace kept exercise ID %d", exercise.ID)
+					}
+				}
+			}
+
+			if err := b.plans.Delete(ctx, uint(plan.ID)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.plans.Get(ctx, uint(plan.ID)); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete: error = %v, want ErrNotFound", err)
+			}
+			if _, err := b.plans.Update(ctx, uint(plan.ID), &edit); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Update after Delete: error = %v, want ErrNotFound", err)
+			}
+			if err := b.plans.Delete(ctx, uint(plan.ID)); !errors.Is(err, ErrNotFound) {
+				t.Errorf("second Delete: error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestJobRepositoryContract(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			id := fmt.Sprintf("%032d", time.Now().UnixNano())
+			job := &models.Job{ID: id, Type: models.JobTypePlanGeneration, UserID: "owner", Status: models.JobStatusQueued}
+			if err := b.jobs.Create(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.jobs.Create(ctx, &models.Job{ID: id, Type: models.JobTypePlanGeneration, UserID: "owner", Status: models.JobStatusQueued}); !errors.Is(err, ErrConflict) {
+				t.Errorf("Create with a taken ID: error = %v, want ErrConflict", err)
+			}
+
+			unfinished, err := b.jobs.ListUnfinished(ctx)
+			if err != nil || !containsJob(unfinished, id) {
+				t.Fatalf("ListUnfinished = %v, %v; want the queued job", unfinished, err)
+			}
+
+			job.Status = models.JobStatusSucceeded
+			job.Attempts = 1
+			job.Result = models.JSON(`{"ok":true}`)
+			if err := b.jobs.Save(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := b.jobs.Get(ctx, id)
+			if err != nil || stored.Status != models.JobStatusSucceeded || stored.Attempts != 1 {
+				t.Fatalf("Get after Save = %+v, %v", stored, err)
+			}
+			if unfinished, _ := b.jobs.ListUnfinished(ctx); containsJob(unfinished, id) {
+				t.Error("ListUnfinished returned a succeeded job")
+			}
+			if _, err := b.jobs.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(missing): error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func containsJob(jobs []models.Job, id string) bool {
+	for _, job := range jobs {
+		if job.ID == id {
+			return true
+		}
+	}
+	return false
+}
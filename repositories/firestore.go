@@ -0,0 +1,847 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"fit-ai-api/models"
+)
+
+// Firestore collections used by the repositories. Profiles live in "users", which the
+// mobile app owns, so API users get a collection of their own.
+const (
+	profileCollection     = "users"
+	apiUserCollection     = "api_users"
+	workoutPlanCollection = "workout_plans"
+	workoutLogCollection  = "workout_logs"
+	counterCollection     = "counters"
+)
+
+// FirestoreUserRepository stores users in Firestore with numeric IDs taken from a
+// counter document
+type FirestoreUserRepository struct {
+	client  *firestore.Client
+	timeout time.Duration
+}
+
+// NewFirestoreUserRepository creates a user repository backed by Firestore
+func NewFirestoreUserRepository(client *firestore.Client, timeout time.Duration) *FirestoreUserRepository {
+	return &FirestoreUserRepository{client: client, timeout: timeout}
+}
+
+// List returns all users ordered by ID
+func (r *FirestoreUserRepository) List(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(apiUserCollection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", contextError(ctx, err))
+	}
+
+	users := make([]models.User, 0, len(docs))
+	for _, doc := range docs {
+		var user models.User
+		if err := fromDocument(doc.Data(), &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Get returns a user by ID
+func (r *FirestoreUserRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	doc, err := r.client.Collection(apiUserCollection).Doc(docID(id)).Get(ctx)
+	if err != nil {
+		return nil, notFoundOr(ctx, err, "failed to load user")
+	}
+
+	var user models.User
+	if err := fromDocument(doc.Data(), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByFirebaseUID returns the user linked to a Firebase account
+func (r *FirestoreUserRepository) GetByFirebaseUID(ctx context.Context, uid string) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docs, err := r.byFirebaseUID(uid).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", contextError(ctx, err))
+	}
+	if len(docs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var user models.User
+	if err := fromDocument(docs[0].Data(), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByEmail returns the user who signs in with email. Password hashes are not stored in
+// Firestore, so such users can't sign in with a password.
+func (r *FirestoreUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(apiUserCollection).Where("email", "==", email).Limit(1).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", contextError(ctx, err))
+	}
+	if len(docs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var user models.User
+	if err := fromDocument(docs[0].Data(), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Create stores a new user
+func (r *FirestoreUserRepository) Create(ctx context.Context, user *models.User) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	counter := r.client.Collection(counterCollection).Doc(apiUserCollection)
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var seq struct {
+			Next int64 `firestore:"next"`
+		}
+		if err := readCounter(tx, counter, &seq); err != nil {
+			return err
+		}
+		if err := r.checkUIDFree(tx, user); err != nil {
+			return err
+		}
+		seq.Next++
+
+		now := time.Now().UTC()
+		user.ID = uint(seq.Next)
+		user.CreatedAt = now
+		user.UpdatedAt = now
+		data, err := toDocument(user)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Set(counter, seq); err != nil {
+			return err
+		}
+		return tx.Create(r.client.Collection(apiUserCollection).Doc(docID(user.ID)), data)
+	})
+	if errors.Is(err, ErrConflict) {
+		return ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", contextError(ctx, err))
+	}
+	return nil
+}
+
+// Update saves an existing user
+func (r *FirestoreUserRepository) Update(ctx context.Context, user *models.User) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	ref := r.client.Collection(apiUserCollection).Doc(docID(user.ID))
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var existing models.User
+		if err := fromDocument(doc.Data(), &existing); err != nil {
+			return err
+		}
+		if err := r.checkUIDFree(tx, user); err != nil {
+			return err
+		}
+
+		user.CreatedAt = existing.CreatedAt
+		user.UpdatedAt = time.Now().UTC()
+		data, err := toDocument(user)
+		if err != nil {
+			return err
+		}
+		return tx.Set(ref, data)
+	})
+	if errors.Is(err, ErrConflict) {
+		return ErrConflict
+	}
+	if err != nil {
+		return notFoundOr(ctx, err, "failed to update user")
+	}
+	return nil
+}
+
+// Delete removes a user
+func (r *FirestoreUserRepository) Delete(ctx context.Context, id uint) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.client.Collection(apiUserCollection).Doc(docID(id)).Delete(ctx, firestore.Exists)
+	if err != nil {
+		return notFoundOr(ctx, err, "failed to delete user")
+	}
+	return nil
+}
+
+// byFirebaseUID queries the user linked to a Firebase account
+func (r *FirestoreUserRepository) byFirebaseUID(uid string) firestore.Query {
+	return r.client.Collection(apiUserCollection).Where("firebase_uid", "==", uid).Limit(1)
+}
+
+// checkUIDFree returns ErrConflict when another user already has user's Firebase UID
+func (r *FirestoreUserRepository) checkUIDFree(tx *firestore.Transaction, user *models.User) error {
+	if user.FirebaseUID == nil {
+		return nil
+	}
+	docs, err := tx.Documents(r.byFirebaseUID(*user.FirebaseUID)).GetAll()
+	if err != nil {
+		return err
+	}
+	if len(docs) > 0 && docs[0].Ref.ID != docID(user.ID) {
+		return ErrConflict
+	}
+	return nil
+}
+
+// FirestoreWorkoutPlanRepository stores each workout plan as a single document with its
+// sessions and exercises nested. Numeric IDs come from a counter document.
+type FirestoreWorkoutPlanRepository struct {
+	client  *firestore.Client
+	timeout time.Duration
+}
+
+// NewFirestoreWorkoutPlanRepository creates a plan repository backed by Firestore
+func NewFirestoreWorkoutPlanRepository(client *firestore.Client, timeout time.Duration) *FirestoreWorkoutPlanRepository {
+	return &FirestoreWorkoutPlanRepository{client: client, timeout: timeout}
+}
+
+// Create stores a new plan for the user
+func (r *FirestoreWorkoutPlanRepository) Create(ctx context.Context, userID string, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var stored *models.WorkoutPlan
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var seq idSequence
+		if err := readCounter(tx, r.counter(), &seq); err != nil {
+			return err
+		}
+
+		// Transactions may be retried, so start from a fresh copy every attempt
+		p, err := copyPlan(plan)
+		if err != nil {
+			return err
+		}
+		seq.Plan++
+		p.ID = int(seq.Plan)
+		p.UserID = userID
+		p.CreatedAt = time.Now().UTC()
+		if p.PlanStartDate.IsZero() {
+			p.PlanStartDate = p.CreatedAt
+		}
+		seq.number(p)
+
+		data, err := toDocument(p)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(r.counter(), seq); err != nil {
+			return err
+		}
+		if err := tx.Create(r.doc(uint(p.ID)), data); err != nil {
+			return err
+		}
+		stored = p
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save workout plan: %w", contextError(ctx, err))
+	}
+	return stored, nil
+}
+
+// Get returns a plan by ID
+func (r *FirestoreWorkoutPlanRepository) Get(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	doc, err := r.doc(id).Get(ctx)
+	if err != nil {
+		return nil, notFoundOr(ctx, err, "failed to load workout plan")
+	}
+
+	var plan models.WorkoutPlan
+	if err := fromDocument(doc.Data(), &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ListByUser returns the user's plans, newest first. Sorting happens here so the query
+// doesn't need a composite index.
+func (r *FirestoreWorkoutPlanRepository) ListByUser(ctx context.Context, userID string) ([]models.WorkoutPlan, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(workoutPlanCollection).Where("userId", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workout plans: %w", contextError(ctx, err))
+	}
+
+	plans := make([]models.WorkoutPlan, 0, len(docs))
+	for _, doc := range docs {
+		var plan models.WorkoutPlan
+		if err := fromDocument(doc.Data(), &plan); err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	sortPlansNewestFirst(plans)
+	return plans, nil
+}
+
+// Update saves a user's edit of a plan, keeping the IDs of matched sessions and exercises
+func (r *FirestoreWorkoutPlanRepository) Update(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var updated *models.WorkoutPlan
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(r.doc(id))
+		if err != nil {
+			return err
+		}
+		var existing models.WorkoutPlan
+		if err := fromDocument(doc.Data(), &existing); err != nil {
+			return err
+		}
+		var seq idSequence
+		if err := readCounter(tx, r.counter(), &seq); err != nil {
+			return err
+		}
+
+		// Transactions may be retried, so start from a fresh copy every attempt
+		edited, err := copyPlan(plan)
+		if err != nil {
+			return err
+		}
+		seq.applyEdits(&existing, edited)
+
+		data, err := toDocument(existing)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(r.counter(), seq); err != nil {
+			return err
+		}
+		if err := tx.Set(r.doc(id), data); err != nil {
+			return err
+		}
+		updated = &existing
+		return nil
+	})
+	if err != nil {
+		return nil, notFoundOr(ctx, err, "failed to update workout plan")
+	}
+	return updated, nil
+}
+
+// Replace replaces the contents of a plan, giving its sessions and exercises new IDs
+func (r *FirestoreWorkoutPlanRepository) Replace(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var updated *models.WorkoutPlan
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(r.doc(id))
+		if err != nil {
+			return err
+		}
+		var existing models.WorkoutPlan
+		if err := fromDocument(doc.Data(), &existing); err != nil {
+			return err
+		}
+		var seq idSequence
+		if err := readCounter(tx, r.counter(), &seq); err != nil {
+			return err
+		}
+
+		p, err := copyPlan(plan)
+		if err != nil {
+			return err
+		}
+		p.ID = existing.ID
+		p.UserID = existing.UserID
+		p.CreatedAt = existing.CreatedAt
+		if p.PlanStartDate.IsZero() {
+			p.PlanStartDate = existing.PlanStartDate
+		}
+		seq.number(p)
+
+		data, err := toDocument(p)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(r.counter(), seq); err != nil {
+			return err
+		}
+		if err := tx.Set(r.doc(id), data); err != nil {
+			return err
+		}
+		updated = p
+		return nil
+	})
+	if err != nil {
+		return nil, notFoundOr(ctx, err, "failed to update workout plan")
+	}
+	return updated, nil
+}
+
+// IncrementSessionsCompleted adds one to the plan's completed session count
+func (r *FirestoreWorkoutPlanRepository) IncrementSessionsCompleted(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	updateCtx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.doc(id).Update(updateCtx, []firestore.Update{
+		{Path: "sessionsCompleted", Value: firestore.Increment(1)},
+	})
+	if err != nil {
+		return nil, notFoundOr(updateCtx, err, "failed to update workout plan")
+	}
+	return r.Get(ctx, id)
+}
+
+// UpdateExerciseTargets updates the prescription of individual exercises in place
+func (r *FirestoreWorkoutPlanRepository) UpdateExerciseTargets(ctx context.Context, id uint, targets []models.Exercise) (*models.WorkoutPlan, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var updated models.WorkoutPlan
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(r.doc(id))
+		if err != nil {
+			return err
+		}
+		var plan models.WorkoutPlan
+		if err := fromDocument(doc.Data(), &plan); err != nil {
+			return err
+		}
+		applyExerciseTargets(&plan, targets)
+
+		data, err := toDocument(plan)
+		if err != nil {
+			return err
+		}
+		if err := tx.Update(r.doc(id), []firestore.Update{{Path: "sessions", Value: data["sessions"]}}); err != nil {
+			return err
+		}
+		updated = plan
+		return nil
+	})
+	if err != nil {
+		return nil, notFoundOr(ctx, err, "failed to update workout plan")
+	}
+	return &updated, nil
+}
+
+// ReplaceExercise swaps one exercise of the plan for another in place
+func (r *FirestoreWorkoutPlanRepository) ReplaceExercise(ctx context.Context, id uint, exercise models.Exercise) (*models.WorkoutPlan, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var updated models.WorkoutPlan
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(r.doc(id))
+		if err != nil {
+			return err
+		}
+		var plan models.WorkoutPlan
+		if err := fromDocument(doc.Data(), &plan); err != nil {
+			return err
+		}
+		applyExerciseReplacement(&plan, exercise)
+
+		data, err := toDocument(plan)
+		if err != nil {
+			return err
+		}
+		if err := tx.Update(r.doc(id), []firestore.Update{{Path: "sessions", Value: data["sessions"]}}); err != nil {
+			return err
+		}
+		updated = plan
+		return nil
+	})
+	if err != nil {
+		return nil, notFoundOr(ctx, err, "failed to update workout plan")
+	}
+	return &updated, nil
+}
+
+// SetSuggestion stores or clears the plan's suggested plan
+func (r *FirestoreWorkoutPlanRepository) SetSuggestion(ctx context.Context, id uint, suggestion *models.SuggestedPlan) (*models.WorkoutPlan, error) {
+	var value interface{}
+	if suggestion != nil {
+		data, err := toDocument(suggestion)
+		if err != nil {
+			return nil, err
+		}
+		value = data
+	}
+
+	updateCtx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.doc(id).Update(updateCtx, []firestore.Update{
+		{Path: "suggestedPlan", Value: value},
+		{Path: "hasNewPlanSuggestion", Value: suggestion != nil},
+	})
+	if err != nil {
+		return nil, notFoundOr(updateCtx, err, "failed to update workout plan")
+	}
+	return r.Get(ctx, id)
+}
+
+// Delete removes a plan
+func (r *FirestoreWorkoutPlanRepository) Delete(ctx context.Context, id uint) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if _, err := r.doc(id).Delete(ctx, firestore.Exists); err != nil {
+		return notFoundOr(ctx, err, "failed to delete workout plan")
+	}
+	return nil
+}
+
+func (r *FirestoreWorkoutPlanRepository) doc(id uint) *firestore.DocumentRef {
+	return r.client.Collection(workoutPlanCollection).Doc(docID(id))
+}
+
+func (r *FirestoreWorkoutPlanRepository) counter() *firestore.DocumentRef {
+	return r.client.Collection(counterCollection).Doc(workoutPlanCollection)
+}
+
+// FirestoreProfileRepository reads fitness profiles from the "users" collection
+type FirestoreProfileRepository struct {
+	client  *firestore.Client
+	timeout time.Duration
+}
+
+// NewFirestoreProfileRepository creates a profile repository backed by Firestore
+func NewFirestoreProfileRepository(client *firestore.Client, timeout time.Duration) *FirestoreProfileRepository {
+	return &FirestoreProfileRepository{client: client, timeout: timeout}
+}
+
+// Get loads a user's profile
+func (r *FirestoreProfileRepository) Get(ctx context.Context, userID string) (models.UserData, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	userData := models.UserData{
+		Collection: profileCollection,
+		DocumentID: userID,
+	}
+
+	doc, err := r.client.Collection(profileCollection).Doc(userID).Get(ctx)
+	if err != nil {
+		return userData, notFoundOr(ctx, err, "failed to load user profile")
+	}
+
+	// The document itself is the profile; round-trip through JSON to apply the model's tags
+	if err := fromDocument(doc.Data(), &userData.Data); err != nil {
+		return userData, fmt.Errorf("failed to parse user data: %w", err)
+	}
+
+	userData.Success = true
+	return userData, nil
+}
+
+// UpdateStats replaces the "stats" field of the user's profile document
+func (r *FirestoreProfileRepository) UpdateStats(ctx context.Context, userID string, stats models.UserStats) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	fields, err := toDocument(stats)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Collection(profileCollection).Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "stats", Value: fields},
+	})
+	if err != nil {
+		return notFoundOr(ctx, err, "failed to update user stats")
+	}
+	return nil
+}
+
+// FirestoreWorkoutLogRepository stores each performed workout as a document with its sets
+// nested. Numeric IDs come from a counter document.
+type FirestoreWorkoutLogRepository struct {
+	client  *firestore.Client
+	timeout time.Duration
+}
+
+// logSequence hands out workout and set IDs
+type logSequence struct {
+	Log int64 `firestore:"log"`
+	Set int64 `firestore:"set"`
+}
+
+// NewFirestoreWorkoutLogRepository creates a workout log repository backed by Firestore
+func NewFirestoreWorkoutLogRepository(client *firestore.Client, timeout time.Duration) *FirestoreWorkoutLogRepository {
+	return &FirestoreWorkoutLogRepository{client: client, timeout: timeout}
+}
+
+// Create stores a new workout
+func (r *FirestoreWorkoutLogRepository) Create(ctx context.Context, log *models.WorkoutLog) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var seq logSequence
+		if err := readCounter(tx, r.counter(), &seq); err != nil {
+			return err
+		}
+		seq.Log++
+
+		now := time.Now().UTC()
+		log.ID = uint(seq.Log)
+		log.CreatedAt = now
+		log.UpdatedAt = now
+		data, err := toDocument(log)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(r.counter(), seq); err != nil {
+			return err
+		}
+		return tx.Create(r.doc(log.ID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create workout: %w", contextError(ctx, err))
+	}
+	return nil
+}
+
+// Get returns a workout with its sets
+func (r *FirestoreWorkoutLogRepository) Get(ctx context.Context, id uint) (*models.WorkoutLog, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	doc, err := r.doc(id).Get(ctx)
+	if err != nil {
+		return nil, notFoundOr(ctx, err, "failed to load workout")
+	}
+
+	var log models.WorkoutLog
+	if err := fromDocument(doc.Data(), &log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// ListByUser returns the user's workouts, most recently started first
+func (r *FirestoreWorkoutLogRepository) ListByUser(ctx context.Context, userID string) ([]models.WorkoutLog, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(workoutLogCollection).Where("userId", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workouts: %w", contextError(ctx, err))
+	}
+
+	logs := make([]models.WorkoutLog, 0, len(docs))
+	for _, doc := range docs {
+		var log models.WorkoutLog
+		if err := fromDocument(doc.Data(), &log); err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	sortLogsNewestFirst(logs)
+	return logs, nil
+}
+
+// AddSet appends a set to a workout
+func (r *FirestoreWorkoutLogRepository) AddSet(ctx context.Context, logID uint, set *models.SetLog) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		log, err := r.getInTx(tx, logID)
+		if err != nil {
+			return err
+		}
+		var seq logSequence
+		if err := readCounter(tx, r.counter(), &seq); err != nil {
+			return err
+		}
+		seq.Set++
+
+		set.ID = uint(seq.Set)
+		set.WorkoutLogID = logID
+		set.CreatedAt = time.Now().UTC()
+		log.Sets = append(log.Sets, *set)
+		log.UpdatedAt = set.CreatedAt
+
+		data, err := toDocument(log)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(r.counter(), seq); err != nil {
+			return err
+		}
+		return tx.Set(r.doc(logID), data)
+	})
+	if err != nil {
+		return notFoundOr(ctx, err, "failed to log set")
+	}
+	return nil
+}
+
+// Complete saves the completion fields of a workout that is still in progress
+func (r *FirestoreWorkoutLogRepository) Complete(ctx context.Context, log *models.WorkoutLog) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		stored, err := r.getInTx(tx, log.ID)
+		if err != nil {
+			return err
+		}
+		if stored.Status != models.WorkoutLogInProgress {
+			return ErrConflict
+		}
+
+		stored.Status = log.Status
+		stored.CompletedAt = log.CompletedAt
+		stored.DurationSeconds = log.DurationSeconds
+		stored.TotalVolume = log.TotalVolume
+		stored.Notes = log.Notes
+		stored.UpdatedAt = time.Now().UTC()
+		data, err := toDocument(stored)
+		if err != nil {
+			return err
+		}
+		return tx.Set(r.doc(log.ID), data)
+	})
+	if errors.Is(err, ErrConflict) {
+		return ErrConflict
+	}
+	if err != nil {
+		return notFoundOr(ctx, err, "failed to complete workout")
+	}
+	return nil
+}
+
+// getInTx reads a workout inside a transaction
+func (r *FirestoreWorkoutLogRepository) getInTx(tx *firestore.Transaction, id uint) (*models.WorkoutLog, error) {
+	doc, err := tx.Get(r.doc(id))
+	if err != nil {
+		return nil, err
+	}
+	var log models.WorkoutLog
+	if err := fromDocument(doc.Data(), &log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+func (r *FirestoreWorkoutLogRepository) doc(id uint) *firestore.DocumentRef {
+	return r.client.Collection(workoutLogCollection).Doc(docID(id))
+}
+
+func (r *FirestoreWorkoutLogRepository) counter() *firestore.DocumentRef {
+	return r.client.Collection(counterCollection).Doc(workoutLogCollection)
+}
+
+// readCounter loads a counter document inside a transaction; a missing counter leaves
+// dst at its zero value
+func readCounter(tx *firestore.Transaction, ref *firestore.DocumentRef, dst interface{}) error {
+	doc, err := tx.Get(ref)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return doc.DataTo(dst)
+}
+
+// toDocument converts a model into Firestore fields using its json tags, so documents
+// match the API representation
+func toDocument(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return fields, nil
+}
+
+// fromDocument decodes Firestore fields into a model using its json tags
+func fromDocument(fields map[string]interface{}, dst interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	return nil
+}
+
+// notFoundOr maps a Firestore NotFound status to ErrNotFound and wraps anything else
+func notFoundOr(ctx context.Context, err error, message string) error {
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	return fmt.Errorf("%s: %w", message, contextError(ctx, err))
+}
+
+// withTimeout applies the Firestore deadline to a single operation
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextError reports cancellation and deadline errors as the plain context error
+// instead of the gRPC status Firestore wraps them in
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func docID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,758 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"fit-ai-api/models"
+)
+
+// GormUserRepository stores users in Postgres
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository creates a user repository backed by db
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+// List returns all users
+func (r *GormUserRepository) List(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.WithContext(ctx).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
+
+// Get returns a user by ID
+func (r *GormUserRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	return &user, nil
+}
+
+// GetByFirebaseUID returns the user linked to a Firebase account
+func (r *GormUserRepository) GetByFirebaseUID(ctx context.Context, uid string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("firebase_uid = ?", uid).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	return &user, nil
+}
+
+// GetByEmail returns the user who signs in with email
+func (r *GormUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	return &user, nil
+}
+
+// Create stores a new user
+func (r *GormUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// Update saves an existing user
+func (r *GormUserRepository) Update(ctx context.Context, user *models.User) error {
+	result := r.db.WithContext(ctx).Model(user).Select("*").Omit("created_at", "deleted_at").Updates(user)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return ErrConflict
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete soft-deletes a user
+func (r *GormUserRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.User{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GormWorkoutPlanRepository stores workout plans in Postgres
+type GormWorkoutPlanRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWorkoutPlanRepository creates a plan repository backed by db
+func NewGormWorkoutPlanRepository(db *gorm.DB) *GormWorkoutPlanRepository {
+	return &GormWorkoutPlanRepository{db: db}
+}
+
+// Create stores a new plan for the user and returns it with the IDs assigned by the database
+func (r *GormWorkoutPlanRepository) Create(ctx context.Context, userID string, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	record := models.NewWorkoutPlanRecord(userID, plan)
+	if err := r.db.WithContext(ctx).Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to save workout plan: %w", err)
+	}
+	return record.ToModel(), nil
+}
+
+// Get returns a plan with its sessions and exercises
+func (r *GormWorkoutPlanRepository) Get(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	record, err := r.load(r.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	return record.ToModel(), nil
+}
+
+// ListByUser returns all plans owned by the user, newest first
+func (r *GormWorkoutPlanRepository) ListByUser(ctx context.Context, userID string) ([]models.WorkoutPlan, error) {
+	var records []models.WorkoutPlanRecord
+	err := withPlanChildren(r.db.WithContext(ctx)).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workout plans: %w", err)
+	}
+
+	plans := make([]models.WorkoutPlan, len(records))
+	for i := range records {
+		plans[i] = *records[i].ToModel()
+	}
+	return plans, nil
+}
+
+// Update saves the editable fields of a plan and reconciles its sessions and exercises
+// by ID, so rows that are kept keep their IDs
+func (r *GormWorkoutPlanRepository) Update(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	var updated *models.WorkoutPlanRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record, err := r.load(tx, id)
+		if err != nil {
+			return err
+		}
+
+		record.ApplyEdits(plan)
+		err = tx.Model(&models.WorkoutPlanRecord{}).Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"name":                 record.Name,
+				"description":          record.Description,
+				"ai_feedback_cycle":    record.AIFeedbackCycle,
+				"plan_validity_period": record.PlanValidityPeriod,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update workout plan: %w", err)
+		}
+		if err := syncSessions(tx, record, plan.Sessions); err != nil {
+			return err
+		}
+
+		updated, err = r.load(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated.ToModel(), nil
+}
+
+// syncSessions makes the session and exercise rows of record match sessions. Sessions
+// and exercises are matched to the plan's rows by ID (exercises may move between
+// sessions); unmatched ones are inserted and rows left out are deleted.
+func syncSessions(tx *gorm.DB, record *models.WorkoutPlanRecord, sessions []models.WorkoutSession) error {
+	existingSessions := make(map[uint]bool, len(record.Sessions))
+	existingExercises := make(map[uint]bool)
+	for _, session := range record.Sessions {
+		existingSessions[session.ID] = true
+		for _, exercise := range session.Exercises {
+			existingExercises[exercise.ID] = true
+		}
+	}
+
+	keptSessions := make(map[uint]bool)
+	keptExercises := make(map[uint]bool)
+	for i, session := range sessions {
+		row := models.WorkoutSessionRecord{PlanID: record.ID, Position: i, Name: session.Name, Note: session.Note}
+		if sessionID, err := strconv.ParseUint(session.ID, 10, 64); err == nil && existingSessions[uint(sessionID)] && !keptSessions[uint(sessionID)] {
+			row.ID = uint(sessionID)
+			err := tx.Model(&models.WorkoutSessionRecord{}).Where("id = ?", row.ID).
+				Updates(map[string]interface{}{"position": row.Position, "name": row.Name, "note": row.Note}).Error
+			if err != nil {
+				return fmt.Errorf("failed to update workout session: %w", err)
+			}
+		} else if err := tx.Omit("Exercises").Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to add workout session: %w", err)
+		}
+		keptSessions[row.ID] = true
+
+		for j, exercise := range session.Exercises {
+			exerciseRow := models.NewExerciseRecord(j, exercise)
+			exerciseRow.SessionID = row.ID
+			exerciseID := uint(exercise.ID)
+			if exercise.ID > 0 && existingExercises[exerciseID] && !keptExercises[exerciseID] {
+				exerciseRow.ID = exerciseID
+				err := tx.Model(&models.ExerciseRecord{}).Where("id = ?", exerciseID).
+					Updates(map[string]interface{}{
+						"session_id":          exerciseRow.SessionID,
+						"position":            exerciseRow.Position,
+						"name":                exerciseRow.Name,
+						"sets":                exerciseRow.Sets,
+						"reps":                exerciseRow.Reps,
+						"weight_value":        exerciseRow.Weight.Value,
+						"weight_unit":         exerciseRow.Weight.Unit,
+						"type":                exerciseRow.Type,
+						"catalog_exercise_id": exerciseRow.CatalogExerciseID,
+					}).Error
+				if err != nil {
+					return fmt.Errorf("failed to update workout exercise: %w", err)
+				}
+			} else if err := tx.Create(&exerciseRow).Error; err != nil {
+				return fmt.Errorf("failed to add workout exercise: %w", err)
+			}
+			keptExercises[exerciseRow.ID] = true
+		}
+	}
+
+	var droppedExercises, droppedSessions []uint
+	for exerciseID := range existingExercises {
+		if !keptExercises[exerciseID] {
+			droppedExercises = append(droppedExercises, exerciseID)
+		}
+	}
+	for sessionID := range existingSessions {
+		if !keptSessions[sessionID] {
+			droppedSessions = append(droppedSessions, sessionID)
+		}
+	}
+	if len(droppedExercises) > 0 {
+		if err := tx.Delete(&models.ExerciseRecord{}, droppedExercises).Error; err != nil {
+			return fmt.Errorf("failed to delete workout exercises: %w", err)
+		}
+	}
+	if len(droppedSessions) > 0 {
+		if err := tx.Delete(&models.WorkoutSessionRecord{}, droppedSessions).Error; err != nil {
+			return fmt.Errorf("failed to delete workout sessions: %w", err)
+		}
+	}
+	return nil
+}
+
+// Replace replaces the contents of a plan, including all of its sessions and exercises.
+// The owner and creation time are kept.
+func (r *GormWorkoutPlanRepository) Replace(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	var updated *models.WorkoutPlanRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record, err := r.load(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Sessions and exercises are replaced wholesale
+		sessionIDs := tx.Model(&models.WorkoutSessionRecord{}).Select("id").Where("plan_id = ?", id)
+		if err := tx.Where("session_id IN (?)", sessionIDs).Delete(&models.ExerciseRecord{}).Error; err != nil {
+			return fmt.Errorf("failed to replace workout exercises: %w", err)
+		}
+		if err := tx.Where("plan_id = ?", id).Delete(&models.WorkoutSessionRecord{}).Error; err != nil {
+			return fmt.Errorf("failed to replace workout sessions: %w", err)
+		}
+
+		record.Apply(plan)
+		if err := tx.Save(record).Error; err != nil {
+			return fmt.Errorf("failed to update workout plan: %w", err)
+		}
+		updated = record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated.ToModel(), nil
+}
+
+// IncrementSessionsCompleted adds one to the plan's completed session count
+func (r *GormWorkoutPlanRepository) IncrementSessionsCompleted(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	result := r.db.WithContext(ctx).Model(&models.WorkoutPlanRecord{}).
+		Where("id = ?", id).
+		Update("sessions_completed", gorm.Expr("sessions_completed + 1"))
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update workout plan: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, id)
+}
+
+// UpdateExerciseTargets updates the prescription of individual exercises in place
+func (r *GormWorkoutPlanRepository) UpdateExerciseTargets(ctx context.Context, id uint, targets []models.Exercise) (*models.WorkoutPlan, error) {
+	var updated *models.WorkoutPlanRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := r.load(tx, id); err != nil {
+			return err
+		}
+
+		sessionIDs := tx.Model(&models.WorkoutSessionRecord{}).Select("id").Where("plan_id = ?", id)
+		for _, target := range targets {
+			err := tx.Model(&models.ExerciseRecord{}).
+				Where("id = ? AND session_id IN (?)", target.ID, sessionIDs).
+				Updates(map[string]interface{}{
+					"sets":         target.Sets,
+					"reps":         target.Reps,
+					"weight_value": target.Weight.Value,
+					"weight_unit":  target.Weight.Unit,
+				}).Error
+			if err != nil {
+				return fmt.Errorf("failed to update workout exercise: %w", err)
+			}
+		}
+
+		record, err := r.load(tx, id)
+		if err != nil {
+			return err
+		}
+		updated = record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated.ToModel(), nil
+}
+
+// ReplaceExercise swaps one exercise of the plan for another in place
+func (r *GormWorkoutPlanRepository) ReplaceExercise(ctx context.Context, id uint, exercise models.Exercise) (*models.WorkoutPlan, error) {
+	var updated *models.WorkoutPlanRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := r.load(tx, id); err != nil {
+			return err
+		}
+
+		sessionIDs := tx.Model(&models.WorkoutSessionRecord{}).Select("id").Where("plan_id = ?", id)
+		err := tx.Model(&models.ExerciseRecord{}).
+			Where("id = ? AND session_id IN (?)", exercise.ID, sessionIDs).
+			Updates(map[string]interface{}{
+				"name":                exercise.Name,
+				"type":                exercise.Type,
+				"weight_value":        exercise.Weight.Value,
+				"weight_unit":         exercise.Weight.Unit,
+				"catalog_exercise_id": exercise.CatalogID,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update workout exercise: %w", err)
+		}
+
+		record, err := r.load(tx, id)
+		if err != nil {
+			return err
+		}
+		updated = record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated.ToModel(), nil
+}
+
+// SetSuggestion stores or clears the plan's suggested plan
+func (r *GormWorkoutPlanRepository) SetSuggestion(ctx context.Context, id uint, suggestion *models.SuggestedPlan) (*models.WorkoutPlan, error) {
+	result := r.db.WithContext(ctx).Model(&models.WorkoutPlanRecord{ID: id}).
+		Select("SuggestedPlan", "HasNewPlanSuggestion").
+		Updates(&models.WorkoutPlanRecord{
+			SuggestedPlan:        suggestion,
+			HasNewPlanSuggestion: suggestion != nil,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update workout plan: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, id)
+}
+
+// Delete removes a plan
+func (r *GormWorkoutPlanRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.WorkoutPlanRecord{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete workout plan: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// load fetches a plan record with its children
+func (r *GormWorkoutPlanRepository) load(db *gorm.DB, id uint) (*models.WorkoutPlanRecord, error) {
+	var record models.WorkoutPlanRecord
+	err := withPlanChildren(db).First(&record, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workout plan: %w", err)
+	}
+	return &record, nil
+}
+
+// withPlanChildren preloads sessions and exercises in their stored order
+func withPlanChildren(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Sessions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Preload("Sessions.Exercises", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		})
+}
+
+// GormWorkoutLogRepository stores performed workouts in Postgres
+type GormWorkoutLogRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWorkoutLogRepository creates a workout log repository backed by db
+func NewGormWorkoutLogRepository(db *gorm.DB) *GormWorkoutLogRepository {
+	return &GormWorkoutLogRepository{db: db}
+}
+
+// Create stores a new workout
+func (r *GormWorkoutLogRepository) Create(ctx context.Context, log *models.WorkoutLog) error {
+	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+		return fmt.Errorf("failed to create workout: %w", err)
+	}
+	return nil
+}
+
+// Get returns a workout with its sets
+func (r *GormWorkoutLogRepository) Get(ctx context.Context, id uint) (*models.WorkoutLog, error) {
+	var log models.WorkoutLog
+	err := withSets(r.db.WithContext(ctx)).First(&log, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workout: %w", err)
+	}
+	return &log, nil
+}
+
+// ListByUser returns the user's workouts, most recently started first
+func (r *GormWorkoutLogRepository) ListByUser(ctx context.Context, userID string) ([]models.WorkoutLog, error) {
+	var logs []models.WorkoutLog
+	err := withSets(r.db.WithContext(ctx)).
+		Where("user_id = ?", userID).
+		Order("started_at DESC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workouts: %w", err)
+	}
+	return logs, nil
+}
+
+// AddSet appends a set to a workout
+func (r *GormWorkoutLogRepository) AddSet(ctx context.Context, logID uint, set *models.SetLog) error {
+	set.WorkoutLogID = logID
+	if err := r.db.WithContext(ctx).Create(set).Error; err != nil {
+		return fmt.Errorf("failed to log set: %w", err)
+	}
+	return nil
+}
+
+// Complete saves the completion fields of a workout that is still in progress
+func (r *GormWorkoutLogRepository) Complete(ctx context.Context, log *models.WorkoutLog) error {
+	result := r.db.WithContext(ctx).Model(&models.WorkoutLog{}).
+		Where("id = ? AND status = ?", log.ID, models.WorkoutLogInProgress).
+		Updates(map[string]interface{}{
+			"status":           log.Status,
+			"completed_at":     log.CompletedAt,
+			"duration_seconds": log.DurationSeconds,
+			"total_volume":     log.TotalVolume,
+			"notes":            log.Notes,
+			"updated_at":       time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to complete workout: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.Get(ctx, log.ID); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+// withSets preloads the sets of a workout in the order they were logged
+func withSets(db *gorm.DB) *gorm.DB {
+	return db.Preload("Sets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+// GormRefreshTokenRepository stores refresh tokens in Postgres
+type GormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRefreshTokenRepository creates a refresh token repository backed by GORM
+func NewGormRefreshTokenRepository(db *gorm.DB) *GormRefreshTokenRepository {
+	return &GormRefreshTokenRepository{db: db}
+}
+
+// Create stores a new refresh token
+func (r *GormRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash returns the refresh token with the given hash
+func (r *GormRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// Revoke marks a token revoked unless it already is
+func (r *GormRefreshTokenRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+// RevokeFamily revokes the unrevoked tokens of a family
+func (r *GormRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser revokes the unrevoked tokens of a user
+func (r *GormRefreshTokenRepository) RevokeUser(ctx context.Context, userID uint, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// GormJobRepository stores background jobs in Postgres
+type GormJobRepository struct {
+	db *gorm.DB
+}
+
+// NewGormJobRepository creates a job repository backed by GORM
+func NewGormJobRepository(db *gorm.DB) *GormJobRepository {
+	return &GormJobRepository{db: db}
+}
+
+// Create stores a new job
+func (r *GormJobRepository) Create(ctx context.Context, job *models.Job) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+// Get returns a job by ID
+func (r *GormJobRepository) Get(ctx context.Context, id string) (*models.Job, error) {
+	var job models.Job
+	err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job: %w", err)
+	}
+	return &job, nil
+}
+
+// Save writes every field of an existing job
+func (r *GormJobRepository) Save(ctx context.Context, job *models.Job) error {
+	if err := r.db.WithContext(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
+
+// ListUnfinished returns the queued and running jobs, oldest first
+func (r *GormJobRepository) ListUnfinished(ctx context.Context) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []models.JobStatus{models.JobStatusQueued, models.JobStatusRunning}).
+		Order("created_at").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unfinished jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// GormExerciseCatalogRepository stores the exercise library in Postgres
+type GormExerciseCatalogRepository struct {
+	db *gorm.DB
+}
+
+// NewGormExerciseCatalogRepository creates an exercise catalog repository backed by GORM
+func NewGormExerciseCatalogRepository(db *gorm.DB) *GormExerciseCatalogRepository {
+	return &GormExerciseCatalogRepository{db: db}
+}
+
+// List returns every exercise ordered by name
+func (r *GormExerciseCatalogRepository) List(ctx context.Context) ([]models.CatalogExercise, error) {
+	var exercises []models.CatalogExercise
+	if err := r.db.WithContext(ctx).Order("name, id").Find(&exercises).Error; err != nil {
+		return nil, fmt.Errorf("failed to list exercises: %w", err)
+	}
+	return exercises, nil
+}
+
+// Get returns an exercise by ID
+func (r *GormExerciseCatalogRepository) Get(ctx context.Context, id uint) (*models.CatalogExercise, error) {
+	var exercise models.CatalogExercise
+	err := r.db.WithContext(ctx).First(&exercise, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exercise: %w", err)
+	}
+	return &exercise, nil
+}
+
+// Search returns the exercises matching filter ordered by name. The muscle and
+// equipment filters use the GIN indexes on the jsonb columns.
+func (r *GormExerciseCatalogRepository) Search(ctx context.Context, filter ExerciseCatalogFilter) ([]models.CatalogExercise, error) {
+	query := r.db.WithContext(ctx).Model(&models.CatalogExercise{})
+	if filter.Query != "" {
+		like := "%" + escapeLike(strings.ToLower(filter.Query)) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(aliases::text) LIKE ?", like, like)
+	}
+	if filter.Muscle != "" {
+		muscle := jsonArray([]string{filter.Muscle})
+		query = query.Where("primary_muscles @> ?::jsonb OR secondary_muscles @> ?::jsonb", muscle, muscle)
+	}
+	if filter.Pattern != "" {
+		query = query.Where("movement_pattern = ?", filter.Pattern)
+	}
+	if filter.Difficulty != "" {
+		query = query.Where("difficulty = ?", filter.Difficulty)
+	}
+	if filter.Equipment != nil {
+		query = query.Where("equipment <@ ?::jsonb", jsonArray(filter.Equipment))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var exercises []models.CatalogExercise
+	if err := query.Order("name, id").Find(&exercises).Error; err != nil {
+		return nil, fmt.Errorf("failed to search exercises: %w", err)
+	}
+	return exercises, nil
+}
+
+// Seed upserts the exercises by slug in one statement
+func (r *GormExerciseCatalogRepository) Seed(ctx context.Context, exercises []models.CatalogExercise) error {
+	if len(exercises) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "aliases", "primary_muscles", "secondary_muscles", "movement_pattern",
+			"equipment", "difficulty", "type", "updated_at",
+		}),
+	}).Create(&exercises).Error
+	if err != nil {
+		return fmt.Errorf("failed to seed exercise catalog: %w", err)
+	}
+	return nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// jsonArray encodes values as a JSON array for comparison with a jsonb column
+func jsonArray(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
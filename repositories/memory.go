@@ -0,0 +1,876 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fit-ai-api/models"
+)
+
+// MemoryUserRepository keeps users in memory. It is safe for concurrent use.
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]models.User
+	nextID uint
+}
+
+// NewMemoryUserRepository creates an empty in-memory user repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[uint]models.User)}
+}
+
+// List returns all users ordered by ID
+func (r *MemoryUserRepository) List(ctx context.Context) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]models.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Get returns a user by ID
+func (r *MemoryUserRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+// GetByFirebaseUID returns the user linked to a Firebase account
+func (r *MemoryUserRepository) GetByFirebaseUID(ctx context.Context, uid string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.FirebaseUID != nil && *user.FirebaseUID == uid {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetByEmail returns the user who signs in with email
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email != nil && *user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Create stores a new user
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.uidTaken(user) {
+		return ErrConflict
+	}
+	r.nextID++
+	now := time.Now()
+	user.ID = r.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.users[user.ID] = *user
+	return nil
+}
+
+// Update saves an existing user
+func (r *MemoryUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if r.uidTaken(user) {
+		return ErrConflict
+	}
+	user.CreatedAt = existing.CreatedAt
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = *user
+	return nil
+}
+
+// Delete removes a user
+func (r *MemoryUserRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// uidTaken reports whether another user already has user's Firebase UID or email
+func (r *MemoryUserRepository) uidTaken(user *models.User) bool {
+	for id, other := range r.users {
+		if id == user.ID {
+			continue
+		}
+		if sameValue(user.FirebaseUID, other.FirebaseUID) || sameValue(user.Email, other.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue reports whether two optional unique values are set and equal
+func sameValue(a, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+// MemoryWorkoutPlanRepository keeps workout plans in memory. It is safe for concurrent use.
+type MemoryWorkoutPlanRepository struct {
+	mu    sync.RWMutex
+	plans map[uint]*models.WorkoutPlan
+	ids   idSequence
+}
+
+// NewMemoryWorkoutPlanRepository creates an empty in-memory plan repository
+func NewMemoryWorkoutPlanRepository() *MemoryWorkoutPlanRepository {
+	return &MemoryWorkoutPlanRepository{plans: make(map[uint]*models.WorkoutPlan)}
+}
+
+// Create stores a new plan for the user
+func (r *MemoryWorkoutPlanRepository) Create(ctx context.Context, userID string, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	stored, err := copyPlan(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ids.Plan++
+	stored.ID = int(r.ids.Plan)
+	stored.UserID = userID
+	stored.CreatedAt = time.Now().UTC()
+	if stored.PlanStartDate.IsZero() {
+		stored.PlanStartDate = stored.CreatedAt
+	}
+	r.ids.number(stored)
+	r.plans[uint(r.ids.Plan)] = stored
+
+	return copyPlan(stored)
+}
+
+// Get returns a plan by ID
+func (r *MemoryWorkoutPlanRepository) Get(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyPlan(plan)
+}
+
+// ListByUser returns the user's plans, newest first
+func (r *MemoryWorkoutPlanRepository) ListByUser(ctx context.Context, userID string) ([]models.WorkoutPlan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plans := []models.WorkoutPlan{}
+	for _, plan := range r.plans {
+		if plan.UserID != userID {
+			continue
+		}
+		stored, err := copyPlan(plan)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *stored)
+	}
+	sortPlansNewestFirst(plans)
+	return plans, nil
+}
+
+// Update saves a user's edit of a plan, keeping the IDs of matched sessions and exercises
+func (r *MemoryWorkoutPlanRepository) Update(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	edited, err := copyPlan(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	r.ids.applyEdits(existing, edited)
+	return copyPlan(existing)
+}
+
+// Replace replaces the contents of a plan, giving its sessions and exercises new IDs
+func (r *MemoryWorkoutPlanRepository) Replace(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	updated, err := copyPlan(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	updated.ID = existing.ID
+	updated.UserID = existing.UserID
+	updated.CreatedAt = existing.CreatedAt
+	if updated.PlanStartDate.IsZero() {
+		updated.PlanStartDate = existing.PlanStartDate
+	}
+	r.ids.number(updated)
+	r.plans[id] = updated
+
+	return copyPlan(updated)
+}
+
+// IncrementSessionsCompleted adds one to the plan's completed session count
+func (r *MemoryWorkoutPlanRepository) IncrementSessionsCompleted(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	plan.SessionsCompleted++
+	return copyPlan(plan)
+}
+
+// UpdateExerciseTargets updates the prescription of individual exercises in place
+func (r *MemoryWorkoutPlanRepository) UpdateExerciseTargets(ctx context.Context, id uint, targets []models.Exercise) (*models.WorkoutPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	applyExerciseTargets(plan, targets)
+	return copyPlan(plan)
+}
+
+// ReplaceExercise swaps one exercise of the plan for another in place
+func (r *MemoryWorkoutPlanRepository) ReplaceExercise(ctx context.Context, id uint, exercise models.Exercise) (*models.WorkoutPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	applyExerciseReplacement(plan, exercise)
+	return copyPlan(plan)
+}
+
+// SetSuggestion stores or clears the plan's suggested plan
+func (r *MemoryWorkoutPlanRepository) SetSuggestion(ctx context.Context, id uint, suggestion *models.SuggestedPlan) (*models.WorkoutPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	plan.SuggestedPlan = suggestion
+	plan.HasNewPlanSuggestion = suggestion != nil
+	// Copy through the plan so the stored suggestion is not shared with the caller
+	stored, err := copyPlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	r.plans[id] = stored
+	return copyPlan(stored)
+}
+
+// Delete removes a plan
+func (r *MemoryWorkoutPlanRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.plans[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.plans, id)
+	return nil
+}
+
+// MemoryProfileRepository serves profiles from memory. Use Put to seed it.
+type MemoryProfileRepository struct {
+	mu       sync.RWMutex
+	profiles map[string]models.FirestoreUser
+}
+
+// NewMemoryProfileRepository creates an empty in-memory profile repository
+func NewMemoryProfileRepository() *MemoryProfileRepository {
+	return &MemoryProfileRepository{profiles: make(map[string]models.FirestoreUser)}
+}
+
+// Put stores or replaces the profile for a user
+func (r *MemoryProfileRepository) Put(userID string, profile models.FirestoreUser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles[userID] = profile
+}
+
+// Get returns the profile of a user
+func (r *MemoryProfileRepository) Get(ctx context.Context, userID string) (models.UserData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userData := models.UserData{
+		Collection: profileCollection,
+		DocumentID: userID,
+	}
+	profile, ok := r.profiles[userID]
+	if !ok {
+		return userData, ErrNotFound
+	}
+	userData.Data = profile
+	userData.Success = true
+	return userData, nil
+}
+
+// UpdateStats replaces the statistics of a stored profile
+func (r *MemoryProfileRepository) UpdateStats(ctx context.Context, userID string, stats models.UserStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	profile, ok := r.profiles[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	profile.Stats = stats
+	r.profiles[userID] = profile
+	return nil
+}
+
+// MemoryWorkoutLogRepository keeps performed workouts in memory. It is safe for
+// concurrent use.
+type MemoryWorkoutLogRepository struct {
+	mu        sync.RWMutex
+	logs      map[uint]*models.WorkoutLog
+	nextID    uint
+	nextSetID uint
+}
+
+// NewMemoryWorkoutLogRepository creates an empty in-memory workout log repository
+func NewMemoryWorkoutLogRepository() *MemoryWorkoutLogRepository {
+	return &MemoryWorkoutLogRepository{logs: make(map[uint]*models.WorkoutLog)}
+}
+
+// Create stores a new workout
+func (r *MemoryWorkoutLogRepository) Create(ctx context.Context, log *models.WorkoutLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	log.ID = r.nextID
+	log.CreatedAt = now
+	log.UpdatedAt = now
+	stored := *log
+	stored.Sets = append([]models.SetLog(nil), log.Sets...)
+	r.logs[log.ID] = &stored
+	return nil
+}
+
+// Get returns a workout with its sets
+func (r *MemoryWorkoutLogRepository) Get(ctx context.Context, id uint) (*models.WorkoutLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	log, ok := r.logs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyLog(log), nil
+}
+
+// ListByUser returns the user's workouts, most recently started first
+func (r *MemoryWorkoutLogRepository) ListByUser(ctx context.Context, userID string) ([]models.WorkoutLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	logs := []models.WorkoutLog{}
+	for _, log := range r.logs {
+		if log.UserID == userID {
+			logs = append(logs, *copyLog(log))
+		}
+	}
+	sortLogsNewestFirst(logs)
+	return logs, nil
+}
+
+// AddSet appends a set to a workout
+func (r *MemoryWorkoutLogRepository) AddSet(ctx context.Context, logID uint, set *models.SetLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log, ok := r.logs[logID]
+	if !ok {
+		return ErrNotFound
+	}
+	r.nextSetID++
+	set.ID = r.nextSetID
+	set.WorkoutLogID = logID
+	set.CreatedAt = time.Now()
+	log.Sets = append(log.Sets, *set)
+	return nil
+}
+
+// Complete saves the completion fields of a workout that is still in progress
+func (r *MemoryWorkoutLogRepository) Complete(ctx context.Context, log *models.WorkoutLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.logs[log.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Status != models.WorkoutLogInProgress {
+		return ErrConflict
+	}
+	stored.Status = log.Status
+	stored.CompletedAt = log.CompletedAt
+	stored.DurationSeconds = log.DurationSeconds
+	stored.TotalVolume = log.TotalVolume
+	stored.Notes = log.Notes
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+// copyLog copies a workout and its sets
+func copyLog(log *models.WorkoutLog) *models.WorkoutLog {
+	copied := *log
+	copied.Sets = append([]models.SetLog(nil), log.Sets...)
+	return &copied
+}
+
+// sortLogsNewestFirst orders workouts by start time, newest first, breaking ties by ID
+func sortLogsNewestFirst(logs []models.WorkoutLog) {
+	sort.Slice(logs, func(i, j int) bool {
+		if !logs[i].StartedAt.Equal(logs[j].StartedAt) {
+			return logs[i].StartedAt.After(logs[j].StartedAt)
+		}
+		return logs[i].ID > logs[j].ID
+	})
+}
+
+// idSequence hands out plan, session and exercise IDs
+type idSequence struct {
+	Plan     int64 `firestore:"plan"`
+	Session  int64 `firestore:"session"`
+	Exercise int64 `firestore:"exercise"`
+}
+
+// number assigns fresh IDs to every session and exercise of plan
+func (s *idSequence) number(plan *models.WorkoutPlan) {
+	for i := range plan.Sessions {
+		s.Session++
+		plan.Sessions[i].ID = strconv.FormatInt(s.Session, 10)
+		for j := range plan.Sessions[i].Exercises {
+			s.Exercise++
+			plan.Sessions[i].Exercises[j].ID = int(s.Exercise)
+		}
+	}
+}
+
+// applyEdits copies the user-editable fields of edited onto plan: the name, description,
+// review settings and sessions. Sessions and exercises keep their IDs when edited uses an
+// ID that belongs to plan (exercises may move between sessions); the others are given new
+// ones. Progress, the start date and the suggested plan are left alone.
+func (s *idSequence) applyEdits(plan, edited *models.WorkoutPlan) {
+	sessionIDs := make(map[string]bool)
+	exerciseIDs := make(map[int]bool)
+	for _, session := range plan.Sessions {
+		sessionIDs[session.ID] = true
+		for _, exercise := range session.Exercises {
+			exerciseIDs[exercise.ID] = true
+		}
+	}
+
+	for i := range edited.Sessions {
+		session := &edited.Sessions[i]
+		if !sessionIDs[session.ID] {
+			s.Session++
+			session.ID = strconv.FormatInt(s.Session, 10)
+		}
+		// An ID is only kept once, so duplicates in the edit get new ones
+		delete(sessionIDs, session.ID)
+		for j := range session.Exercises {
+			exercise := &session.Exercises[j]
+			if !exerciseIDs[exercise.ID] {
+				s.Exercise++
+				exercise.ID = int(s.Exercise)
+			}
+			delete(exerciseIDs, exercise.ID)
+		}
+	}
+
+	plan.Name = edited.Name
+	plan.Description = edited.Description
+	plan.AIFeedbackCycle = edited.AIFeedbackCycle
+	plan.PlanValidityPeriod = edited.PlanValidityPeriod
+	plan.Sessions = edited.Sessions
+}
+
+// applyExerciseTargets copies Sets, Reps and Weight from targets onto the plan's
+// exercises with the same IDs
+func applyExerciseTargets(plan *models.WorkoutPlan, targets []models.Exercise) {
+	byID := make(map[int]models.Exercise, len(targets))
+	for _, target := range targets {
+		byID[target.ID] = target
+	}
+	for i := range plan.Sessions {
+		for j := range plan.Sessions[i].Exercises {
+			exercise := &plan.Sessions[i].Exercises[j]
+			if target, ok := byID[exercise.ID]; ok {
+				exercise.Sets = target.Sets
+				exercise.Reps = target.Reps
+				exercise.Weight = target.Weight
+			}
+		}
+	}
+}
+
+// applyExerciseReplacement gives the exercise of plan with the ID of replacement its
+// name, type, weight and catalog ID
+func applyExerciseReplacement(plan *models.WorkoutPlan, replacement models.Exercise) {
+	for i := range plan.Sessions {
+		for j := range plan.Sessions[i].Exercises {
+			exercise := &plan.Sessions[i].Exercises[j]
+			if exercise.ID != replacement.ID {
+				continue
+			}
+			exercise.Name = replacement.Name
+			exercise.Type = replacement.Type
+			exercise.Weight = replacement.Weight
+			if replacement.CatalogID != nil {
+				id := *replacement.CatalogID
+				exercise.CatalogID = &id
+			} else {
+				exercise.CatalogID = nil
+			}
+		}
+	}
+}
+
+// copyPlan deep-copies a plan so callers can't modify stored data
+func copyPlan(plan *models.WorkoutPlan) (*models.WorkoutPlan, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	var copied models.WorkoutPlan
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// sortPlansNewestFirst orders plans by creation time, newest first, breaking ties by ID
+func sortPlansNewestFirst(plans []models.WorkoutPlan) {
+	sort.Slice(plans, func(i, j int) bool {
+		if !plans[i].CreatedAt.Equal(plans[j].CreatedAt) {
+			return plans[i].CreatedAt.After(plans[j].CreatedAt)
+		}
+		return plans[i].ID > plans[j].ID
+	})
+}
+
+// MemoryRefreshTokenRepository keeps refresh tokens in memory. It is safe for concurrent use.
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uint]models.RefreshToken
+	nextID uint
+}
+
+// NewMemoryRefreshTokenRepository creates an empty in-memory refresh token repository
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{tokens: make(map[uint]models.RefreshToken)}
+}
+
+// Create stores a new refresh token
+func (r *MemoryRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.tokens {
+		if other.TokenHash == token.TokenHash {
+			return ErrConflict
+		}
+	}
+	r.nextID++
+	token.ID = r.nextID
+	token.CreatedAt = time.Now()
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+// GetByHash returns the refresh token with the given hash
+func (r *MemoryRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Revoke marks a token revoked unless it already is
+func (r *MemoryRefreshTokenRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if token.RevokedAt != nil {
+		return ErrConflict
+	}
+	token.RevokedAt = &at
+	r.tokens[id] = token
+	return nil
+}
+
+// RevokeFamily revokes the unrevoked tokens of a family
+func (r *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	r.revokeWhere(func(token models.RefreshToken) bool { return token.FamilyID == familyID }, at)
+	return nil
+}
+
+// RevokeUser revokes the unrevoked tokens of a user
+func (r *MemoryRefreshTokenRepository) RevokeUser(ctx context.Context, userID uint, at time.Time) error {
+	r.revokeWhere(func(token models.RefreshToken) bool { return token.UserID == userID }, at)
+	return nil
+}
+
+// revokeWhere revokes the unrevoked tokens that match
+func (r *MemoryRefreshTokenRepository) revokeWhere(match func(models.RefreshToken) bool, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &at
+			r.tokens[id] = token
+		}
+	}
+}
+
+// MemoryJobRepository keeps background jobs in memory. It is safe for concurrent use.
+type MemoryJobRepository struct {
+	mu   sync.Mutex
+	jobs map[string]models.Job
+}
+
+// NewMemoryJobRepository creates an empty in-memory job repository
+func NewMemoryJobRepository() *MemoryJobRepository {
+	return &MemoryJobRepository{jobs: make(map[string]models.Job)}
+}
+
+// Create stores a new job
+func (r *MemoryJobRepository) Create(ctx context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[job.ID]; ok {
+		return ErrConflict
+	}
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+// Get returns a job by ID
+func (r *MemoryJobRepository) Get(ctx context.Context, id string) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
+
+// Save writes every field of an existing job
+func (r *MemoryJobRepository) Save(ctx context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[job.ID]; !ok {
+		return ErrNotFound
+	}
+	job.UpdatedAt = time.Now()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+// ListUnfinished returns the queued and running jobs, oldest first
+func (r *MemoryJobRepository) ListUnfinished(ctx context.Context) ([]models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var jobs []models.Job
+	for _, job := range r.jobs {
+		if job.Status == models.JobStatusQueued || job.Status == models.JobStatusRunning {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs, nil
+}
+
+// MemoryExerciseCatalogRepository keeps the exercise library in memory. It is safe for
+// concurrent use.
+type MemoryExerciseCatalogRepository struct {
+	mu        sync.RWMutex
+	exercises map[uint]models.CatalogExercise
+	nextID    uint
+}
+
+// NewMemoryExerciseCatalogRepository creates an empty in-memory exercise catalog
+func NewMemoryExerciseCatalogRepository() *MemoryExerciseCatalogRepository {
+	return &MemoryExerciseCatalogRepository{exercises: make(map[uint]models.CatalogExercise)}
+}
+
+// List returns every exercise ordered by name
+func (r *MemoryExerciseCatalogRepository) List(ctx context.Context) ([]models.CatalogExercise, error) {
+	return r.Search(ctx, ExerciseCatalogFilter{})
+}
+
+// Get returns an exercise by ID
+func (r *MemoryExerciseCatalogRepository) Get(ctx context.Context, id uint) (*models.CatalogExercise, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exercise, ok := r.exercises[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &exercise, nil
+}
+
+// Search returns the exercises matching filter ordered by name
+func (r *MemoryExerciseCatalogRepository) Search(ctx context.Context, filter ExerciseCatalogFilter) ([]models.CatalogExercise, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exercises := make([]models.CatalogExercise, 0, len(r.exercises))
+	for _, exercise := range r.exercises {
+		if catalogFilterMatches(filter, &exercise) {
+			exercises = append(exercises, exercise)
+		}
+	}
+	sort.Slice(exercises, func(i, j int) bool {
+		if exercises[i].Name != exercises[j].Name {
+			return exercises[i].Name < exercises[j].Name
+		}
+		return exercises[i].ID < exercises[j].ID
+	})
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(exercises) {
+			return []models.CatalogExercise{}, nil
+		}
+		exercises = exercises[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(exercises) {
+		exercises = exercises[:filter.Limit]
+	}
+	return exercises, nil
+}
+
+// Seed inserts the exercises, replacing those with the same slug
+func (r *MemoryExerciseCatalogRepository) Seed(ctx context.Context, exercises []models.CatalogExercise) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bySlug := make(map[string]uint, len(r.exercises))
+	for id, exercise := range r.exercises {
+		bySlug[exercise.Slug] = id
+	}
+	now := time.Now()
+	for i := range exercises {
+		exercise := &exercises[i]
+		if id, ok := bySlug[exercise.Slug]; ok {
+			exercise.ID = id
+			exercise.CreatedAt = r.exercises[id].CreatedAt
+		} else {
+			r.nextID++
+			exercise.ID = r.nextID
+			exercise.CreatedAt = now
+			bySlug[exercise.Slug] = exercise.ID
+		}
+		exercise.UpdatedAt = now
+		r.exercises[exercise.ID] = *exercise
+	}
+	return nil
+}
+
+// catalogFilterMatches applies a catalog filter the way the Postgres query does
+func catalogFilterMatches(filter ExerciseCatalogFilter, exercise *models.CatalogExercise) bool {
+	if filter.Query != "" {
+		query := strings.ToLower(filter.Query)
+		found := strings.Contains(strings.ToLower(exercise.Name), query)
+		for _, alias := range exercise.Aliases {
+			found = found || strings.Contains(strings.ToLower(alias), query)
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.Muscle != "" && !exercise.WorksMuscle(filter.Muscle) {
+		return false
+	}
+	if filter.Pattern != "" && exercise.MovementPattern != filter.Pattern {
+		return false
+	}
+	if filter.Difficulty != "" && exercise.Difficulty != filter.Difficulty {
+		return false
+	}
+	if filter.Equipment != nil && !exercise.RequiresOnly(filter.Equipment) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,394 @@
+// Package repositories abstracts where users, workout plans, workout logs, profiles,
+// refresh tokens, the exercise catalog and background jobs are stored. Each repository has
+// a Postgres (GORM), Firestore and in-memory implementation, except refresh tokens, which
+// are kept in Postgres or memory with the users they belong to, and the catalog and jobs,
+// which are Postgres or memory only; the in-memory ones make handlers testable without
+// live infrastructure.
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"gorm.io/gorm"
+
+	"fit-ai-api/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write would break a uniqueness rule, such as two
+	// users sharing a Firebase UID or email
+	ErrConflict = errors.New("record already exists")
+)
+
+// UserRepository stores API users
+type UserRepository interface {
+	List(ctx context.Context) ([]models.User, error)
+	Get(ctx context.Context, id uint) (*models.User, error)
+	GetByFirebaseUID(ctx context.Context, uid string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// Create stores a new user and sets its ID and timestamps
+	Create(ctx context.Context, user *models.User) error
+	// Update saves the user's fields; it returns ErrNotFound if the user doesn't exist
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// WorkoutPlanRepository stores workout plans owned by a user (a Firebase UID).
+// Implementations assign plan, session and exercise IDs; IDs sent by callers are ignored,
+// except by Update, which uses them to match the plan's existing sessions and exercises.
+type WorkoutPlanRepository interface {
+	Create(ctx context.Context, userID string, plan *models.WorkoutPlan) (*models.WorkoutPlan, error)
+	Get(ctx context.Context, id uint) (*models.WorkoutPlan, error)
+	// ListByUser returns the user's plans, newest first
+	ListByUser(ctx context.Context, userID string) ([]models.WorkoutPlan, error)
+	// Update saves a user's edit of a plan: its name, description, review settings and
+	// sessions. Sessions and exercises whose IDs belong to the plan are updated in place,
+	// so workout logs keep referring to them; others are added with new IDs, and those
+	// left out are deleted. Progress, the start date and the suggested plan are kept.
+	Update(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error)
+	// Replace replaces every field of a plan, including its progress and suggested plan,
+	// giving all sessions and exercises new IDs. The owner and creation time are kept.
+	Replace(ctx context.Context, id uint, plan *models.WorkoutPlan) (*models.WorkoutPlan, error)
+	// IncrementSessionsCompleted adds one to SessionsCompleted without touching the
+	// sessions, so session and exercise IDs stay stable
+	IncrementSessionsCompleted(ctx context.Context, id uint) (*models.WorkoutPlan, error)
+	// UpdateExerciseTargets sets the Sets, Reps and Weight of the plan's exercises with
+	// the same IDs as targets; other exercises and all IDs are left unchanged
+	UpdateExerciseTargets(ctx context.Context, id uint, targets []models.Exercise) (*models.WorkoutPlan, error)
+	// ReplaceExercise sets the Name, Type, Weight and CatalogID of the plan's exercise
+	// with the ID of exercise; its sets, reps and all IDs are left unchanged
+	ReplaceExercise(ctx context.Context, id uint, exercise models.Exercise) (*models.WorkoutPlan, error)
+	// SetSuggestion stores the plan's suggested plan and sets HasNewPlanSuggestion, or
+	// clears both when suggestion is nil, without touching the sessions
+	SetSuggestion(ctx context.Context, id uint, suggestion *models.SuggestedPlan) (*models.WorkoutPlan, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// WorkoutLogRepository stores performed workouts and their sets
+type WorkoutLogRepository interface {
+	// Create stores a new workout and sets its ID
+	Create(ctx context.Context, log *models.WorkoutLog) error
+	// Get returns a workout with its sets in the order they were logged
+	Get(ctx context.Context, id uint) (*models.WorkoutLog, error)
+	// ListByUser returns the user's workouts with their sets, most recently started first
+	ListByUser(ctx context.Context, userID string) ([]models.WorkoutLog, error)
+	// AddSet appends a set to a workout and sets its ID
+	AddSet(ctx context.Context, logID uint, set *models.SetLog) error
+	// Complete saves the completion fields of a workout. It returns ErrConflict if the
+	// workout was already completed.
+	Complete(ctx context.Context, log *models.WorkoutLog) error
+}
+
+// ProfileRepository reads the fitness profiles used to personalize generated plans
+type ProfileRepository interface {
+	Get(ctx context.Context, userID string) (models.UserData, error)
+	// UpdateStats replaces the training statistics of the user's profile
+	UpdateStats(ctx context.Context, userID string, stats models.UserStats) error
+}
+
+// RefreshTokenRepository stores refresh tokens by the hash of the token
+type RefreshTokenRepository interface {
+	// Create stores a new token and sets its ID
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// Revoke marks a token revoked. It returns ErrConflict if the token already was, so
+	// two requests can't both rotate the same token.
+	Revoke(ctx context.Context, id uint, at time.Time) error
+	// RevokeFamily revokes every token descended from the same login
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeUser revokes all of the user's tokens
+	RevokeUser(ctx context.Context, userID uint, at time.Time) error
+}
+
+// ExerciseCatalogFilter narrows a catalog search. Empty fields match every exercise.
+type ExerciseCatalogFilter struct {
+	// Query matches a substring of the name or an alias, ignoring case
+	Query string
+	// Muscle matches exercises working the muscle as a primary or secondary muscle
+	Muscle     string
+	Pattern    string
+	Difficulty string
+	// Equipment lists the equipment available; when it is non-nil only exercises that
+	// need nothing else match, so an empty list leaves the bodyweight exercises
+	Equipment []string
+	Limit     int
+	Offset    int
+}
+
+// ExerciseCatalogRepository stores the exercise library
+type ExerciseCatalogRepository interface {
+	// List returns every exercise ordered by name
+	List(ctx context.Context) ([]models.CatalogExercise, error)
+	Get(ctx context.Context, id uint) (*models.CatalogExercise, error)
+	// Search returns the exercises matching filter ordered by name
+	Search(ctx context.Context, filter ExerciseCatalogFilter) ([]models.CatalogExercise, error)
+	// Seed inserts the exercises, updating those whose slug is already stored, and sets
+	// their IDs
+	Seed(ctx context.Context, exercises []models.CatalogExercise) error
+}
+
+// JobRepository stores background jobs
+type JobRepository interface {
+	// Create stores a new job; its ID is set by the caller
+	Create(ctx context.Context, job *models.Job) error
+	Get(ctx context.Context, id string) (*models.Job, error)
+	// Save writes every field of an existing job
+	Save(ctx context.Context, job *models.Job) error
+	// ListUnfinished returns the queued and running jobs, oldest first
+	ListUnfinished(ctx context.Context) ([]models.Job, error)
+}
+
+// Backend names a storage implementation
+type Backend string
+
+const (
+	BackendPostgres  Backend = "postgres"
+	BackendFirestore Backend = "firestore"
+	BackendMemory    Backend = "memory"
+)
+
+// ParseBackend parses a backend name, returning def for an empty string
+func ParseBackend(value string, def Backend) (Backend, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return def, nil
+	}
+	switch backend := Backend(value); backend {
+	case BackendPostgres, BackendFirestore, BackendMemory:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("unknown storage backend %q (expected postgres, firestore or memory)", value)
+	}
+}
+
+// Config holds the connections the repositories can be built on. Fields for backends
+// that aren't selected may be left empty.
+type Config struct {
+	DB        *gorm.DB
+	Firestore *firestore.Client
+	// FirestoreTimeout bounds each Firestore operation; zero means no extra deadline
+	FirestoreTimeout time.Duration
+}
+
+// NewUserRepository builds the user repository for the given backend
+func NewUserRepository(backend Backend, cfg Config) (UserRepository, error) {
+	switch backend {
+	case BackendPostgres:
+		if cfg.DB == nil {
+			return nil, errors.New("postgres user repository requires a database connection")
+		}
+		return NewGormUserRepository(cfg.DB), nil
+	case BackendFirestore:
+		if cfg.Firestore == nil {
+			return nil, errors.New("firestore user repository requires a Firestore client")
+		}
+		return NewFirestoreUserRepository(cfg.Firestore, cfg.FirestoreTimeout), nil
+	case BackendMemory:
+		return NewMemoryUserRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// NewWorkoutPlanRepository builds the workout plan repository for the given backend
+func NewWorkoutPlanRepository(backend Backend, cfg Config) (WorkoutPlanRepository, error) {
+	switch backend {
+	case BackendPostgres:
+		if cfg.DB == nil {
+			return nil, errors.New("postgres plan repository requires a database connection")
+		}
+		return NewGormWorkoutPlanRepository(cfg.DB), nil
+	case BackendFirestore:
+		if cfg.Firestore == nil {
+			return nil, errors.New("firestore plan repository requires a Firestore client")
+		}
+		return NewFirestoreWorkoutPlanRepository(cfg.Firestore, cfg.FirestoreTimeout), nil
+	case BackendMemory:
+		return NewMemoryWorkoutPlanRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// NewWorkoutLogRepository builds the workout log repository for the given backend
+func NewWorkoutLogRepository(backend Backend, cfg Config) (WorkoutLogRepository, error) {
+	switch backend {
+	case BackendPostgres:
+		if cfg.DB == nil {
+			return nil, errors.New("postgres workout log repository requires a database connection")
+		}
+		return NewGormWorkoutLogRepository(cfg.DB), nil
+	case BackendFirestore:
+		if cfg.Firestore == nil {
+			return nil, errors.New("firestore workout log repository requires a Firestore client")
+		}
+		return NewFirestoreWorkoutLogRepository(cfg.Firestore, cfg.FirestoreTimeout), nil
+	case BackendMemory:
+		return NewMemoryWorkoutLogRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// NewRefreshTokenRepository builds the refresh token repository for the given backend
+func NewRefreshTokenRepository(backend Backend, cfg Config) (RefreshTokenRepository, error) {
+	switch backend {
+	case BackendPostgres:
+		if cfg.DB == nil {
+			return nil, errors.New("postgres refresh token repository requires a database connection")
+		}
+		return NewGormRefreshTokenRepository(cfg.DB), nil
+	case BackendMemory:
+		return NewMemoryRefreshTokenRepository(), nil
+	default:
+		return nil, fmt.Errorf("refresh tokens cannot be stored in %q", backend)
+	}
+}
+
+// NewExerciseCatalogRepository builds the exercise catalog repository for the given
+// backend. The in-memory catalog starts out with the seed library.
+func NewExerciseCatalogRepository(backend Backend, cfg Config) (ExerciseCatalogRepository, error) {
+	switch backend {
+	case BackendPostgres:
+		if cfg.DB == nil {
+			return nil, errors.New("postgres exercise catalog requires a database connection")
+		}
+		return NewGormExerciseCatalogRepository(cfg.DB), nil
+	case BackendMemory:
+		exercises, err := DefaultExerciseCatalog()
+		if err != nil {
+			return nil, err
+		}
+		repo := NewMemoryExerciseCatalogRepository()
+		if err := repo.Seed(context.Background(), exercises); err != nil {
+			return nil, err
+		}
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("the exercise catalog cannot be stored in %q", backend)
+	}
+}
+
+// NewProfileRepository builds the profile repository for the given backend. The Postgres
+// backend reads the profile of the API user linked to the Firebase UID; the Firestore
+// backend falls back to it when a database connection is configured.
+func NewProfileRepository(backend Backend, cfg Config) (ProfileRepository, error) {
+	switch backend {
+	case BackendPostgres:
+		if cfg.DB == nil {
+			return nil, errors.New("postgres profile repository requires a database connection")
+		}
+		return NewUserProfileRepository(NewGormUserRepository(cfg.DB)), nil
+	case BackendFirestore:
+		if cfg.Firestore == nil {
+			return nil, errors.New("firestore profile repository requires a Firestore client")
+		}
+		firestoreProfiles := NewFirestoreProfileRepository(cfg.Firestore, cfg.FirestoreTimeout)
+		if cfg.DB == nil {
+			return firestoreProfiles, nil
+		}
+		return NewFallbackProfileRepository(firestoreProfiles, NewUserProfileRepository(NewGormUserRepository(cfg.DB))), nil
+	case BackendMemory:
+		return NewMemoryProfileRepository(), nil
+	default:
+		return nil, fmt.Errorf("profiles cannot be stored in %q", backend)
+	}
+}
+
+// UserProfileRepository serves the profiles of API users, looked up by Firebase UID
+type UserProfileRepository struct {
+	users UserRepository
+}
+
+// NewUserProfileRepository creates a profile repository that reads from users
+func NewUserProfileRepository(users UserRepository) *UserProfileRepository {
+	return &UserProfileRepository{users: users}
+}
+
+// Get returns the profile of the user linked to the Firebase UID
+func (r *UserProfileRepository) Get(ctx context.Context, userID string) (models.UserData, error) {
+	user, err := r.users.GetByFirebaseUID(ctx, userID)
+	if err != nil {
+		return models.UserData{Collection: "users", DocumentID: userID}, err
+	}
+	return user.ProfileData(), nil
+}
+
+// UpdateStats saves the statistics on the user linked to the Firebase UID
+func (r *UserProfileRepository) UpdateStats(ctx context.Context, userID string, stats models.UserStats) error {
+	user, err := r.users.GetByFirebaseUID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user.Profile.Stats = stats
+	return r.users.Update(ctx, user)
+}
+
+// FallbackProfileRepository reads profiles from a primary repository and tries the
+// fallback when the primary fails, for example because Firestore is unreachable
+type FallbackProfileRepository struct {
+	primary  ProfileRepository
+	fallback ProfileRepository
+}
+
+// NewFallbackProfileRepository creates a profile repository with a fallback
+func NewFallbackProfileRepository(primary, fallback ProfileRepository) *FallbackProfileRepository {
+	return &FallbackProfileRepository{primary: primary, fallback: fallback}
+}
+
+// Get returns the profile from the primary repository, or from the fallback if that fails.
+// When both fail the primary's error is returned unless it was only ErrNotFound.
+func (r *FallbackProfileRepository) Get(ctx context.Context, userID string) (models.UserData, error) {
+	userData, err := r.primary.Get(ctx, userID)
+	if err == nil || ctx.Err() != nil {
+		return userData, err
+	}
+
+	fallbackData, fallbackErr := r.fallback.Get(ctx, userID)
+	if fallbackErr == nil {
+		return fallbackData, nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return fallbackData, fallbackErr
+	}
+	return userData, err
+}
+
+// UpdateStats writes to the primary repository, or to the fallback if that fails
+func (r *FallbackProfileRepository) UpdateStats(ctx context.Context, userID string, stats models.UserStats) error {
+	err := r.primary.UpdateStats(ctx, userID, stats)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	fallbackErr := r.fallback.UpdateStats(ctx, userID, stats)
+	if fallbackErr == nil {
+		return nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return fallbackErr
+	}
+	return err
+}
+
+// NewJobRepository builds the background job repository for the given backend
+func NewJobRepository(backend Backend, cfg Config) (JobRepository, error) {
+	switch backend {
+	case BackendPostgres:
+		if cfg.DB == nil {
+			return nil, errors.New("postgres job repository requires a database connection")
+		}
+		return NewGormJobRepository(cfg.DB), nil
+	case BackendMemory:
+		return NewMemoryJobRepository(), nil
+	default:
+		return nil, fmt.Errorf("jobs cannot be stored in %q", backend)
+	}
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"fit-ai-api/models"
+)
+
+// exerciseSeed is the exercise library loaded into the catalog on startup. Entries are
+// matched by slug, so editing an entry updates the stored exercise instead of adding one.
+//
+//go:embed seed/exercises.json
+var exerciseSeed []byte
+
+// DefaultExerciseCatalog returns the exercises of the seed library, without IDs
+func DefaultExerciseCatalog() ([]models.CatalogExercise, error) {
+	var exercises []models.CatalogExercise
+	if err := json.Unmarshal(exerciseSeed, &exercises); err != nil {
+		return nil, fmt.Errorf("invalid exercise seed data: %w", err)
+	}
+	return exercises, nil
+}
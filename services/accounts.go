@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+var (
+	// ErrInvalidSignup is returned for a malformed email or an unacceptable password
+	ErrInvalidSignup = errors.New("invalid signup")
+	// ErrEmailTaken is returned when signing up with an email that already has an account
+	ErrEmailTaken = errors.New("an account with this email already exists")
+	// ErrInvalidCredentials is returned when the email or password is wrong
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidRefreshToken is returned for unknown, expired, revoked or reused refresh
+	// tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
+const (
+	// minPasswordLength and maxPasswordLength bound passwords; bcrypt only reads 72 bytes
+	minPasswordLength = 8
+	maxPasswordLength = 72
+
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+	// uidAlphabet and uidLength shape generated UIDs like Firebase UIDs
+	uidAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	uidLength   = 28
+)
+
+// SignupInput is an email and password sign-up
+type SignupInput struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Age      int    `json:"age"`
+}
+
+// AuthResult is returned by sign-up, login and refresh. The access token goes in the
+// Authorization header; the refresh token is exchanged for a new pair before it expires.
+type AuthResult struct {
+	User             *models.User `json:"user"`
+	AccessToken      string       `json:"accessToken"`
+	TokenType        string       `json:"tokenType"`
+	ExpiresIn        int          `json:"expiresIn"`
+	RefreshToken     string       `json:"refreshToken"`
+	RefreshExpiresAt time.Time    `json:"refreshExpiresAt"`
+}
+
+// AccountService signs users up and in with an email and password and manages their
+// tokens. Access tokens are short-lived JWTs; refresh tokens are opaque, single use and
+// rotated on every refresh. Presenting a refresh token that was already rotated revokes
+// every token from the same login, since the token has likely been stolen.
+type AccountService struct {
+	users      repositories.UserRepository
+	tokens     repositories.RefreshTokenRepository
+	issuer     *auth.TokenIssuer
+	refreshTTL time.Duration
+	dummyHash  []byte
+	now        func() time.Time
+}
+
+// NewAccountService creates an account service issuing access tokens with issuer and
+// refresh tokens valid for refreshTTL
+func NewAccountService(users repositories.UserRepository, tokens repositories.RefreshTokenRepository, issuer *auth.TokenIssuer, refreshTTL time.Duration) (*AccountService, error) {
+	if refreshTTL <= 0 {
+		return nil, errors.New("refresh token lifetime must be positive")
+	}
+	// Logins for unknown emails compare against this hash so they take as long as real ones
+	dummyPassword, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte(dummyPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountService{
+		users:      users,
+		tokens:     tokens,
+		issuer:     issuer,
+		refreshTTL: refreshTTL,
+		dummyHash:  dummyHash,
+		now:        time.Now,
+	}, nil
+}
+
+// NewAccountServiceFromEnv creates an account service signing access tokens with
+// JWT_SECRET; JWT_ACCESS_TTL and JWT_REFRESH_TTL override the token lifetimes
+func NewAccountServiceFromEnv(users repositories.UserRepository, tokens repositories.RefreshTokenRepository) (*AccountService, error) {
+	issuer, err := auth.NewTokenIssuer([]byte(os.Getenv("JWT_SECRET")), envDuration("JWT_ACCESS_TTL", defaultAccessTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+	return NewAccountService(users, tokens, issuer, envDuration("JWT_REFRESH_TTL", defaultRefreshTokenTTL))
+}
+
+// Verifier returns the verifier for the access tokens this service issues
+func (s *AccountService) Verifier() auth.Verifier {
+	return s.issuer
+}
+
+// Signup creates a user who signs in with an email and password and signs them in. The
+// user gets a generated UID that owns their plans and workouts.
+func (s *AccountService) Signup(ctx context.Context, input SignupInput) (*AuthResult, error) {
+	email, err := normalizeEmail(input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Password) < minPasswordLength || len(input.Password) > maxPasswordLength {
+		return nil, fmt.Errorf("%w: password must be %d to %d characters", ErrInvalidSignup, minPasswordLength, maxPasswordLength)
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidSignup)
+	}
+	if input.Age < 0 {
+		return nil, fmt.Errorf("%w: age cannot be negative", ErrInvalidSignup)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	uid, err := generateUID()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Name:         name,
+		Age:          input.Age,
+		FirebaseUID:  &uid,
+		Email:        &email,
+		PasswordHash: string(hash),
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+	return s.signIn(ctx, user, "")
+}
+
+// Login signs a user in with their email and password
+func (s *AccountService) Login(ctx context.Context, email, password string) (*AuthResult, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, repositories.ErrNotFound) {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash == "" || user.FirebaseUID == nil {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return s.signIn(ctx, user, "")
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token. The old refresh
+// token can't be used again.
+func (s *AccountService) Refresh(ctx context.Context, refreshToken string) (*AuthResult, error) {
+	stored, err := s.tokens.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if stored.RevokedAt != nil {
+		// A rotated token was presented again: revoke the whole login
+		if err := s.tokens.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err := s.tokens.Revoke(ctx, stored.ID, now); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			// Another request rotated it first; treat it as reuse
+			if err := s.tokens.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+				return nil, err
+			}
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	user, err := s.users.Get(ctx, stored.UserID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.FirebaseUID == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.signIn(ctx, user, stored.FamilyID)
+}
+
+// Logout revokes the refresh token and every token rotated from the same login. Unknown
+// tokens are ignored so logging out twice succeeds.
+func (s *AccountService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.tokens.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeFamily(ctx, stored.FamilyID, s.now())
+}
+
+// RevokeAll revokes every refresh token of the user with the given UID, signing them out
+// everywhere once their access tokens expire
+func (s *AccountService) RevokeAll(ctx context.Context, uid string) error {
+	user, err := s.users.GetByFirebaseUID(ctx, uid)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeUser(ctx, user.ID, s.now())
+}
+
+// signIn issues an access token and a refresh token for user. The refresh token joins
+// familyID, or starts a new family when it is empty.
+func (s *AccountService) signIn(ctx context.Context, user *models.User, familyID string) (*AuthResult, error) {
+	accessToken, _, err := s.issuer.Issue(*user.FirebaseUID, user.Admin)
+	if err != nil {
+		return nil, err
+	}
+
+	if familyID == "" {
+		if familyID, err = randomToken(); err != nil {
+			return nil, err
+		}
+	}
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	stored := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: s.now().Add(s.refreshTTL),
+	}
+	if err := s.tokens.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		User:             user,
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.issuer.TTL().Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt.UTC(),
+	}, nil
+}
+
+// normalizeEmail lowercases and validates an email address
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("%w: invalid email address", ErrInvalidSignup)
+	}
+	return email, nil
+}
+
+// hashToken returns the hex SHA-256 of a refresh token, the form it is stored in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns 32 random bytes, base64url encoded
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// generateUID returns a random alphanumeric UID
+func generateUID() (string, error) {
+	uid := make([]byte, uidLength)
+	max := big.NewInt(int64(len(uidAlphabet)))
+	for i := range uid {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate UID: %w", err)
+		}
+		uid[i] = uidAlphabet[n.Int64()]
+	}
+	return string(uid), nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fit-ai-api/auth"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// newTestAccounts returns an account service on in-memory repositories whose clock reads
+// *now, issuing refresh tokens valid for a day
+func newTestAccounts(t *testing.T, tokens repositories.RefreshTokenRepository, now *time.Time) *AccountService {
+	t.Helper()
+	issuer, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := NewAccountService(repositories.NewMemoryUserRepository(), tokens, issuer, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts.now = func() time.Time { return *now }
+	return accounts
+}
+
+// signupResult signs a test user up
+func signupResult(t *testing.T, accounts *AccountService) *AuthResult {
+	t.Helper()
+	result, err := accounts.Signup(context.Background(), SignupInput{Email: "Ana@Example.com", Password: "correct horse", Name: "Ana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	accounts := newTestAccounts(t, repositories.NewMemoryRefreshTokenRepository(), &now)
+	first := signupResult(t, accounts)
+
+	now = now.Add(time.Hour)
+	second, err := accounts.Refresh(context.Background(), first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.User.ID != first.User.ID {
+		t.Fatalf("refresh = %+v, want a new token for the same user", second)
+	}
+	if !second.RefreshExpiresAt.Equal(now.Add(24 * time.Hour)) {
+		t.Errorf("refresh token expires at %v, want a day after the refresh", second.RefreshExpiresAt)
+	}
+	if _, err := accounts.Verifier().Verify(context.Background(), second.AccessToken); err != nil {
+		t.Errorf("new access token doesn't verify: %v", err)
+	}
+
+	// The new token keeps working until it expires
+	third, err := accounts.Refresh(context.Background(), second.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = third.RefreshExpiresAt
+	if _, err := accounts.Refresh(context.Background(), third.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expired token: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := accounts.Refresh(context.Background(), "unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	accounts := newTestAccounts(t, repositories.NewMemoryRefreshTokenRepository(), &now)
+	first := signupResult(t, accounts)
+	other, err := accounts.Login(context.Background(), "ana@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := accounts.Refresh(context.Background(), first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replaying the rotated token revokes the token it was rotated into
+	if _, err := accounts.Refresh(context.Background(), first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("replayed token: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := accounts.Refresh(context.Background(), second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("token rotated from the replayed one: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	// Other logins are unaffected
+	if _, err := accounts.Refresh(context.Background(), other.RefreshToken); err != nil {
+		t.Errorf("other login: %v", err)
+	}
+}
+
+// racingTokens lets another request rotate every token between its lookup and revocation
+type racingTokens struct {
+	*repositories.MemoryRefreshTokenRepository
+}
+
+func (r racingTokens) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	token, err := r.MemoryRefreshTokenRepository.GetByHash(ctx, hash)
+	if err == nil {
+		r.MemoryRefreshTokenRepository.Revoke(ctx, token.ID, time.Now())
+	}
+	return token, err
+}
+
+func TestRefreshRaceRevokesFamily(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	memory := repositories.NewMemoryRefreshTokenRepository()
+	first := signupResult(t, newTestAccounts(t, memory, &now))
+	// A later token in the same family, as if the racing request had been answered
+	stored, err := memory.GetByHash(context.Background(), hashToken(first.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sibling := &models.RefreshToken{UserID: stored.UserID, FamilyID: stored.FamilyID, TokenHash: hashToken("sibling"), ExpiresAt: stored.ExpiresAt}
+	if err := memory.Create(context.Background(), sibling); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := newTestAccounts(t, racingTokens{memory}, &now)
+	if _, err := accounts.Refresh(context.Background(), first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("losing the race: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if revoked, _ := memory.GetByHash(context.Background(), sibling.TokenHash); revoked.RevokedAt == nil {
+		t.Error("the family was not revoked")
+	}
+}
+
+func TestLogout(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	accounts := newTestAccounts(t, repositories.NewMemoryRefreshTokenRepository(), &now)
+	first := signupResult(t, accounts)
+	second, err := accounts.Refresh(context.Background(), first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := accounts.Logout(context.Background(), second.RefreshToken); err != nil {
+			t.Fatalf("logout %d: %v", i+1, err)
+		}
+	}
+	if err := accounts.Logout(context.Background(), "unknown"); err != nil {
+		t.Errorf("logout with an unknown token: %v", err)
+	}
+	if _, err := accounts.Refresh(context.Background(), second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after logout: error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
@@ -1,13 +1,14 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
@@ -20,20 +21,113 @@ type AIProvider string
 const (
 	OpenAI   AIProvider = "OPEN_AI"
 	DeepSeek AIProvider = "DEEPSEEK"
+	// Ollama covers any self-hosted OpenAI-compatible server (Ollama, llama.cpp, vLLM)
+	Ollama AIProvider = "OLLAMA"
 )
 
+// workoutPlanSchema is derived from models.WorkoutPlan so the prompt, the provider's
+// structured output constraint and the parser always agree on the format
+var (
+	workoutPlanSchema = &ResponseSchema{
+		Name:        "workout_plan",
+		Description: "A personalized weekly workout plan",
+		Schema:      JSONSchemaFor(reflect.TypeOf(models.WorkoutPlan{})),
+	}
+	workoutPlanSchemaJSON = mustMarshalIndent(workoutPlanSchema.Schema)
+)
+
+// mustMarshalIndent renders a value built from static types as indented JSON
+func mustMarshalIndent(v interface{}) string {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
 // AIService handles AI-powered workout plan generation
 type AIService struct {
-	openaiKey   string
-	deepseekKey string
-	selectedAI  AIProvider
-	client      *http.Client
+	selectedAI AIProvider
+	providers  []Provider
+	breakers   map[AIProvider]*circuitBreaker
+	retry      retryPolicy
+	// generationTimeout bounds a whole generation including retries and fallbacks,
+	// requestTimeout bounds a single provider call
+	generationTimeout time.Duration
+	requestTimeout    time.Duration
+	maxRepairAttempts int
+}
+
+// GenerationMetadata describes how a workout plan was produced
+type GenerationMetadata struct {
+	Provider  AIProvider        `json:"provider"`
+	Model     string            `json:"model"`
+	Usage     Usage             `json:"usage"`
+	Fallbacks []ProviderAttempt `json:"fallbacks,omitempty"`
+	// RepairAttempts counts how often the model was asked to fix an invalid reply
+	RepairAttempts int `json:"repairAttempts"`
+	// Catalog reports which exercises were resolved to the exercise catalog
+	Catalog *CatalogReport `json:"catalog,omitempty"`
+	// Equipment lists the exercises replaced because the user lacks their equipment
+	Equipment *EquipmentReport `json:"equipment,omitempty"`
+}
+
+// merge folds the metadata of one provider call into the running totals
+func (m *GenerationMetadata) merge(call GenerationMetadata) {
+	if call.Provider != "" {
+		m.Provider = call.Provider
+		m.Model = call.Model
+	}
+	m.Usage.PromptTokens += call.Usage.PromptTokens
+	m.Usage.CompletionTokens += call.Usage.CompletionTokens
+	m.Usage.TotalTokens += call.Usage.TotalTokens
+	m.Fallbacks = append(m.Fallbacks, call.Fallbacks...)
+}
+
+// ProviderAttempt records a provider that was skipped or failed before another one succeeded
+type ProviderAttempt struct {
+	Provider AIProvider `json:"provider"`
+	Error    string     `json:"error"`
 }
 
-// NewAIService creates a new AI service instance
+// Stream event types emitted by GenerateWorkoutPlanStream
+const (
+	StreamEventStatus  = "status"
+	StreamEventSession = "session"
+	StreamEventRepair  = "repair"
+)
+
+// StreamEvent is a progress update emitted while a plan is being generated
+type StreamEvent struct {
+	Type    string                 `json:"type"`
+	Message string                 `json:"message,omitempty"`
+	Index   int                    `json:"index,omitempty"` // 1-based number of a completed session
+	Session *models.WorkoutSession `json:"session,omitempty"`
+}
+
+// GenerationResult is a generated workout plan together with its metadata
+type GenerationResult struct {
+	Plan     *models.WorkoutPlan
+	Metadata GenerationMetadata
+}
+
+// NewAIService creates a new AI service instance.
+// AI_PROVIDERS lists providers in fallback order (e.g. "DEEPSEEK,OPEN_AI,OLLAMA");
+// when unset only SELECTED_AI is used.
 func NewAIService() *AIService {
-	openaiKey := os.Getenv("OPEN_AI_API_KEY")
-	deepseekKey := os.Getenv("DEEPSEEK_AI_API_KEY")
+	return newAIServiceFromEnv("")
+}
+
+// NewAIServiceWithBaseURL creates the AI service configured by the environment like
+// NewAIService, but sends the requests of every OpenAI-compatible provider to baseURL,
+// e.g. a local gateway or the llmstub test server
+func NewAIServiceWithBaseURL(baseURL string) *AIService {
+	return newAIServiceFromEnv(baseURL)
+}
+
+// newAIServiceFromEnv builds the provider chain from SELECTED_AI and AI_PROVIDERS,
+// overriding each provider's base URL when baseURL is set
+func newAIServiceFromEnv(baseURL string) *AIService {
 	selectedAI := AIProvider(strings.ToUpper(os.Getenv("SELECTED_AI")))
 
 	// Default to OpenAI if not specified
@@ -41,34 +135,206 @@ func NewAIService() *AIService {
 		selectedAI = OpenAI
 	}
 
-	return &AIService{
-		openaiKey:   openaiKey,
-		deepseekKey: deepseekKey,
-		selectedAI:  selectedAI,
-		client: &http.Client{
-			Timeout: 120 * time.Second, // Increased timeout for complex prompts
-		},
+	names := []AIProvider{selectedAI}
+	if chain := os.Getenv("AI_PROVIDERS"); chain != "" {
+		names = names[:0]
+		for _, name := range strings.Split(chain, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, AIProvider(strings.ToUpper(name)))
+			}
+		}
 	}
+
+	// Deadlines come from the request context and AI_REQUEST_TIMEOUT rather than a
+	// client-wide timeout, so a disconnecting caller cancels the call immediately
+	client := &http.Client{}
+
+	var providers []Provider
+	for _, name := range names {
+		provider, err := NewProvider(name, client)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		if baseURL != "" {
+			if compatible, ok := provider.(*OpenAICompatibleProvider); ok {
+				provider = compatible.WithBaseURL(baseURL)
+			} else {
+				log.Printf("Warning: AI provider %s does not support a custom base URL", name)
+			}
+		}
+		providers = append(providers, provider)
+	}
+
+	ai := NewAIServiceWithProviders(providers...)
+	if len(names) > 0 {
+		ai.selectedAI = names[0]
+	}
+	return ai
+}
+
+// NewAIServiceWithProvider creates an AI service backed by the given provider
+func NewAIServiceWithProvider(provider Provider) *AIService {
+	return NewAIServiceWithProviders(provider)
+}
+
+// NewAIServiceWithProviders creates an AI service that tries the given providers in order.
+// Each provider gets a circuit breaker configured by AI_CIRCUIT_FAILURE_THRESHOLD and
+// AI_CIRCUIT_COOLDOWN, and is retried on transient errors according to AI_MAX_RETRIES.
+// AI_REQUEST_TIMEOUT bounds each provider call and AI_GENERATION_TIMEOUT the whole generation.
+func NewAIServiceWithProviders(providers ...Provider) *AIService {
+	threshold := envInt("AI_CIRCUIT_FAILURE_THRESHOLD", 3)
+	cooldown := envDuration("AI_CIRCUIT_COOLDOWN", time.Minute)
+
+	ai := &AIService{
+		providers: providers,
+		breakers:  make(map[AIProvider]*circuitBreaker, len(providers)),
+		retry:     newRetryPolicyFromEnv(),
+
+		generationTimeout: envDuration("AI_GENERATION_TIMEOUT", 3*time.Minute),
+		requestTimeout:    envDuration("AI_REQUEST_TIMEOUT", 90*time.Second),
+		maxRepairAttempts: envInt("AI_MAX_REPAIR_ATTEMPTS", 2),
+	}
+	for _, provider := range providers {
+		ai.breakers[provider.Name()] = newCircuitBreaker(threshold, cooldown)
+	}
+	if len(providers) > 0 {
+		ai.selectedAI = providers[0].Name()
+	}
+	return ai
+}
+
+// GenerateWorkoutPlan generates a personalized workout plan based on user data.
+// When the reply cannot be parsed or breaks the domain rules, the model is re-prompted
+// with the specific problems up to AI_MAX_REPAIR_ATTEMPTS times.
+// Cancelling ctx aborts any in-flight provider call.
+func (ai *AIService) GenerateWorkoutPlan(ctx context.Context, userData models.UserData) (*GenerationResult, error) {
+	return ai.generateWorkoutPlan(ctx, userData, nil)
 }
 
-// GenerateWorkoutPlan generates a personalized workout plan based on user data
-func (ai *AIService) GenerateWorkoutPlan(userData models.UserData) (*models.WorkoutPlan, error) {
+// GenerateWorkoutPlanStream generates a plan like GenerateWorkoutPlan, streaming the
+// provider's reply and calling emit as each session is completed. emit is called from
+// the calling goroutine.
+func (ai *AIService) GenerateWorkoutPlanStream(ctx context.Context, userData models.UserData, emit func(StreamEvent)) (*GenerationResult, error) {
+	return ai.generateWorkoutPlan(ctx, userData, emit)
+}
+
+// generateWorkoutPlan runs the generation and repair loop; emit is nil when not streaming
+func (ai *AIService) generateWorkoutPlan(ctx context.Context, userData models.UserData, emit func(StreamEvent)) (*GenerationResult, error) {
+	if ai.generationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ai.generationTimeout)
+		defer cancel()
+	}
+
+	var onProgress func(text string)
+	parser := &sessionStreamParser{}
+	if emit != nil {
+		onProgress = func(text string) {
+			for _, streamed := range parser.Feed(text) {
+				emit(StreamEvent{
+					Type:    StreamEventSession,
+					Index:   streamed.Index,
+					Message: fmt.Sprintf("Session %d generated", streamed.Index),
+					Session: &streamed.Session,
+				})
+			}
+		}
+		emit(StreamEvent{Type: StreamEventStatus, Message: "Generating workout plan"})
+	}
+
 	// Create the prompt for the AI
-	prompt := ai.createWorkoutPrompt(userData)
+	messages := []ChatMessage{
+		{
+			Role:    "system",
+			Content: WorkoutPlanPrompt,
+		},
+		{
+			Role:    "user",
+			Content: ai.createWorkoutPrompt(userData),
+		},
+	}
 
-	// Call the AI API based on selected provider
-	response, err := ai.callAIAPI(prompt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call AI API: %w", err)
+	var onRepair func(attempt int)
+	if emit != nil {
+		onRepair = func(attempt int) {
+			parser.Reset()
+			emit(StreamEvent{
+				Type:    StreamEventRepair,
+				Message: fmt.Sprintf("Generated plan was invalid, requesting a corrected plan (attempt %d of %d)", attempt, ai.maxRepairAttempts),
+			})
+		}
 	}
 
-	// Parse the AI response into a workout plan
-	workoutPlan, err := ai.parseAIResponse(response)
+	var workoutPlan *models.WorkoutPlan
+	metadata, err := ai.generateWithRepair(ctx, messages, workoutPlanSchema, "workout plan", onProgress, onRepair,
+		func(content string) error {
+			// Parse the AI response and check it against the domain rules
+			plan, err := ai.parseAIResponse(content)
+			if err != nil {
+				return err
+			}
+			if violations := ValidateWorkoutPlan(plan); len(violations) > 0 {
+				return &PlanValidationError{Violations: violations}
+			}
+			workoutPlan = plan
+			return nil
+		})
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+		return nil, err
 	}
 
-	return workoutPlan, nil
+	return &GenerationResult{
+		Plan:     workoutPlan,
+		Metadata: metadata,
+	}, nil
+}
+
+// generateWithRepair sends the conversation to the providers and hands each reply to
+// accept. When accept fails with a *PlanValidationError the model is re-prompted with
+// the violations; any other error is treated as an unparseable reply. After
+// AI_MAX_REPAIR_ATTEMPTS repairs the last error is returned. subject names the requested
+// document in the repair prompt and onRepair, if set, is called with the 1-based attempt
+// before each repair.
+func (ai *AIService) generateWithRepair(ctx context.Context, messages []ChatMessage, schema *ResponseSchema, subject string, onProgress func(text string), onRepair func(attempt int), accept func(content string) error) (GenerationMetadata, error) {
+	var metadata GenerationMetadata
+	for attempt := 0; ; attempt++ {
+		// Call the AI API, falling back through the configured providers
+		response, callMetadata, err := ai.callAIAPI(ctx, messages, schema, onProgress)
+		metadata.merge(callMetadata)
+		if err != nil {
+			return metadata, fmt.Errorf("failed to call AI API: %w", err)
+		}
+
+		err = accept(response.Content)
+		var validationErr *PlanValidationError
+		if err != nil && !errors.As(err, &validationErr) {
+			err = &ProviderError{
+				Provider: callMetadata.Provider,
+				Kind:     ErrorKindMalformed,
+				Message:  "failed to parse AI response",
+				Err:      err,
+			}
+		}
+		if err == nil {
+			return metadata, nil
+		}
+
+		if attempt >= ai.maxRepairAttempts {
+			return metadata, err
+		}
+
+		// Ask the model to fix its own output
+		log.Printf("AI response from %s rejected, requesting repair (attempt %d of %d): %v", callMetadata.Provider, attempt+1, ai.maxRepairAttempts, err)
+		metadata.RepairAttempts++
+		if onRepair != nil {
+			onRepair(attempt + 1)
+		}
+		messages = append(messages,
+			ChatMessage{Role: "assistant", Content: response.Content},
+			ChatMessage{Role: "user", Content: createRepairPrompt(err, subject)},
+		)
+	}
 }
 
 // createWorkoutPrompt creates a detailed prompt for the AI based on user data
@@ -80,219 +346,138 @@ func (ai *AIService) createWorkoutPrompt(userData models.UserData) string {
 		user.DateOfBirth,
 		user.Gender,
 		user.FitnessLevel,
-		user.ActivityLevel,
-		user.Height.Value,
-		user.Height.Unit,
-		user.Weight.Value,
-		user.Weight.Unit,
 		user.Goals,
 		user.Equipment,
-		user.Location,
 		user.Preferences.Units,
 		user.Stats.TotalWorkouts,
 		user.Stats.CurrentStreak,
-		user.Stats.LongestStreak,
 		user.Stats.TotalTime,
 		user.Stats.TotalVolume,
 		user.Equipment,
-		user.Goals,
 		user.FitnessLevel,
-		user.Preferences.Units)
+		workoutPlanSchemaJSON)
 
 	return prompt
 }
 
-// callAIAPI makes a request to the selected AI API
-func (ai *AIService) callAIAPI(prompt string) (string, error) {
-	switch ai.selectedAI {
-	case OpenAI:
-		return ai.callOpenAI(prompt)
-	case DeepSeek:
-		return ai.callDeepSeek(prompt)
-	default:
-		return "", fmt.Errorf("unsupported AI provider: %s", ai.selectedAI)
-	}
+// callAIAPI sends the conversation to the configured providers, asking for output that
+// matches schema
+func (ai *AIService) callAIAPI(ctx context.Context, messages []ChatMessage, schema *ResponseSchema, onProgress func(text string)) (*ChatResponse, GenerationMetadata, error) {
+	return ai.complete(ctx, ChatRequest{
+		Messages:    messages,
+		Temperature: 0.7,
+		MaxTokens:   2000,
+		JSON:        true,
+		Schema:      schema,
+	}, onProgress)
 }
 
-// callOpenAI makes a request to OpenAI API
-func (ai *AIService) callOpenAI(prompt string) (string, error) {
-	// Check if API key is available
-	if ai.openaiKey == "" {
-		return "", fmt.Errorf("OPEN_AI_API_KEY environment variable is not set")
-	}
-
-	// Create OpenAI request
-	requestBody := map[string]interface{}{
-		"model": "gpt-4",
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": WorkoutPlanPrompt,
-			},
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.7,
-		"max_tokens":  2000,
-	}
-
-	// Convert request to JSON
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Create HTTP request
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+ai.openaiKey)
-
-	// Make the request
-	resp, err := ai.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("OpenAI API request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Check for HTTP errors
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("OpenAI API error: %s - %s", resp.Status, string(body))
+// createRepairPrompt describes why the previous reply, a subject such as "workout
+// plan", was rejected
+func createRepairPrompt(err error, subject string) string {
+	var problems []string
+
+	var validationErr *PlanValidationError
+	if errors.As(err, &validationErr) {
+		for _, v := range validationErr.Violations {
+			problems = append(problems, fmt.Sprintf("- %s: %s", v.Path, v.Message))
+		}
+	} else {
+		problems = append(problems, "- "+err.Error())
 	}
 
-	// Parse OpenAI response
-	var openaiResponse struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	err = json.Unmarshal(body, &openaiResponse)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse OpenAI response: %w", err)
-	}
-
-	// Extract the response content
-	if len(openaiResponse.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
-	}
-
-	return openaiResponse.Choices[0].Message.Content, nil
+	return fmt.Sprintf(RepairPromptTemplate, strings.Join(problems, "\n"), subject)
 }
 
-// callDeepSeek makes a request to DeepSeek API
-func (ai *AIService) callDeepSeek(prompt string) (string, error) {
-	// Check if API key is available
-	if ai.deepseekKey == "" {
-		return "", fmt.Errorf("DEEPSEEK_AI_API_KEY environment variable is not set")
-	}
+// complete tries each provider in order, retrying transient failures first. Transport
+// errors, timeouts, 429s and 5xx responses then move on to the next provider; any other
+// error is returned immediately. Providers that are not configured or whose circuit
+// breaker is open are skipped. When onProgress is set, providers that support streaming
+// report the accumulated reply through it.
+func (ai *AIService) complete(ctx context.Context, req ChatRequest, onProgress func(text string)) (*ChatResponse, GenerationMetadata, error) {
+	var metadata GenerationMetadata
 
-	// Create DeepSeek request with context for better timeout handling
-	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
-	defer cancel()
-
-	requestBody := map[string]interface{}{
-		"model": "deepseek-chat",
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": WorkoutPlanPrompt,
-			},
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.7,
-		"max_tokens":  2000,
-		"response_format": map[string]string{
-			"type": "json_object",
-		},
+	if len(ai.providers) == 0 {
+		return nil, metadata, fmt.Errorf("unsupported AI provider: %s", ai.selectedAI)
 	}
 
-	// Convert request to JSON
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	var errs []error
+	for _, provider := range ai.providers {
+		// Stop as soon as the caller gives up or the generation deadline passes
+		if err := ctx.Err(); err != nil {
+			return nil, metadata, err
+		}
+
+		name := provider.Name()
+		breaker := ai.breakers[name]
+
+		if !breaker.Allow() {
+			metadata.Fallbacks = append(metadata.Fallbacks, ProviderAttempt{
+				Provider: name,
+				Error:    "circuit breaker open",
+			})
+			continue
+		}
+
+		response, err := ai.callWithRetry(ctx, provider, req, onProgress)
+		if err == nil {
+			breaker.Success()
+			metadata.Provider = name
+			metadata.Model = response.Model
+			metadata.Usage = response.Usage
+			return response, metadata, nil
+		}
+
+		if errors.Is(err, ErrProviderNotConfigured) {
+			breaker.Release()
+			metadata.Fallbacks = append(metadata.Fallbacks, ProviderAttempt{
+				Provider: name,
+				Error:    err.Error(),
+			})
+			errs = append(errs, err)
+			continue
+		}
+
+		if !shouldFallback(err) {
+			breaker.Release()
+			return nil, metadata, err
+		}
+
+		breaker.Failure()
+		log.Printf("AI provider %s failed, trying next provider: %v", name, err)
+		metadata.Fallbacks = append(metadata.Fallbacks, ProviderAttempt{
+			Provider: name,
+			Error:    err.Error(),
+		})
+		errs = append(errs, err)
 	}
 
-	// Create HTTP request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.deepseek.com/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	if len(errs) == 0 {
+		return nil, metadata, ErrNoProviderAvailable
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+ai.deepseekKey)
-
-	// Make the request
-	resp, err := ai.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("DeepSeek API request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body with timeout
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	// Wrap the last error so callers classify the failure by the final provider tried
+	last := errs[len(errs)-1]
+	if len(errs) == 1 {
+		return nil, metadata, fmt.Errorf("all AI providers failed: %w", last)
 	}
-
-	// Check for HTTP errors
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("DeepSeek API error: %s - %s", resp.Status, string(body))
+	earlier := make([]string, 0, len(errs)-1)
+	for _, err := range errs[:len(errs)-1] {
+		earlier = append(earlier, err.Error())
 	}
-
-	// Parse DeepSeek response
-	var deepseekResponse struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Error *struct {
-			Message string `json:"message"`
-		} `json:"error,omitempty"`
-	}
-
-	err = json.Unmarshal(body, &deepseekResponse)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse DeepSeek response: %w", err)
-	}
-
-	// Check for API errors in response
-	if deepseekResponse.Error != nil {
-		return "", fmt.Errorf("DeepSeek API error: %s", deepseekResponse.Error.Message)
-	}
-
-	// Extract the response content
-	if len(deepseekResponse.Choices) == 0 {
-		return "", fmt.Errorf("no response from DeepSeek")
-	}
-
-	return deepseekResponse.Choices[0].Message.Content, nil
+	return nil, metadata, fmt.Errorf("all AI providers failed (%s): %w", strings.Join(earlier, "; "), last)
 }
 
 // parseAIResponse parses the AI response into a WorkoutPlan struct
 func (ai *AIService) parseAIResponse(response string) (*models.WorkoutPlan, error) {
 	var workoutPlan models.WorkoutPlan
 
-	err := json.Unmarshal([]byte(response), &workoutPlan)
+	payload, err := extractJSONPayload(response)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(payload), &workoutPlan)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal AI response: %w", err)
 	}
@@ -308,7 +493,7 @@ func (ai *AIService) parseAIResponse(response string) (*models.WorkoutPlan, erro
 	return &workoutPlan, nil
 }
 
-// GetSelectedAI returns the currently selected AI provider
+// GetSelectedAI returns the primary AI provider
 func (ai *AIService) GetSelectedAI() AIProvider {
 	return ai.selectedAI
 }
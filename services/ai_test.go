@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"fit-ai-api/llmstub"
+	"fit-ai-api/models"
+)
+
+// setAIEnv configures the AI service for tests: the given providers in order, no
+// waiting between retries and no environment settings leaking in from the host
+func setAIEnv(t *testing.T, providers ...AIProvider) {
+	t.Helper()
+	names := make([]string, len(providers))
+	for i, name := range providers {
+		names[i] = string(name)
+	}
+	t.Setenv("SELECTED_AI", "")
+	t.Setenv("AI_PROVIDERS", strings.Join(names, ","))
+	t.Setenv("AI_MAX_RETRIES", "0")
+	t.Setenv("AI_RETRY_BASE_DELAY", "1ms")
+	t.Setenv("AI_RETRY_MAX_DELAY", "10ms")
+	t.Setenv("AI_MAX_REPAIR_ATTEMPTS", "1")
+	t.Setenv("AI_CIRCUIT_FAILURE_THRESHOLD", "")
+	t.Setenv("AI_CIRCUIT_COOLDOWN", "")
+	t.Setenv("AI_REQUEST_TIMEOUT", "")
+	t.Setenv("AI_GENERATION_TIMEOUT", "")
+	for _, pc := range providerCases {
+		t.Setenv(pc.apiKeyEnv, "test-key")
+		t.Setenv(pc.modelEnv, "test-model")
+	}
+	t.Setenv("OLLAMA_STRUCTURED_OUTPUT", "")
+}
+
+// stubServer starts a stub server that is closed when the test ends
+func stubServer(t *testing.T, responses ...llmstub.Response) *llmstub.Server {
+	t.Helper()
+	stub := llmstub.NewServer(responses...)
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func testUserData() models.UserData {
+	return models.UserData{Data: models.FirestoreUser{
+		UID: "user-1",
+		UserProfile: models.UserProfile{
+			FullName:     "Ana Tester",
+			DateOfBirth:  "1990-05-01",
+			Gender:       "female",
+			FitnessLevel: "intermediate",
+			Goals:        []string{"muscle gain"},
+			Equipment:    []string{"barbell", "cable machine"},
+			Preferences:  models.UserPreferences{Units: "metric"},
+			Stats:        models.UserStats{TotalWorkouts: 42, CurrentStreak: 5},
+		},
+	}}
+}
+
+func TestGenerateWorkoutPlanPrompt(t *testing.T) {
+	setAIEnv(t, OpenAI)
+	stub := stubServer(t, llmstub.MustFixture("openai_plan"))
+
+	result, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlan(context.Background(), testUserData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := stub.LastRequest()
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != WorkoutPlanPrompt {
+		t.Fatalf("unexpected messages: %+v", req.Messages)
+	}
+	prompt := req.Messages[1].Content
+	for _, want := range []string{"Ana Tester", "intermediate", "muscle gain", "barbell cable machine", "metric", "42 workouts", `"sessions"`} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q", want)
+		}
+	}
+
+	if result.Plan.Name != "Push Pull Legs Hypertrophy" || len(result.Plan.Sessions) != 3 {
+		t.Errorf("unexpected plan: %+v", result.Plan)
+	}
+	if result.Metadata.Provider != OpenAI || result.Metadata.Model != "gpt-4o-2024-08-06" || result.Metadata.Usage.TotalTokens != 2455 {
+		t.Errorf("unexpected metadata: %+v", result.Metadata)
+	}
+}
+
+func TestGenerateWorkoutPlanEveryProvider(t *testing.T) {
+	for _, pc := range providerCases {
+		t.Run(string(pc.name), func(t *testing.T) {
+			setAIEnv(t, pc.name)
+			stub := stubServer(t, llmstub.MustFixture("deepseek_tool_call_plan"))
+
+			result, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlan(context.Background(), testUserData())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Metadata.Provider != pc.name || result.Plan.Name != "Dumbbell Full Body" {
+				t.Errorf("got plan %q from %s", result.Plan.Name, result.Metadata.Provider)
+			}
+		})
+	}
+}
+
+func TestGenerateWorkoutPlanFencedReply(t *testing.T) {
+	setAIEnv(t, OpenAI)
+	stub := stubServer(t, llmstub.MustFixture("openai_fenced_plan"))
+
+	result, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlan(context.Background(), testUserData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Metadata.RepairAttempts != 0 || len(result.Plan.Sessions) != 3 {
+		t.Errorf("fenced plan was not parsed directly: %+v", result.Metadata)
+	}
+}
+
+func TestGenerateWorkoutPlanRepairsMalformedReply(t *testing.T) {
+	setAIEnv(t, OpenAI)
+	stub := stubServer(t, llmstub.MustFixture("openai_malformed_content"), llmstub.MustFixture("openai_plan"))
+
+	result, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlan(context.Background(), testUserData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Metadata.RepairAttempts != 1 {
+		t.Errorf("RepairAttempts = %d, want 1", result.Metadata.RepairAttempts)
+	}
+
+	// The repair request replays the bad reply and explains the problem
+	requests := stub.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	messages := requests[1].Messages
+	if len(messages) != 4 || messages[2].Role != "assistant" || !strings.Contains(messages[3].Content, "could not be used") {
+		t.Errorf("unexpected repair conversation: %+v", messages)
+	}
+}
+
+func TestGenerateWorkoutPlanMalformedReplies(t *testing.T) {
+	for _, fixture := range []string{"openai_malformed_content", "openai_malformed_body", "openai_empty_choices"} {
+		t.Run(fixture, func(t *testing.T) {
+			setAIEnv(t, OpenAI)
+			stub := stubServer(t)
+			stub.SetDefault(llmstub.MustFixture(fixture))
+
+			_, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlan(context.Background(), testUserData())
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) || providerErr.Kind != ErrorKindMalformed {
+				t.Fatalf("err = %v, want a malformed output error", err)
+			}
+		})
+	}
+}
+
+func TestGenerateWorkoutPlanRetriesRateLimit(t *testing.T) {
+	setAIEnv(t, OpenAI)
+	t.Setenv("AI_MAX_RETRIES", "2")
+	rateLimited := llmstub.Error(http.StatusTooManyRequests, "requests", "rate_limit_exceeded", "Rate limit reached")
+	stub := stubServer(t, rateLimited, rateLimited, llmstub.MustFixture("openai_plan"))
+
+	result, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlan(context.Background(), testUserData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.Requests()) != 3 || len(result.Metadata.Fallbacks) != 0 {
+		t.Errorf("got %d requests and fallbacks %v, want 3 requests to one provider", len(stub.Requests()), result.Metadata.Fallbacks)
+	}
+}
+
+func TestGenerateWorkoutPlanFallsBack(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		fallback bool
+		kind     ErrorKind
+	}{
+		{"openai_rate_limited", true, ErrorKindRateLimit},
+		{"openai_insufficient_quota", true, ErrorKindQuota},
+		{"deepseek_server_overloaded", true, ErrorKindServer},
+		{"openai_slow_plan", true, ErrorKindTimeout},
+		{"openai_invalid_api_key", false, ErrorKindAuth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			setAIEnv(t, DeepSeek, OpenAI)
+			t.Setenv("AI_REQUEST_TIMEOUT", "50ms")
+			primary := stubServer(t, llmstub.MustFixture(tt.fixture))
+			secondary := stubServer(t, llmstub.MustFixture("openai_plan"))
+			t.Setenv("DEEPSEEK_BASE_URL", primary.BaseURL())
+			t.Setenv("OPEN_AI_BASE_URL", secondary.BaseURL())
+
+			result, err := NewAIService().GenerateWorkoutPlan(context.Background(), testUserData())
+			if !tt.fallback {
+				var providerErr *ProviderError
+				if !errors.As(err, &providerErr) || providerErr.Kind != tt.kind || providerErr.Provider != DeepSeek {
+					t.Fatalf("err = %v, want a %s error from %s", err, tt.kind, DeepSeek)
+				}
+				if len(secondary.Requests()) != 0 {
+					t.Error("fell back after a non-transient error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Metadata.Provider != OpenAI || len(result.Metadata.Fallbacks) != 1 || result.Metadata.Fallbacks[0].Provider != DeepSeek {
+				t.Errorf("unexpected metadata: %+v", result.Metadata)
+			}
+		})
+	}
+}
+
+func TestGenerateWorkoutPlanAllProvidersFail(t *testing.T) {
+	setAIEnv(t, DeepSeek, OpenAI)
+	stub := stubServer(t)
+	stub.SetDefault(llmstub.MustFixture("deepseek_server_overloaded"))
+
+	_, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlan(context.Background(), testUserData())
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Provider != OpenAI || providerErr.Kind != ErrorKindServer {
+		t.Fatalf("err = %v, want the server error of the last provider", err)
+	}
+	if !strings.Contains(err.Error(), "all AI providers failed") || len(stub.Requests()) != 2 {
+		t.Errorf("err = %v after %d requests", err, len(stub.Requests()))
+	}
+}
+
+func TestGenerateWorkoutPlanStream(t *testing.T) {
+	setAIEnv(t, DeepSeek)
+	stub := stubServer(t, llmstub.MustFixture("deepseek_tool_call_plan"))
+
+	var sessions int
+	result, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateWorkoutPlanStream(context.Background(), testUserData(), func(event StreamEvent) {
+		if event.Type == StreamEventSession {
+			sessions++
+			if event.Index != sessions {
+				t.Errorf("session event %d has index %d", sessions, event.Index)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions != len(result.Plan.Sessions) {
+		t.Errorf("streamed %d sessions, plan has %d", sessions, len(result.Plan.Sessions))
+	}
+}
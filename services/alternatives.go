@@ -0,0 +1,475 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// Sources of exercise alternatives
+const (
+	AlternativeSourceCatalog = "catalog"
+	AlternativeSourceAI      = "ai"
+)
+
+// ErrInvalidSwap is returned when a swap doesn't name a usable replacement
+var ErrInvalidSwap = errors.New("invalid exercise swap")
+
+// exerciseAlternativesSchema is derived from suggestedAlternatives like workoutPlanSchema
+var (
+	exerciseAlternativesSchema = &ResponseSchema{
+		Name:        "exercise_alternatives",
+		Description: "Substitutes for one exercise of a workout plan",
+		Schema:      JSONSchemaFor(reflect.TypeOf(suggestedAlternatives{})),
+	}
+	exerciseAlternativesSchemaJSON = mustMarshalIndent(exerciseAlternativesSchema.Schema)
+)
+
+// validExerciseTypes lists the accepted values of Exercise.Type
+var validExerciseTypes = map[string]bool{
+	"weight":      true,
+	"bodyweight":  true,
+	"cardio":      true,
+	"flexibility": true,
+}
+
+// suggestedAlternatives is the reply format of ExerciseAlternativesTemplate
+type suggestedAlternatives struct {
+	Alternatives []SuggestedAlternative `json:"alternatives"`
+}
+
+// SuggestedAlternative is a substitute proposed by the model
+type SuggestedAlternative struct {
+	Name   string `json:"name"`
+	Type   string `json:"type" enum:"weight,bodyweight,cardio,flexibility"`
+	Reason string `json:"reason"`
+}
+
+// ExerciseAlternatives lists the exercises that can replace one exercise of a plan
+type ExerciseAlternatives struct {
+	Exercise models.Exercise `json:"exercise"`
+	// Original is the catalog entry of the exercise, or nil when the catalog doesn't know it
+	Original *models.CatalogExercise `json:"original"`
+	// Available is the user's equipment mapped to the catalog taxonomy, or nil when the
+	// profile doesn't say what the user has
+	Available []string `json:"available"`
+	// Source tells whether the alternatives come from the catalog or from the model
+	Source       string                `json:"source"`
+	Alternatives []ExerciseAlternative `json:"alternatives"`
+	// Meta describes the generation when the model was asked
+	Meta *GenerationMetadata `json:"meta,omitempty"`
+}
+
+// ExerciseAlternative is one substitute for an exercise
+type ExerciseAlternative struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// CatalogExercise is the catalog entry of the substitute, or nil for a suggestion of
+	// the model the catalog doesn't know
+	CatalogExercise *models.CatalogExercise `json:"catalogExercise"`
+	// Similarity is the weighted overlap of the muscles both exercises work, from 0 to 1,
+	// when both are in the catalog
+	Similarity float64 `json:"similarity"`
+	Reason     string  `json:"reason"`
+}
+
+// SwapRequest names the exercise that replaces another one: either a catalog exercise
+// by ID or a free-text name, which is resolved to the catalog when possible. Type is
+// used for names the catalog doesn't know and defaults to the type of the replaced
+// exercise.
+type SwapRequest struct {
+	CatalogID *uint  `json:"catalogId"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+}
+
+// SwapResult is a plan after one of its exercises was swapped
+type SwapResult struct {
+	Plan        *models.WorkoutPlan `json:"plan"`
+	Original    models.Exercise     `json:"original"`
+	Replacement models.Exercise     `json:"replacement"`
+}
+
+// ExerciseSwapService finds substitutes for the exercises of stored plans and swaps them
+// into the plan
+type ExerciseSwapService struct {
+	plans     repositories.WorkoutPlanRepository
+	profiles  repositories.ProfileRepository
+	catalog   *ExerciseCatalog
+	aiService *AIService
+}
+
+// NewExerciseSwapService creates a swap service. catalog may be nil, in which case every
+// lookup asks the model and swaps are stored by name only.
+func NewExerciseSwapService(plans repositories.WorkoutPlanRepository, profiles repositories.ProfileRepository, catalog *ExerciseCatalog, aiService *AIService) *ExerciseSwapService {
+	return &ExerciseSwapService{
+		plans:     plans,
+		profiles:  profiles,
+		catalog:   catalog,
+		aiService: aiService,
+	}
+}
+
+// Alternatives returns up to limit substitutes for an exercise of a stored plan that work
+// the same muscles with the same movement pattern and need only the user's equipment.
+// The catalog is asked first; the model is asked when the exercise is not in the catalog
+// or the catalog has nothing that fits. reason, which may be empty, says why the user
+// wants to swap and is passed on to the model.
+func (s *ExerciseSwapService) Alternatives(ctx context.Context, planID uint, sessionID string, exerciseID int, reason string, limit int) (*ExerciseAlternatives, error) {
+	plan, session, exercise, err := s.find(ctx, planID, sessionID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	userData, err := s.profiles.Get(ctx, plan.UserID)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+	profile := userData.Data.UserProfile
+	available, _, ok := AvailableEquipment(profile.Equipment)
+	if !ok {
+		available = nil
+	}
+
+	result := &ExerciseAlternatives{
+		Exercise:     *exercise,
+		Available:    available,
+		Source:       AlternativeSourceCatalog,
+		Alternatives: []ExerciseAlternative{},
+	}
+	if s.catalog != nil {
+		original, alternatives, err := s.catalog.Alternatives(ctx, *exercise, available, sessionCatalogIDs(session), profile.FitnessLevel)
+		if err != nil {
+			return nil, err
+		}
+		result.Original = original
+		for _, alternative := range alternatives {
+			if len(result.Alternatives) == limit {
+				break
+			}
+			result.Alternatives = append(result.Alternatives, catalogAlternative(alternative, original))
+		}
+		if len(result.Alternatives) > 0 {
+			return result, nil
+		}
+	}
+
+	suggestions, metadata, err := s.aiService.SuggestAlternatives(ctx, userData, session, exercise, result.Original, reason, limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Source = AlternativeSourceAI
+	result.Meta = &metadata
+	result.Alternatives, err = s.resolveSuggestions(ctx, suggestions, exercise, result.Original, available, limit)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Swap replaces an exercise of a stored plan, keeping its ID, sets and reps so logged
+// workouts still refer to it. The weight is adjusted like for equipment substitutions.
+func (s *ExerciseSwapService) Swap(ctx context.Context, planID uint, sessionID string, exerciseID int, req SwapRequest) (*SwapResult, error) {
+	plan, _, exercise, err := s.find(ctx, planID, sessionID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement, err := s.replacementFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	name, exerciseType := strings.TrimSpace(req.Name), req.Type
+	var catalogID *uint
+	if replacement != nil {
+		id := replacement.ID
+		name, exerciseType, catalogID = replacement.Name, replacement.Type, &id
+	}
+	equipment := inferEquipment(name)
+	if replacement != nil {
+		equipment = replacement.Equipment
+	}
+	if exerciseType == "" {
+		exerciseType = exercise.Type
+	}
+	if !validExerciseTypes[exerciseType] {
+		return nil, fmt.Errorf("%w: type must be one of weight, bodyweight, cardio, flexibility, got %q", ErrInvalidSwap, exerciseType)
+	}
+	if strings.EqualFold(name, exercise.Name) {
+		return nil, fmt.Errorf("%w: %s is already the exercise being replaced", ErrInvalidSwap, name)
+	}
+
+	originalEquipment, err := s.equipmentOf(ctx, *exercise)
+	if err != nil {
+		return nil, err
+	}
+
+	swapped := *exercise
+	applyReplacement(&swapped, name, exerciseType, catalogID, !sameEquipment(originalEquipment, equipment), plan.Sessions)
+	updated, err := s.plans.ReplaceExercise(ctx, planID, swapped)
+	if err != nil {
+		return nil, err
+	}
+	return &SwapResult{
+		Plan:        updated,
+		Original:    *exercise,
+		Replacement: swapped,
+	}, nil
+}
+
+// find loads a plan and returns it with the requested session and exercise
+func (s *ExerciseSwapService) find(ctx context.Context, planID uint, sessionID string, exerciseID int) (*models.WorkoutPlan, *models.WorkoutSession, *models.Exercise, error) {
+	plan, err := s.plans.Get(ctx, planID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	session := findSession(plan, sessionID)
+	if session == nil {
+		return nil, nil, nil, ErrSessionNotFound
+	}
+	exercise := findExercise(session, exerciseID)
+	if exercise == nil {
+		return nil, nil, nil, ErrExerciseNotFound
+	}
+	return plan, session, exercise, nil
+}
+
+// equipmentOf returns the equipment exercise needs: that of its catalog entry, or the
+// equipment inferred from its name when it has none
+func (s *ExerciseSwapService) equipmentOf(ctx context.Context, exercise models.Exercise) ([]string, error) {
+	if exercise.CatalogID != nil && s.catalog != nil {
+		entry, err := s.catalog.Get(ctx, *exercise.CatalogID)
+		if err == nil {
+			return entry.Equipment, nil
+		}
+		if !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return inferEquipment(exercise.Name), nil
+}
+
+// replacementFor returns the catalog exercise a swap asks for, or nil for a name the
+// catalog doesn't know
+func (s *ExerciseSwapService) replacementFor(ctx context.Context, req SwapRequest) (*models.CatalogExercise, error) {
+	if req.CatalogID != nil {
+		if s.catalog == nil {
+			return nil, fmt.Errorf("%w: the exercise catalog is not available", ErrInvalidSwap)
+		}
+		replacement, err := s.catalog.Get(ctx, *req.CatalogID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, fmt.Errorf("%w: catalog exercise %d does not exist", ErrInvalidSwap, *req.CatalogID)
+		}
+		return replacement, err
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: catalogId or name is required", ErrInvalidSwap)
+	}
+	if s.catalog == nil {
+		return nil, nil
+	}
+	return s.catalog.Resolve(ctx, req.Name)
+}
+
+// resolveSuggestions turns the model's suggestions into alternatives, resolving them to
+// the catalog and dropping duplicates, the exercise itself and suggestions needing
+// equipment that isn't available
+func (s *ExerciseSwapService) resolveSuggestions(ctx context.Context, suggestions []SuggestedAlternative, exercise *models.Exercise, original *models.CatalogExercise, available []string, limit int) ([]ExerciseAlternative, error) {
+	alternatives := []ExerciseAlternative{}
+	seen := map[string]bool{strings.ToLower(exercise.Name): true}
+	for _, suggestion := range suggestions {
+		if len(alternatives) == limit {
+			break
+		}
+		alternative := ExerciseAlternative{
+			Name:   strings.TrimSpace(suggestion.Name),
+			Type:   suggestion.Type,
+			Reason: suggestion.Reason,
+		}
+
+		var resolved *models.CatalogExercise
+		if s.catalog != nil {
+			var err error
+			if resolved, err = s.catalog.Resolve(ctx, alternative.Name); err != nil {
+				return nil, err
+			}
+		}
+		if resolved != nil {
+			if original != nil && resolved.ID == original.ID {
+				continue
+			}
+			if available != nil && !resolved.RequiresOnly(available) {
+				continue
+			}
+			alternative.Name, alternative.Type = resolved.Name, resolved.Type
+			alternative.CatalogExercise = resolved
+			if original != nil {
+				alternative.Similarity = roundScore(muscleSimilarity(original, resolved))
+			}
+		} else if available != nil && len(missingEquipment(inferEquipment(alternative.Name), available)) > 0 {
+			continue
+		}
+
+		if key := strings.ToLower(alternative.Name); !seen[key] {
+			seen[key] = true
+			alternatives = append(alternatives, alternative)
+		}
+	}
+	return alternatives, nil
+}
+
+// Alternatives returns the catalog entry of exercise, resolving it by name when it has
+// no CatalogID, and the catalog exercises that can replace it as ranked by
+// rankAlternatives. Both are nil when the catalog doesn't know the exercise.
+func (c *ExerciseCatalog) Alternatives(ctx context.Context, exercise models.Exercise, available []string, exclude map[uint]bool, fitnessLevel string) (*models.CatalogExercise, []Alternative, error) {
+	entries, byID, err := c.load(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var original *models.CatalogExercise
+	if exercise.CatalogID != nil {
+		if i, ok := byID[*exercise.CatalogID]; ok {
+			original = &entries[i].exercise
+		}
+	}
+	if original == nil {
+		if original, err = c.Resolve(ctx, exercise.Name); err != nil || original == nil {
+			return nil, nil, err
+		}
+	}
+	return original, rankAlternatives(entries, original, available, exclude, fitnessLevel), nil
+}
+
+// catalogAlternative describes a catalog alternative to original
+func catalogAlternative(alternative Alternative, original *models.CatalogExercise) ExerciseAlternative {
+	replacement := alternative.Exercise
+	return ExerciseAlternative{
+		Name:            replacement.Name,
+		Type:            replacement.Type,
+		CatalogExercise: &replacement,
+		Similarity:      alternative.Similarity,
+		Reason: fmt.Sprintf("%s works %s with the same %s pattern as %s",
+			replacement.Name, strings.Join(taxonomyLabels(replacement.PrimaryMuscles), " and "), patternLabel(original.MovementPattern), original.Name),
+	}
+}
+
+// SuggestAlternatives asks the model for up to limit substitutes for an exercise of
+// session. original is the catalog entry of the exercise, if known, and reason says why
+// the user wants to swap. Replies are validated and repaired like generated plans.
+func (ai *AIService) SuggestAlternatives(ctx context.Context, userData models.UserData, session *models.WorkoutSession, exercise *models.Exercise, original *models.CatalogExercise, reason string, limit int) ([]SuggestedAlternative, GenerationMetadata, error) {
+	if ai.generationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ai.generationTimeout)
+		defer cancel()
+	}
+
+	if strings.TrimSpace(reason) == "" {
+		reason = "not given"
+	}
+	user := userData.Data
+	messages := []ChatMessage{
+		{
+			Role:    "system",
+			Content: ExerciseAlternativesPrompt,
+		},
+		{
+			Role: "user",
+			Content: fmt.Sprintf(ExerciseAlternativesTemplate,
+				user.FitnessLevel,
+				user.Goals,
+				user.Equipment,
+				exercise.Name,
+				exercise.Type,
+				exercise.Sets,
+				exercise.Reps,
+				formatWeight(exercise.Weight),
+				describeCatalogExercise(original),
+				describeSession(session),
+				reason,
+				limit,
+				exerciseAlternativesSchemaJSON),
+		},
+	}
+
+	var suggestions []SuggestedAlternative
+	metadata, err := ai.generateWithRepair(ctx, messages, exerciseAlternativesSchema, "list of alternatives", nil, nil,
+		func(content string) error {
+			payload, err := extractJSONPayload(content)
+			if err != nil {
+				return err
+			}
+			var parsed suggestedAlternatives
+			if err := json.Unmarshal([]byte(payload), &parsed); err != nil {
+				return fmt.Errorf("failed to unmarshal AI response: %w", err)
+			}
+			if violations := validateAlternatives(parsed.Alternatives, exercise); len(violations) > 0 {
+				return &PlanValidationError{Violations: violations}
+			}
+			suggestions = parsed.Alternatives
+			return nil
+		})
+	if err != nil {
+		return nil, metadata, err
+	}
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, metadata, nil
+}
+
+// validateAlternatives checks the model's suggestions for exercise
+func validateAlternatives(alternatives []SuggestedAlternative, exercise *models.Exercise) []PlanViolation {
+	var violations []PlanViolation
+	if len(alternatives) == 0 {
+		violations = append(violations, PlanViolation{
+			Path:    "alternatives",
+			Rule:    "required",
+			Message: "at least one alternative is required",
+		})
+	}
+	for i, alternative := range alternatives {
+		path := fmt.Sprintf("alternatives[%d]", i)
+		name := strings.TrimSpace(alternative.Name)
+		switch {
+		case name == "":
+			violations = append(violations, PlanViolation{Path: path + ".name", Rule: "required", Message: "exercise name is required"})
+		case strings.EqualFold(name, exercise.Name):
+			violations = append(violations, PlanViolation{Path: path + ".name", Rule: "distinct", Message: fmt.Sprintf("%s is the exercise being replaced", name)})
+		}
+		if !validExerciseTypes[alternative.Type] {
+			violations = append(violations, PlanViolation{Path: path + ".type", Rule: "enum", Message: fmt.Sprintf("type must be one of weight, bodyweight, cardio, flexibility, got %q", alternative.Type)})
+		}
+	}
+	return violations
+}
+
+// describeCatalogExercise renders what the catalog knows about an exercise for a prompt
+func describeCatalogExercise(exercise *models.CatalogExercise) string {
+	if exercise == nil {
+		return "not in the exercise catalog"
+	}
+	description := fmt.Sprintf("%s, %s pattern, primary muscles: %s",
+		exercise.Name, patternLabel(exercise.MovementPattern), strings.Join(taxonomyLabels(exercise.PrimaryMuscles), ", "))
+	if len(exercise.SecondaryMuscles) > 0 {
+		description += ", secondary muscles: " + strings.Join(taxonomyLabels(exercise.SecondaryMuscles), ", ")
+	}
+	if len(exercise.Equipment) > 0 {
+		description += ", equipment: " + strings.Join(taxonomyLabels(exercise.Equipment), ", ")
+	}
+	return description
+}
+
+// describeSession renders a session as its name and exercises for a prompt
+func describeSession(session *models.WorkoutSession) string {
+	names := make([]string, len(session.Exercises))
+	for i, exercise := range session.Exercises {
+		names[i] = exercise.Name
+	}
+	return fmt.Sprintf("%s: %s", session.Name, strings.Join(names, ", "))
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"fit-ai-api/llmstub"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// newTestSwapService stores testPlan for a user with dumbbells, a bench and a pull-up
+// bar. The replies are played back to any request for the model.
+func newTestSwapService(t *testing.T, edit func(*models.WorkoutPlan), replies ...llmstub.Response) (*ExerciseSwapService, *models.WorkoutPlan, *llmstub.Server) {
+	t.Helper()
+	setAIEnv(t, OpenAI)
+	stub := stubServer(t, replies...)
+	aiService := NewAIServiceWithBaseURL(stub.BaseURL())
+
+	catalog := newTestCatalog(t)
+	fixture := resolvedTestPlan(t, catalog)
+	if edit != nil {
+		edit(fixture)
+	}
+	plans := repositories.NewMemoryWorkoutPlanRepository()
+	plan, err := plans.Create(context.Background(), "user-1", fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles := repositories.NewMemoryProfileRepository()
+	user := testUserData().Data
+	user.Equipment = []string{"dumbbells", "bench", "pull-up bar"}
+	profiles.Put("user-1", user)
+
+	return NewExerciseSwapService(plans, profiles, catalog, aiService), plan, stub
+}
+
+func TestAlternativesFromCatalog(t *testing.T) {
+	swaps, plan, stub := newTestSwapService(t, nil)
+	session := plan.Sessions[0]
+	bench := session.Exercises[0]
+
+	result, err := swaps.Alternatives(context.Background(), uint(plan.ID), session.ID, bench.ID, "the bench is taken", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Source != AlternativeSourceCatalog || len(stub.Requests()) != 0 {
+		t.Fatalf("source = %s after %d requests, want the catalog without asking the model", result.Source, len(stub.Requests()))
+	}
+	if result.Original == nil || result.Original.Name != "Barbell Bench Press" {
+		t.Fatalf("original = %+v", result.Original)
+	}
+	if len(result.Alternatives) == 0 || len(result.Alternatives) > 3 {
+		t.Fatalf("got %d alternatives, want 1-3", len(result.Alternatives))
+	}
+	for _, alternative := range result.Alternatives {
+		exercise := alternative.CatalogExercise
+		if exercise.MovementPattern != result.Original.MovementPattern || !exercise.RequiresOnly(result.Available) {
+			t.Errorf("%s (%s, %v) doesn't fit", exercise.Name, exercise.MovementPattern, exercise.Equipment)
+		}
+	}
+	if result.Alternatives[0].Name != "Dumbbell Bench Press" {
+		t.Errorf("best alternative = %s, want Dumbbell Bench Press", result.Alternatives[0].Name)
+	}
+}
+
+func TestAlternativesFallBackToModel(t *testing.T) {
+	reply := llmstub.Completion(`{"alternatives":[
+		{"name":"Goblet Squat","type":"weight","reason":"Front-loaded squat that only needs one dumbbell"},
+		{"name":"Barbell Back Squat","type":"weight","reason":"The classic squat"},
+		{"name":"Sandbag Bear Hug Squat","type":"weight","reason":"Same front-loaded position"},
+		{"name":"Dumbbell Split Squat","type":"weight","reason":"Single-leg squat with dumbbells"}]}`)
+	swaps, plan, stub := newTestSwapService(t, func(plan *models.WorkoutPlan) {
+		plan.Sessions[2].Exercises[0].Name = "Sandbag Zercher Squat"
+		plan.Sessions[2].Exercises[0].CatalogID = nil
+	}, reply)
+	session := plan.Sessions[2]
+
+	result, err := swaps.Alternatives(context.Background(), uint(plan.ID), session.ID, session.Exercises[0].ID, "my knee hurts", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Source != AlternativeSourceAI || result.Meta == nil || result.Original != nil {
+		t.Fatalf("got source %s, meta %v, original %v; want the model's suggestions", result.Source, result.Meta, result.Original)
+	}
+	request, _ := stub.LastRequest()
+	if prompt := request.Prompt(); !strings.Contains(prompt, "Sandbag Zercher Squat") || !strings.Contains(prompt, "my knee hurts") {
+		t.Errorf("prompt is missing the exercise or the reason:\n%s", prompt)
+	}
+
+	// The barbell squat needs equipment the user doesn't have
+	var names []string
+	for _, alternative := range result.Alternatives {
+		names = append(names, alternative.Name)
+	}
+	if strings.Join(names, ", ") != "Goblet Squat, Sandbag Bear Hug Squat, Dumbbell Split Squat" {
+		t.Errorf("alternatives = %v", names)
+	}
+	if result.Alternatives[0].CatalogExercise == nil || result.Alternatives[1].CatalogExercise != nil {
+		t.Error("catalog exercises were not resolved")
+	}
+}
+
+func TestSwapExercise(t *testing.T) {
+	swaps, plan, _ := newTestSwapService(t, nil)
+	session := plan.Sessions[0]
+	bench := session.Exercises[0]
+	catalog := swaps.catalog
+	dumbbellPress, err := catalog.Resolve(context.Background(), "Dumbbell Bench Press")
+	if err != nil || dumbbellPress == nil {
+		t.Fatalf("Dumbbell Bench Press is not in the catalog: %v", err)
+	}
+
+	result, err := swaps.Swap(context.Background(), uint(plan.ID), session.ID, bench.ID, SwapRequest{CatalogID: &dumbbellPress.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := findExercise(findSession(result.Plan, session.ID), bench.ID)
+	if swapped == nil || swapped.Name != "Dumbbell Bench Press" || *swapped.CatalogID != dumbbellPress.ID {
+		t.Fatalf("stored exercise = %+v", swapped)
+	}
+	if swapped.Sets != bench.Sets || swapped.Reps != bench.Reps {
+		t.Errorf("swap changed the prescription: %+v -> %+v", bench, *swapped)
+	}
+	if swapped.Weight != (models.WeightInfo{Value: 0, Unit: bench.Weight.Unit}) {
+		t.Errorf("weight = %+v, want the barbell load reset for dumbbells", swapped.Weight)
+	}
+
+	// A name the catalog doesn't know is stored as given
+	result, err = swaps.Swap(context.Background(), uint(plan.ID), session.ID, bench.ID, SwapRequest{Name: "Towel Isometric Squeeze", Type: "bodyweight"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped = findExercise(findSession(result.Plan, session.ID), bench.ID)
+	if swapped.Name != "Towel Isometric Squeeze" || swapped.CatalogID != nil || swapped.Weight.Unit != WeightUnitBodyweight || swapped.Sets != bench.Sets {
+		t.Errorf("stored exercise = %+v", *swapped)
+	}
+
+	for _, req := range []SwapRequest{{}, {Name: "Towel Isometric Squeeze"}, {Name: "Ring Press", Type: "plyometric"}} {
+		if _, err := swaps.Swap(context.Background(), uint(plan.ID), session.ID, bench.ID, req); !errors.Is(err, ErrInvalidSwap) {
+			t.Errorf("Swap(%+v) error = %v, want ErrInvalidSwap", req, err)
+		}
+	}
+	if _, err := swaps.Swap(context.Background(), uint(plan.ID), "session_9", bench.ID, SwapRequest{Name: "Push-Up"}); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("unknown session: error = %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+const (
+	// defaultCatalogMatchThreshold is the lowest score at which a name is resolved to a
+	// catalog exercise
+	defaultCatalogMatchThreshold = 0.8
+	// tokenSimilarityThreshold is the lowest similarity at which two words count as the
+	// same word spelled differently, e.g. "pulldown" and "pull-down"
+	tokenSimilarityThreshold = 0.8
+)
+
+// catalogAbbreviations expands abbreviations common in exercise names
+var catalogAbbreviations = map[string][]string{
+	"db":   {"dumbbell"},
+	"bb":   {"barbell"},
+	"kb":   {"kettlebell"},
+	"rdl":  {"romanian", "deadlift"},
+	"ohp":  {"overhead", "press"},
+	"flye": {"fly"},
+}
+
+// catalogStopwords are dropped from names before they are compared
+var catalogStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "with": true, "and": true, "of": true,
+	"on": true, "to": true, "using": true, "hold": true,
+}
+
+// equipmentWords name equipment in exercise names. A name mentioning one only matches
+// catalog names that mention it too, so "Dumbbell Bench Press" never resolves to the
+// barbell version.
+var equipmentWords = map[string]bool{
+	"barbell": true, "dumbbell": true, "kettlebell": true, "cable": true, "band": true,
+	"machine": true, "ez": true, "smith": true, "medicine": true, "bench": true,
+}
+
+// CatalogMatch is a catalog exercise a free-text name was compared with
+type CatalogMatch struct {
+	Exercise models.CatalogExercise `json:"exercise"`
+	// MatchedName is the name or alias of the exercise that was closest
+	MatchedName string  `json:"matchedName"`
+	Score       float64 `json:"score"`
+	// Resolved reports whether the score reaches the match threshold
+	Resolved bool `json:"resolved"`
+}
+
+// CatalogReport summarizes how the exercise names of a plan were resolved
+type CatalogReport struct {
+	Resolved int               `json:"resolved"`
+	Unknown  []UnknownExercise `json:"unknown,omitempty"`
+}
+
+// UnknownExercise is a plan exercise that matched no catalog exercise
+type UnknownExercise struct {
+	Session string `json:"session"`
+	Name    string `json:"name"`
+	// Closest is the best catalog candidate, too weak to be trusted
+	Closest string  `json:"closest,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// catalogName is a normalized name or alias of a catalog exercise
+type catalogName struct {
+	name   string
+	tokens []string
+	// key is the tokens joined without separators, so "Push-Up" and "Pushup" are equal
+	key string
+}
+
+// catalogEntry is a catalog exercise with its normalized names
+type catalogEntry struct {
+	exercise models.CatalogExercise
+	names    []catalogName
+	// words holds every token of every name, for the equipment word check
+	words map[string]bool
+}
+
+// ExerciseCatalog searches the exercise library and resolves free-text exercise names,
+// such as those written by the model, to catalog exercises. The library is loaded once
+// and cached; Seed refreshes it.
+type ExerciseCatalog struct {
+	repo      repositories.ExerciseCatalogRepository
+	threshold float64
+
+	mu      sync.RWMutex
+	entries []catalogEntry
+	byID    map[uint]int
+}
+
+// NewExerciseCatalog creates a catalog service over repo.
+// CATALOG_MATCH_THRESHOLD sets the lowest match score, between 0 and 1, at which a name
+// is resolved (default 0.8).
+func NewExerciseCatalog(repo repositories.ExerciseCatalogRepository) *ExerciseCatalog {
+	threshold := envFloat("CATALOG_MATCH_THRESHOLD", defaultCatalogMatchThreshold)
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultCatalogMatchThreshold
+	}
+	return &ExerciseCatalog{repo: repo, threshold: threshold}
+}
+
+// Seed stores the built-in exercise library, updating exercises that already exist
+func (c *ExerciseCatalog) Seed(ctx context.Context) error {
+	exercises, err := repositories.DefaultExerciseCatalog()
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Seed(ctx, exercises); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.entries = nil
+	c.byID = nil
+	c.mu.Unlock()
+	return nil
+}
+
+// Search returns the exercises matching filter
+func (c *ExerciseCatalog) Search(ctx context.Context, filter repositories.ExerciseCatalogFilter) ([]models.CatalogExercise, error) {
+	return c.repo.Search(ctx, filter)
+}
+
+// Get returns a catalog exercise by ID
+func (c *ExerciseCatalog) Get(ctx context.Context, id uint) (*models.CatalogExercise, error) {
+	return c.repo.Get(ctx, id)
+}
+
+// Resolve returns the catalog exercise name refers to, or nil when none is close enough
+func (c *ExerciseCatalog) Resolve(ctx context.Context, name string) (*models.CatalogExercise, error) {
+	matches, err := c.Rank(ctx, name, 1)
+	if err != nil || len(matches) == 0 || !matches[0].Resolved {
+		return nil, err
+	}
+	return &matches[0].Exercise, nil
+}
+
+// Rank returns up to limit catalog exercises similar to name, best first. Exercises that
+// share no word with name are left out.
+func (c *ExerciseCatalog) Rank(ctx context.Context, name string, limit int) ([]CatalogMatch, error) {
+	entries, _, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := normalizeExerciseName(name)
+	if len(query.tokens) == 0 {
+		return []CatalogMatch{}, nil
+	}
+	matches := make([]CatalogMatch, 0)
+	for i := range entries {
+		score, matched := entries[i].score(query)
+		if score <= 0 {
+			continue
+		}
+		matches = append(matches, CatalogMatch{
+			Exercise:    entries[i].exercise,
+			MatchedName: matched,
+			Score:       score,
+			Resolved:    score >= c.threshold,
+		})
+	}
+	// Entries are ordered by name, so ties keep alphabetical order
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// ResolvePlan sets the CatalogID of every exercise in sessions and reports the
+// exercises that could not be resolved. A CatalogID that already refers to a catalog
+// exercise is kept, so clients can pick exercises from the catalog directly.
+func (c *ExerciseCatalog) ResolvePlan(ctx context.Context, sessions []models.WorkoutSession) (*CatalogReport, error) {
+	entries, byID, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CatalogReport{}
+	for s := range sessions {
+		session := &sessions[s]
+		for e := range session.Exercises {
+			exercise := &session.Exercises[e]
+			if exercise.CatalogID != nil {
+				if _, ok := byID[*exercise.CatalogID]; ok {
+					report.Resolved++
+					continue
+				}
+			}
+
+			exercise.CatalogID = nil
+			best, bestScore := -1, 0.0
+			query := normalizeExerciseName(exercise.Name)
+			for i := range entries {
+				if score, _ := entries[i].score(query); score > bestScore {
+					best, bestScore = i, score
+				}
+			}
+			if best >= 0 && bestScore >= c.threshold {
+				id := entries[best].exercise.ID
+				exercise.CatalogID = &id
+				report.Resolved++
+				continue
+			}
+
+			unknown := UnknownExercise{Session: session.Name, Name: exercise.Name}
+			if best >= 0 {
+				unknown.Closest = entries[best].exercise.Name
+				unknown.Score = roundScore(bestScore)
+			}
+			report.Unknown = append(report.Unknown, unknown)
+		}
+	}
+	return report, nil
+}
+
+// Annotate resolves the exercises of sessions like ResolvePlan but never fails: a catalog
+// that can't be loaded is logged and the exercises are left as they are. It does nothing
+// on a nil catalog.
+func (c *ExerciseCatalog) Annotate(ctx context.Context, sessions []models.WorkoutSession) *CatalogReport {
+	if c == nil {
+		return nil
+	}
+	report, err := c.ResolvePlan(ctx, sessions)
+	if err != nil {
+		log.Printf("Warning: exercise names were not resolved: %v", err)
+		return nil
+	}
+	return report
+}
+
+// load returns the cached catalog, reading it from the repository the first time
+func (c *ExerciseCatalog) load(ctx context.Context) ([]catalogEntry, map[uint]int, error) {
+	c.mu.RLock()
+	entries, byID := c.entries, c.byID
+	c.mu.RUnlock()
+	if entries != nil {
+		return entries, byID, nil
+	}
+
+	exercises, err := c.repo.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load exercise catalog: %w", err)
+	}
+	entries = make([]catalogEntry, len(exercises))
+	byID = make(map[uint]int, len(exercises))
+	for i, exercise := range exercises {
+		entries[i] = newCatalogEntry(exercise)
+		byID[exercise.ID] = i
+	}
+
+	c.mu.Lock()
+	c.entries, c.byID = entries, byID
+	c.mu.Unlock()
+	return entries, byID, nil
+}
+
+func newCatalogEntry(exercise models.CatalogExercise) catalogEntry {
+	entry := catalogEntry{exercise: exercise, words: make(map[string]bool)}
+	for _, name := range append([]string{exercise.Name}, exercise.Aliases...) {
+		normalized := normalizeExerciseName(name)
+		if len(normalized.tokens) == 0 {
+			continue
+		}
+		entry.names = append(entry.names, normalized)
+		for _, token := range normalized.tokens {
+			entry.words[token] = true
+		}
+	}
+	return entry
+}
+
+// score compares query with the exercise's names and returns the best score and the name
+// that produced it. An exact match of a name or alias scores 1; otherwise words are
+// paired up and the score is their Dice coefficient, counting near-identical words as
+// partial matches.
+func (e *catalogEntry) score(query catalogName) (float64, string) {
+	for _, token := range query.tokens {
+		if equipmentWords[token] && !e.words[token] {
+			return 0, ""
+		}
+	}
+
+	best, matched := 0.0, ""
+	for _, name := range e.names {
+		if name.key == query.key {
+			return 1, name.name
+		}
+		if score := diceSimilarity(query.tokens, name.tokens); score > best {
+			best, matched = score, name.name
+		}
+	}
+	return roundScore(best), matched
+}
+
+// normalizeExerciseName lowercases name, splits it into words, drops stopwords, reduces
+// plurals to their singular and expands abbreviations
+func normalizeExerciseName(name string) catalogName {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.ReplaceAll(field, "'", "")
+		if field == "" || catalogStopwords[field] {
+			continue
+		}
+		field = singular(field)
+		if expanded, ok := catalogAbbreviations[field]; ok {
+			tokens = append(tokens, expanded...)
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return catalogName{name: name, tokens: tokens, key: strings.Join(tokens, "")}
+}
+
+// singular strips a plural ending: "curls" becomes "curl" and "presses" "press"
+func singular(word string) string {
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// diceSimilarity pairs each word of a with its most similar unpaired word of b and
+// returns twice the summed similarity over the number of words
+func diceSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	used := make([]bool, len(b))
+	total := 0.0
+	for _, word := range a {
+		best, bestIndex := 0.0, -1
+		for j, other := range b {
+			if used[j] {
+				continue
+			}
+			if similarity := wordSimilarity(word, other); similarity > best {
+				best, bestIndex = similarity, j
+			}
+		}
+		if bestIndex >= 0 {
+			used[bestIndex] = true
+			total += best
+		}
+	}
+	return 2 * total / float64(len(a)+len(b))
+}
+
+// wordSimilarity is 1 minus the edit distance relative to the longer word, or 0 when the
+// words are too different to be the same word
+func wordSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	similarity := 1 - float64(levenshtein(ra, rb))/float64(longest)
+	if similarity < tokenSimilarityThreshold {
+		return 0
+	}
+	return similarity
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// roundScore rounds a score to two decimals for reporting
+func roundScore(score float64) float64 {
+	return float64(int(score*100+0.5)) / 100
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+func newTestCatalog(t *testing.T) *ExerciseCatalog {
+	t.Helper()
+	t.Setenv("CATALOG_MATCH_THRESHOLD", "")
+	repo, err := repositories.NewExerciseCatalogRepository(repositories.BackendMemory, repositories.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewExerciseCatalog(repo)
+}
+
+func TestCatalogResolve(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Dumbbell Bench Press", "Dumbbell Bench Press"},
+		{"Bench Press", "Barbell Bench Press"},
+		{"DB Lateral Raises", "Dumbbell Lateral Raise"},
+		{"Lat Pull-downs", "Lat Pulldown"},
+		{"Pullups", "Pull-Up"},
+		{"Romanian Deadlifts", "Romanian Deadlift"},
+		{"DB RDL", "Dumbbell Romanian Deadlift"},
+		{"Tricep Rope Pushdowns", "Cable Triceps Pushdown"},
+		{"Farmer's Carry", "Farmer's Walk"},
+		{"Cable Flyes", "Cable Crossover"},
+		{"Plank Hold", "Plank"},
+		// Equipment named in the query has to match
+		{"Kettlebell Bench Press", ""},
+		{"Zottman Curl", ""},
+		{"Underwater Basket Weaving", ""},
+	}
+	for _, tt := range tests {
+		exercise, err := catalog.Resolve(context.Background(), tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if exercise != nil {
+			got = exercise.Name
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogResolvePlan(t *testing.T) {
+	catalog := newTestCatalog(t)
+	bogus := uint(99999)
+	sessions := []models.WorkoutSession{{
+		Name: "Push",
+		Exercises: []models.Exercise{
+			{Name: "Incline DB Press"},
+			{Name: "Zottman Curl"},
+			{Name: "Chest Dips", CatalogID: &bogus},
+		},
+	}}
+
+	report, err := catalog.ResolvePlan(context.Background(), sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Resolved != 2 || len(report.Unknown) != 1 || report.Unknown[0].Name != "Zottman Curl" || report.Unknown[0].Session != "Push" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	exercises := sessions[0].Exercises
+	if exercises[0].CatalogID == nil || exercises[1].CatalogID != nil || exercises[2].CatalogID == nil || *exercises[2].CatalogID == bogus {
+		t.Errorf("unexpected catalog IDs: %v, %v, %v", exercises[0].CatalogID, exercises[1].CatalogID, exercises[2].CatalogID)
+	}
+	dip, err := catalog.Get(context.Background(), *exercises[2].CatalogID)
+	if err != nil || dip.Slug != "chest-dip" {
+		t.Errorf("Chest Dips resolved to %+v (%v)", dip, err)
+	}
+}
+
+func TestCatalogSearch(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	exercises, err := catalog.Search(context.Background(), repositories.ExerciseCatalogFilter{
+		Pattern:   models.PatternHorizontalPush,
+		Equipment: []string{models.EquipmentDumbbell, models.EquipmentBench},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exercises) == 0 {
+		t.Fatal("no exercises found")
+	}
+	for _, exercise := range exercises {
+		if exercise.MovementPattern != models.PatternHorizontalPush || !exercise.RequiresOnly([]string{"dumbbell", "bench"}) {
+			t.Errorf("%s does not match the filter", exercise.Name)
+		}
+	}
+}
+
+func TestSeedCatalogIsConsistent(t *testing.T) {
+	exercises, err := repositories.DefaultExerciseCatalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	known := func(values []string, value string) bool {
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+	slugs := make(map[string]bool)
+	for _, exercise := range exercises {
+		if exercise.Slug == "" || slugs[exercise.Slug] {
+			t.Errorf("missing or duplicate slug %q", exercise.Slug)
+		}
+		slugs[exercise.Slug] = true
+		if !known(models.MovementPatterns, exercise.MovementPattern) || !known(models.Difficulties, exercise.Difficulty) {
+			t.Errorf("%s: unknown pattern %q or difficulty %q", exercise.Slug, exercise.MovementPattern, exercise.Difficulty)
+		}
+		for _, muscle := range append(append([]string{}, exercise.PrimaryMuscles...), exercise.SecondaryMuscles...) {
+			if !known(models.MuscleGroups, muscle) {
+				t.Errorf("%s: unknown muscle %q", exercise.Slug, muscle)
+			}
+		}
+		for _, equipment := range exercise.Equipment {
+			if !known(models.EquipmentTypes, equipment) {
+				t.Errorf("%s: unknown equipment %q", exercise.Slug, equipment)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitState is the state of a circuit breaker
+type circuitState int
+
+const (
+	// circuitClosed lets every call through
+	circuitClosed circuitState = iota
+	// circuitOpen rejects calls until the cooldown elapses
+	circuitOpen
+	// circuitHalfOpen has let one probe through after the cooldown and rejects other
+	// calls until the probe reports back
+	circuitHalfOpen
+)
+
+// circuitBreaker stops calls to a provider after repeated failures.
+// Once the cooldown elapses exactly one call is let through as a probe;
+// a success closes the circuit again, a failure re-opens it.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     circuitState
+	failures  int
+	openUntil time.Time
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may be made. After the cooldown only the first caller is
+// allowed; it must report back with Success, Failure or Release.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if b.now().Before(b.openUntil) {
+			return false
+		}
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// Success closes the circuit
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = circuitClosed
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+// Failure records a failed call and opens the circuit once the threshold is reached, or
+// straight away when the call was the probe
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.state = circuitOpen
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// Release ends a call that says nothing about the provider's health, such as one the
+// caller cancelled. A released probe lets the next caller probe instead.
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+	}
+}
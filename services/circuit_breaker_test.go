@@ -0,0 +1,118 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker opening after 2 failures for a minute, whose clock
+// reads *now
+func newTestBreaker(now *time.Time) *circuitBreaker {
+	breaker := newCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return *now }
+	return breaker
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	breaker := newTestBreaker(&now)
+
+	breaker.Failure()
+	if !breaker.Allow() {
+		t.Fatal("opened below the threshold")
+	}
+	breaker.Failure()
+	if breaker.Allow() {
+		t.Fatal("still closed after reaching the threshold")
+	}
+
+	now = now.Add(59 * time.Second)
+	if breaker.Allow() {
+		t.Fatal("allowed a call during the cooldown")
+	}
+
+	// After the cooldown exactly one probe is let through
+	now = now.Add(time.Second)
+	if !breaker.Allow() {
+		t.Fatal("no probe after the cooldown")
+	}
+	if breaker.Allow() {
+		t.Fatal("a second call was let through while probing")
+	}
+
+	// A failed probe re-opens the circuit for a full cooldown
+	breaker.Failure()
+	now = now.Add(30 * time.Second)
+	if breaker.Allow() {
+		t.Fatal("allowed a call after the probe failed")
+	}
+	now = now.Add(30 * time.Second)
+	if !breaker.Allow() {
+		t.Fatal("no probe after the second cooldown")
+	}
+
+	// A successful probe closes it
+	breaker.Success()
+	for i := 0; i < 3; i++ {
+		if !breaker.Allow() {
+			t.Fatalf("call %d rejected after a successful probe", i+1)
+		}
+	}
+	breaker.Failure()
+	if !breaker.Allow() {
+		t.Error("the failure count was not reset by the successful probe")
+	}
+}
+
+func TestCircuitBreakerReleasedProbe(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	breaker := newTestBreaker(&now)
+	breaker.Failure()
+	breaker.Failure()
+	now = now.Add(time.Minute)
+
+	if !breaker.Allow() {
+		t.Fatal("no probe after the cooldown")
+	}
+	// The probe was cancelled, so the next caller probes instead
+	breaker.Release()
+	if !breaker.Allow() {
+		t.Fatal("no new probe after the first was released")
+	}
+	if breaker.Allow() {
+		t.Fatal("a second call was let through while probing")
+	}
+
+	// Releasing a closed breaker changes nothing
+	breaker.Success()
+	breaker.Release()
+	if !breaker.Allow() {
+		t.Error("Release opened a closed breaker")
+	}
+}
+
+func TestCircuitBreakerSingleProbeUnderContention(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	breaker := newTestBreaker(&now)
+	breaker.Failure()
+	breaker.Failure()
+	now = now.Add(time.Minute)
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if breaker.Allow() {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := allowed.Load(); n != 1 {
+		t.Errorf("%d callers were let through after the cooldown, want 1", n)
+	}
+}
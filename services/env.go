@@ -0,0 +1,63 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envOrDefault returns the environment variable value or the fallback when it is unset
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envInt parses an integer environment variable, falling back when it is unset or invalid
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// envFloat parses a floating point environment variable, falling back when it is unset
+// or invalid
+func envFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %g", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// envDuration parses a duration environment variable such as "90s" or "2m",
+// falling back when it is unset or invalid
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %s", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
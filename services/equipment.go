@@ -0,0 +1,507 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"fit-ai-api/models"
+)
+
+// equipmentSynonyms maps the ways users describe their equipment to the catalog
+// taxonomy. Keys are normalized phrases; a profile entry matches every phrase it contains,
+// so "dumbbells and a bench" yields both dumbbell and bench. Longer phrases are matched
+// first and consume their words, so "cable machine" doesn't also count as a machine.
+var equipmentSynonyms = map[string][]string{
+	"barbell":            {models.EquipmentBarbell},
+	"olympic bar":        {models.EquipmentBarbell},
+	"ez bar":             {models.EquipmentEZBar},
+	"ez curl bar":        {models.EquipmentEZBar},
+	"curl bar":           {models.EquipmentEZBar},
+	"dumbbell":           {models.EquipmentDumbbell},
+	"dumbell":            {models.EquipmentDumbbell},
+	"db":                 {models.EquipmentDumbbell},
+	"kettlebell":         {models.EquipmentKettlebell},
+	"kettle bell":        {models.EquipmentKettlebell},
+	"bench":              {models.EquipmentBench},
+	"squat rack":         {models.EquipmentSquatRack},
+	"power rack":         {models.EquipmentSquatRack},
+	"squat stand":        {models.EquipmentSquatRack},
+	"rack":               {models.EquipmentSquatRack},
+	"pull up bar":        {models.EquipmentPullUpBar},
+	"pullup bar":         {models.EquipmentPullUpBar},
+	"chin up bar":        {models.EquipmentPullUpBar},
+	"chinup bar":         {models.EquipmentPullUpBar},
+	"dip station":        {models.EquipmentDipStation},
+	"dip bar":            {models.EquipmentDipStation},
+	"parallel bar":       {models.EquipmentDipStation},
+	"cable":              {models.EquipmentCableMachine},
+	"cable machine":      {models.EquipmentCableMachine},
+	"cable station":      {models.EquipmentCableMachine},
+	"functional trainer": {models.EquipmentCableMachine},
+	"lat pulldown":       {models.EquipmentCableMachine},
+	"machine":            {models.EquipmentMachine},
+	"leg press":          {models.EquipmentMachine},
+	"smith machine":      {models.EquipmentMachine},
+	"resistance band":    {models.EquipmentResistanceBand},
+	"band":               {models.EquipmentResistanceBand},
+	"medicine ball":      {models.EquipmentMedicineBall},
+	"med ball":           {models.EquipmentMedicineBall},
+	"slam ball":          {models.EquipmentMedicineBall},
+	"treadmill":          {models.EquipmentTreadmill},
+	"exercise bike":      {models.EquipmentStationaryBike},
+	"stationary bike":    {models.EquipmentStationaryBike},
+	"spin bike":          {models.EquipmentStationaryBike},
+	"bike":               {models.EquipmentStationaryBike},
+	"rowing machine":     {models.EquipmentRowingMachine},
+	"rower":              {models.EquipmentRowingMachine},
+	"erg":                {models.EquipmentRowingMachine},
+	"jump rope":          {models.EquipmentJumpRope},
+	"skipping rope":      {models.EquipmentJumpRope},
+}
+
+// equipmentPhrases holds the keys of equipmentSynonyms, longest first
+var equipmentPhrases = func() []string {
+	phrases := make([]string, 0, len(equipmentSynonyms))
+	for phrase := range equipmentSynonyms {
+		phrases = append(phrases, phrase)
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if len(phrases[i]) != len(phrases[j]) {
+			return len(phrases[i]) > len(phrases[j])
+		}
+		return phrases[i] < phrases[j]
+	})
+	return phrases
+}()
+
+// fullGymEquipment are profile entries that stand for every kind of equipment. They
+// must match the whole entry, so "home gym" is not mistaken for a commercial gym.
+var fullGymEquipment = map[string]bool{
+	"gym": true, "full gym": true, "commercial gym": true, "gym access": true,
+	"full gym access": true, "fitness center": true, "all equipment": true,
+}
+
+// noEquipment are profile entries that state the user has no equipment at all
+var noEquipment = map[string]bool{
+	"none": true, "no equipment": true, "bodyweight": true, "bodyweight only": true,
+	"body weight": true, "nothing": true,
+}
+
+// equipmentNameWords infer the equipment of exercises the catalog doesn't know from the
+// words of their name
+var equipmentNameWords = map[string]string{
+	"barbell":    models.EquipmentBarbell,
+	"dumbbell":   models.EquipmentDumbbell,
+	"kettlebell": models.EquipmentKettlebell,
+	"cable":      models.EquipmentCableMachine,
+	"band":       models.EquipmentResistanceBand,
+	"machine":    models.EquipmentMachine,
+	"smith":      models.EquipmentMachine,
+	"ez":         models.EquipmentEZBar,
+	"treadmill":  models.EquipmentTreadmill,
+	"rower":      models.EquipmentRowingMachine,
+}
+
+// EquipmentReport lists the exercises of a generated plan that needed equipment the user
+// doesn't have
+type EquipmentReport struct {
+	// Available is the user's equipment mapped to the catalog taxonomy
+	Available []string `json:"available"`
+	// Unrecognized lists profile entries that matched no known equipment
+	Unrecognized  []string             `json:"unrecognized,omitempty"`
+	Substitutions []Substitution       `json:"substitutions,omitempty"`
+	Infeasible    []InfeasibleExercise `json:"infeasible,omitempty"`
+}
+
+// Substitution is an exercise that was replaced because of missing equipment
+type Substitution struct {
+	Session     string   `json:"session"`
+	Original    string   `json:"original"`
+	Replacement string   `json:"replacement"`
+	CatalogID   uint     `json:"catalogId"`
+	Missing     []string `json:"missing"`
+	Reason      string   `json:"reason"`
+}
+
+// InfeasibleExercise is an exercise needing missing equipment that had no replacement
+type InfeasibleExercise struct {
+	Session string   `json:"session"`
+	Name    string   `json:"name"`
+	Missing []string `json:"missing"`
+	Reason  string   `json:"reason"`
+}
+
+// Alternative is a catalog exercise that can replace another one
+type Alternative struct {
+	Exercise models.CatalogExercise `json:"exercise"`
+	// Similarity is the weighted overlap of the muscles both exercises work, from 0 to 1
+	Similarity float64 `json:"similarity"`
+}
+
+// AvailableEquipment maps the free-text equipment of a profile to the catalog taxonomy.
+// ok is false when the profile gives nothing to go by, because it lists no equipment or
+// none that is recognized; an explicit "none" or "bodyweight" yields an empty list.
+func AvailableEquipment(items []string) (available, unrecognized []string, ok bool) {
+	found := make(map[string]bool)
+	for _, item := range items {
+		normalized := normalizeEquipmentName(item)
+		switch {
+		case normalized == "":
+			continue
+		case fullGymEquipment[normalized]:
+			for _, equipment := range models.EquipmentTypes {
+				found[equipment] = true
+			}
+			ok = true
+			continue
+		case noEquipment[normalized]:
+			ok = true
+			continue
+		}
+
+		matched := false
+		padded := " " + normalized + " "
+		for _, phrase := range equipmentPhrases {
+			if !strings.Contains(padded, " "+phrase+" ") {
+				continue
+			}
+			for _, equipment := range equipmentSynonyms[phrase] {
+				found[equipment] = true
+			}
+			padded = strings.ReplaceAll(padded, " "+phrase+" ", "  ")
+			matched = true
+		}
+		if matched {
+			ok = true
+		} else {
+			unrecognized = append(unrecognized, item)
+		}
+	}
+
+	available = []string{}
+	for _, equipment := range models.EquipmentTypes {
+		if found[equipment] {
+			available = append(available, equipment)
+		}
+	}
+	return available, unrecognized, ok
+}
+
+// normalizeEquipmentName lowercases an equipment description, replaces punctuation with
+// spaces and reduces plurals to their singular
+func normalizeEquipmentName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for i, field := range fields {
+		fields[i] = singular(field)
+	}
+	return strings.Join(fields, " ")
+}
+
+// EnforceEquipment replaces the exercises of sessions that need equipment missing from
+// profile with the closest catalog exercise of the same movement pattern the user can do,
+// keeping their sets and reps. Exercises should already be resolved with ResolvePlan;
+// the requirements of unresolved ones are inferred from their names. It returns nil when
+// the profile doesn't say what equipment the user has.
+func (c *ExerciseCatalog) EnforceEquipment(ctx context.Context, sessions []models.WorkoutSession, profile models.UserProfile) (*EquipmentReport, error) {
+	available, unrecognized, ok := AvailableEquipment(profile.Equipment)
+	if !ok {
+		return nil, nil
+	}
+	entries, byID, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EquipmentReport{Available: available, Unrecognized: unrecognized}
+	for s := range sessions {
+		session := &sessions[s]
+		for e := range session.Exercises {
+			exercise := &session.Exercises[e]
+
+			var original *models.CatalogExercise
+			var required []string
+			if exercise.CatalogID != nil {
+				if i, ok := byID[*exercise.CatalogID]; ok {
+					original = &entries[i].exercise
+					required = original.Equipment
+				}
+			}
+			if original == nil {
+				required = inferEquipment(exercise.Name)
+			}
+			missing := missingEquipment(required, available)
+			if len(missing) == 0 {
+				continue
+			}
+
+			if original == nil {
+				report.Infeasible = append(report.Infeasible, InfeasibleExercise{
+					Session: session.Name,
+					Name:    exercise.Name,
+					Missing: missing,
+					Reason:  "the exercise is not in the catalog, so no alternative could be chosen",
+				})
+				continue
+			}
+
+			alternatives := rankAlternatives(entries, original, available, sessionCatalogIDs(session), profile.FitnessLevel)
+			if len(alternatives) == 0 {
+				report.Infeasible = append(report.Infeasible, InfeasibleExercise{
+					Session: session.Name,
+					Name:    exercise.Name,
+					Missing: missing,
+					Reason:  fmt.Sprintf("no %s exercise in the catalog fits the available equipment", patternLabel(original.MovementPattern)),
+				})
+				continue
+			}
+
+			replacement := alternatives[0].Exercise
+			substitution := Substitution{
+				Session:     session.Name,
+				Original:    exercise.Name,
+				Replacement: replacement.Name,
+				CatalogID:   replacement.ID,
+				Missing:     missing,
+				Reason: fmt.Sprintf("%s needs %s; %s is the closest %s exercise for the available equipment",
+					exercise.Name, strings.Join(taxonomyLabels(missing), " and "), replacement.Name, patternLabel(original.MovementPattern)),
+			}
+			id := replacement.ID
+			if applyReplacement(exercise, replacement.Name, replacement.Type, &id, !sameEquipment(required, replacement.Equipment), sessions) {
+				substitution.Reason += "; its weight starts at 0 and the load needs re-testing"
+			}
+			report.Substitutions = append(report.Substitutions, substitution)
+		}
+	}
+	return report, nil
+}
+
+// Enforce applies EnforceEquipment but never fails: a catalog that can't be loaded is
+// logged and the exercises are left as they are. It does nothing on a nil catalog.
+func (c *ExerciseCatalog) Enforce(ctx context.Context, sessions []models.WorkoutSession, profile models.UserProfile) *EquipmentReport {
+	if c == nil {
+		return nil
+	}
+	report, err := c.EnforceEquipment(ctx, sessions, profile)
+	if err != nil {
+		log.Printf("Warning: exercise equipment was not checked: %v", err)
+		return nil
+	}
+	return report
+}
+
+// rankAlternatives returns the catalog exercises with the movement pattern of original
+// that need only available equipment (any equipment when available is nil), most similar
+// first. Exercises in exclude are only offered when nothing else fits. Ties go to the
+// exercise of the same type (loaded or not), then to the difficulty closest to the
+// original, then to the one matching fitnessLevel.
+func rankAlternatives(entries []catalogEntry, original *models.CatalogExercise, available []string, exclude map[uint]bool, fitnessLevel string) []Alternative {
+	var preferred, excluded []Alternative
+	for i := range entries {
+		candidate := &entries[i].exercise
+		if candidate.ID == original.ID || candidate.MovementPattern != original.MovementPattern {
+			continue
+		}
+		if available != nil && !candidate.RequiresOnly(available) {
+			continue
+		}
+		similarity := muscleSimilarity(original, candidate)
+		if similarity == 0 {
+			continue
+		}
+		alternative := Alternative{Exercise: *candidate, Similarity: roundScore(similarity)}
+		if exclude[candidate.ID] {
+			excluded = append(excluded, alternative)
+		} else {
+			preferred = append(preferred, alternative)
+		}
+	}
+	if len(preferred) == 0 {
+		preferred = excluded
+	}
+
+	sort.SliceStable(preferred, func(i, j int) bool {
+		a, b := preferred[i], preferred[j]
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		if sa, sb := a.Exercise.Type == original.Type, b.Exercise.Type == original.Type; sa != sb {
+			return sa
+		}
+		da, db := difficultyDistance(original.Difficulty, a.Exercise.Difficulty), difficultyDistance(original.Difficulty, b.Exercise.Difficulty)
+		if da != db {
+			return da < db
+		}
+		return difficultyDistance(fitnessLevel, a.Exercise.Difficulty) < difficultyDistance(fitnessLevel, b.Exercise.Difficulty)
+	})
+	return preferred
+}
+
+// muscleSimilarity is the weighted Jaccard similarity of the muscles two exercises work,
+// counting primary muscles twice as much as secondary ones
+func muscleSimilarity(a, b *models.CatalogExercise) float64 {
+	weights := func(e *models.CatalogExercise) map[string]float64 {
+		w := make(map[string]float64)
+		for _, m := range e.SecondaryMuscles {
+			w[m] = 1
+		}
+		for _, m := range e.PrimaryMuscles {
+			w[m] = 2
+		}
+		return w
+	}
+	wa, wb := weights(a), weights(b)
+
+	var shared, total float64
+	for muscle, x := range wa {
+		y := wb[muscle]
+		shared += min(x, y)
+		total += max(x, y)
+	}
+	for muscle, y := range wb {
+		if _, ok := wa[muscle]; !ok {
+			total += y
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return shared / total
+}
+
+// difficultyDistance is how many levels apart two difficulties are; unknown levels count
+// as one level apart from everything
+func difficultyDistance(a, b string) int {
+	index := func(level string) int {
+		for i, d := range models.Difficulties {
+			if strings.EqualFold(level, d) {
+				return i
+			}
+		}
+		return -1
+	}
+	ia, ib := index(a), index(b)
+	if ia < 0 || ib < 0 {
+		return 1
+	}
+	if ia > ib {
+		return ia - ib
+	}
+	return ib - ia
+}
+
+// applyReplacement turns exercise into the exercise called name, of the given type and
+// catalog ID (nil for exercises the catalog doesn't know), keeping its ID, sets and reps.
+// The weight is kept as a starting point only when both exercises are loaded and use the
+// same equipment: an unloaded replacement gets no weight, a loaded one replacing a
+// bodyweight exercise starts at zero in the unit used elsewhere in the plan, and a loaded
+// one on different equipment (a barbell lift done with dumbbells) starts at zero in the
+// unit it had. It reports whether the replacement is loaded and its weight was reset, so
+// the load has to be found again.
+func applyReplacement(exercise *models.Exercise, name, exerciseType string, catalogID *uint, equipmentChanged bool, sessions []models.WorkoutSession) bool {
+	exercise.Name = name
+	exercise.CatalogID = catalogID
+	exercise.Type = exerciseType
+
+	switch {
+	case exerciseType != "weight":
+		exercise.Weight = models.WeightInfo{Value: 0, Unit: WeightUnitBodyweight}
+	case exercise.Weight.Unit == WeightUnitBodyweight || exercise.Weight.Unit == "":
+		exercise.Weight = models.WeightInfo{Value: 0, Unit: planWeightUnit(sessions)}
+		return true
+	case equipmentChanged:
+		exercise.Weight.Value = 0
+		return true
+	}
+	return false
+}
+
+// sameEquipment reports whether a and b list the same equipment, in any order
+func sameEquipment(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, equipment := range a {
+		counts[equipment]++
+	}
+	for _, equipment := range b {
+		if counts[equipment] == 0 {
+			return false
+		}
+		counts[equipment]--
+	}
+	return true
+}
+
+// planWeightUnit returns the unit of the first loaded exercise in sessions, or KG
+func planWeightUnit(sessions []models.WorkoutSession) string {
+	for _, session := range sessions {
+		for _, exercise := range session.Exercises {
+			if exercise.Weight.Unit == WeightUnitKilograms || exercise.Weight.Unit == WeightUnitPounds {
+				return exercise.Weight.Unit
+			}
+		}
+	}
+	return WeightUnitKilograms
+}
+
+// inferEquipment guesses the equipment an exercise needs from the words of its name
+func inferEquipment(name string) []string {
+	var required []string
+	seen := make(map[string]bool)
+	for _, token := range normalizeExerciseName(name).tokens {
+		if equipment, ok := equipmentNameWords[token]; ok && !seen[equipment] {
+			seen[equipment] = true
+			required = append(required, equipment)
+		}
+	}
+	return required
+}
+
+// missingEquipment returns the entries of required that are not in available
+func missingEquipment(required, available []string) []string {
+	var missing []string
+	for _, needed := range required {
+		found := false
+		for _, have := range available {
+			if have == needed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, needed)
+		}
+	}
+	return missing
+}
+
+// sessionCatalogIDs returns the catalog IDs of the exercises already in session
+func sessionCatalogIDs(session *models.WorkoutSession) map[uint]bool {
+	ids := make(map[uint]bool)
+	for _, exercise := range session.Exercises {
+		if exercise.CatalogID != nil {
+			ids[*exercise.CatalogID] = true
+		}
+	}
+	return ids
+}
+
+// patternLabel turns a movement pattern into words, e.g. "horizontal push"
+func patternLabel(pattern string) string {
+	return strings.ReplaceAll(pattern, "_", " ")
+}
+
+// taxonomyLabels turns taxonomy values into words, e.g. "squat rack"
+func taxonomyLabels(values []string) []string {
+	labels := make([]string, len(values))
+	for i, value := range values {
+		labels[i] = strings.ReplaceAll(value, "_", " ")
+	}
+	return labels
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fit-ai-api/models"
+)
+
+func TestAvailableEquipment(t *testing.T) {
+	tests := []struct {
+		items []string
+		want  []string
+		ok    bool
+	}{
+		{[]string{"Dumbbells", "adjustable bench"}, []string{"dumbbell", "bench"}, true},
+		{[]string{"barbell", "cable machine"}, []string{"barbell", "cable_machine"}, true},
+		{[]string{"Pull-up bar", "resistance bands"}, []string{"pull_up_bar", "resistance_band"}, true},
+		{[]string{"Power rack with barbell and plates"}, []string{"barbell", "squat_rack"}, true},
+		{[]string{"Rowing machine"}, []string{"rowing_machine"}, true},
+		{[]string{"none"}, []string{}, true},
+		{[]string{"Full gym"}, models.EquipmentTypes, true},
+		{[]string{"yoga mat"}, []string{}, false},
+		{nil, []string{}, false},
+	}
+	for _, tt := range tests {
+		got, _, ok := AvailableEquipment(tt.items)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AvailableEquipment(%q) = %v, %t; want %v, %t", tt.items, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEnforceEquipment(t *testing.T) {
+	catalog := newTestCatalog(t)
+	plan := resolvedTestPlan(t, catalog)
+	sessions := plan.Sessions
+	profile := models.UserProfile{FitnessLevel: "intermediate", Equipment: []string{"dumbbells", "bench", "pull-up bar"}}
+	before := sessions[0].Exercises[0]
+
+	report, err := catalog.EnforceEquipment(context.Background(), sessions, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report == nil || len(report.Substitutions) == 0 {
+		t.Fatalf("expected substitutions, got %+v", report)
+	}
+
+	// Every exercise left in the plan can be done with dumbbells, a bench and a bar
+	for _, session := range sessions {
+		for _, exercise := range session.Exercises {
+			if exercise.CatalogID == nil {
+				continue
+			}
+			entry, err := catalog.Get(context.Background(), *exercise.CatalogID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !entry.RequiresOnly(report.Available) && !infeasible(report, exercise.Name) {
+				t.Errorf("%s still needs %v", exercise.Name, entry.Equipment)
+			}
+		}
+	}
+
+	after := sessions[0].Exercises[0]
+	if after.Name != "Dumbbell Bench Press" {
+		t.Errorf("Barbell Bench Press was replaced by %q, want Dumbbell Bench Press", after.Name)
+	}
+	if after.ID != before.ID || after.Sets != before.Sets || after.Reps != before.Reps {
+		t.Errorf("replacement changed the prescription: %+v -> %+v", before, after)
+	}
+	// A barbell load says nothing about the dumbbells, so it has to be found again
+	if after.Weight != (models.WeightInfo{Value: 0, Unit: before.Weight.Unit}) {
+		t.Errorf("replacement weight = %+v, want 0 %s", after.Weight, before.Weight.Unit)
+	}
+	if reason := report.Substitutions[0].Reason; !strings.Contains(reason, "load needs re-testing") {
+		t.Errorf("substitution reason %q does not mention re-testing the load", reason)
+	}
+	if violations := ValidateWorkoutPlan(plan); len(violations) > 0 {
+		t.Errorf("plan is invalid after substitution: %v", violations)
+	}
+}
+
+func TestEnforceEquipmentWithoutEquipmentInfo(t *testing.T) {
+	catalog := newTestCatalog(t)
+	sessions := []models.WorkoutSession{{Name: "Push", Exercises: []models.Exercise{{Name: "Barbell Bench Press"}}}}
+	report, err := catalog.EnforceEquipment(context.Background(), sessions, models.UserProfile{})
+	if err != nil || report != nil {
+		t.Fatalf("got %+v, %v; want no check without equipment info", report, err)
+	}
+	if sessions[0].Exercises[0].Name != "Barbell Bench Press" {
+		t.Error("exercise was changed")
+	}
+}
+
+func TestApplyReplacement(t *testing.T) {
+	barbell := models.Exercise{ID: 3, Name: "Barbell Bench Press", Sets: 4, Reps: 8, Weight: models.WeightInfo{Value: 60, Unit: WeightUnitPounds}, Type: "weight"}
+	pushUp := models.Exercise{ID: 3, Name: "Push-Up", Sets: 3, Reps: 12, Weight: models.WeightInfo{Unit: WeightUnitBodyweight}, Type: "bodyweight"}
+	sessions := []models.WorkoutSession{{Exercises: []models.Exercise{barbell}}}
+
+	tests := []struct {
+		name             string
+		exercise         models.Exercise
+		replacementType  string
+		equipmentChanged bool
+		want             models.WeightInfo
+		wantReset        bool
+	}{
+		{"same equipment keeps the load", barbell, "weight", false, barbell.Weight, false},
+		{"different equipment resets the load", barbell, "weight", true, models.WeightInfo{Value: 0, Unit: WeightUnitPounds}, true},
+		{"unloaded replacement", barbell, "bodyweight", true, models.WeightInfo{Value: 0, Unit: WeightUnitBodyweight}, false},
+		{"loaded replacement of a bodyweight exercise", pushUp, "weight", true, models.WeightInfo{Value: 0, Unit: WeightUnitPounds}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exercise := tt.exercise
+			reset := applyReplacement(&exercise, "Replacement", tt.replacementType, nil, tt.equipmentChanged, sessions)
+			if exercise.Weight != tt.want || reset != tt.wantReset {
+				t.Errorf("weight = %+v, reset = %v; want %+v, %v", exercise.Weight, reset, tt.want, tt.wantReset)
+			}
+			if exercise.ID != tt.exercise.ID || exercise.Sets != tt.exercise.Sets || exercise.Reps != tt.exercise.Reps || exercise.Type != tt.replacementType {
+				t.Errorf("exercise = %+v", exercise)
+			}
+		})
+	}
+}
+
+func infeasible(report *EquipmentReport, name string) bool {
+	for _, exercise := range report.Infeasible {
+		if exercise.Name == name {
+			return true
+		}
+	}
+	return false
+}
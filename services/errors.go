@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoProviderAvailable is returned when every configured provider is unavailable,
+// for example because all circuit breakers are open
+var ErrNoProviderAvailable = errors.New("no AI provider available")
+
+// ErrProviderNotConfigured is returned by a provider that is missing required configuration
+// such as an API key; the fallback chain skips such providers
+var ErrProviderNotConfigured = errors.New("AI provider not configured")
+
+// ErrorKind classifies provider failures so callers can react to them
+type ErrorKind string
+
+const (
+	ErrorKindRateLimit      ErrorKind = "rate_limit"
+	ErrorKindAuth           ErrorKind = "auth"
+	ErrorKindQuota          ErrorKind = "quota"
+	ErrorKindServer         ErrorKind = "server"
+	ErrorKindTimeout        ErrorKind = "timeout"
+	ErrorKindTransport      ErrorKind = "transport"
+	ErrorKindMalformed      ErrorKind = "malformed_output"
+	ErrorKindInvalidRequest ErrorKind = "invalid_request"
+)
+
+// ProviderError describes a failed call to an AI provider
+type ProviderError struct {
+	Provider   AIProvider
+	Kind       ErrorKind
+	StatusCode int           // HTTP status code, 0 when the request never got a response
+	RetryAfter time.Duration // delay requested by the provider, 0 when not given
+	Message    string
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the failure is transient and the same provider may succeed
+// on another attempt
+func (e *ProviderError) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindRateLimit, ErrorKindServer, ErrorKindTimeout, ErrorKindTransport:
+		return true
+	default:
+		return false
+	}
+}
+
+// shouldFallback reports whether another provider should be tried after err.
+// An exhausted quota is not worth retrying but another provider may still have budget.
+func shouldFallback(err error) bool {
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	return providerErr.Retryable() || providerErr.Kind == ErrorKindQuota
+}
+
+// newStatusError classifies a non-200 response from an OpenAI-compatible API
+func newStatusError(provider AIProvider, label string, resp *http.Response, body []byte) *ProviderError {
+	message, code := parseErrorBody(body)
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+
+	err := &ProviderError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Message:    fmt.Sprintf("%s API error: %s - %s", label, resp.Status, message),
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		err.Kind = ErrorKindAuth
+	case resp.StatusCode == http.StatusPaymentRequired:
+		err.Kind = ErrorKindQuota
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// OpenAI reports an exhausted balance as a 429 with code insufficient_quota
+		if strings.Contains(code, "quota") {
+			err.Kind = ErrorKindQuota
+		} else {
+			err.Kind = ErrorKindRateLimit
+		}
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusGatewayTimeout:
+		err.Kind = ErrorKindTimeout
+	case resp.StatusCode >= 500:
+		err.Kind = ErrorKindServer
+	default:
+		err.Kind = ErrorKindInvalidRequest
+	}
+
+	return err
+}
+
+// newTransportError classifies a request that failed without a response
+func newTransportError(provider AIProvider, label string, err error) *ProviderError {
+	kind := ErrorKindTransport
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		kind = ErrorKindTimeout
+	}
+
+	return &ProviderError{
+		Provider: provider,
+		Kind:     kind,
+		Message:  fmt.Sprintf("%s API request failed", label),
+		Err:      err,
+	}
+}
+
+// parseErrorBody extracts the message and code from an OpenAI-style error body
+func parseErrorBody(body []byte) (message, code string) {
+	var payload struct {
+		Error struct {
+			Message string          `json:"message"`
+			Type    string          `json:"type"`
+			Code    json.RawMessage `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", ""
+	}
+
+	code = strings.Trim(string(payload.Error.Code), `"`)
+	if code == "" || code == "null" {
+		code = payload.Error.Type
+	}
+	return payload.Error.Message, code
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -2,41 +2,63 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// ErrDocumentNotFound is returned when a Firestore document does not exist
+var ErrDocumentNotFound = errors.New("document not found")
+
+// emulatorProjectID is used with the Firestore emulator when no project is configured;
+// the demo- prefix keeps the emulator from reaching out to real Google services
+const emulatorProjectID = "demo-fit-ai"
+
 type FirebaseService struct {
-	client *firestore.Client
+	client  *firestore.Client
+	timeout time.Duration
 }
 
+// NewFirebaseService connects to Firestore. When FIRESTORE_EMULATOR_HOST is set it connects
+// to the emulator without credentials, using GOOGLE_CLOUD_PROJECT or emulatorProjectID.
 func NewFirebaseService() (*FirebaseService, error) {
 	ctx := context.Background()
 
-	// Check if we have a service account key file
-	serviceAccountKey := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
-	var opt option.ClientOption
+	var opts []option.ClientOption
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
 
-	if serviceAccountKey != "" {
-		// Use service account key file
-		opt = option.WithCredentialsFile(serviceAccountKey)
+	if emulator := os.Getenv("FIRESTORE_EMULATOR_HOST"); emulator != "" {
+		// The client library talks to the emulator itself and needs no credentials
+		if projectID == "" {
+			projectID = emulatorProjectID
+		}
+		log.Printf("Using the Firestore emulator at %s (project %s)", emulator, projectID)
 	} else {
-		// Use default credentials (for local development)
-		opt = option.WithCredentialsFile("serviceAccountKey.json")
-	}
-
-	// Get project ID from environment or use default
-	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
-	if projectID == "" {
-		projectID = "your-project-id" // You'll need to set this
-		log.Println("Warning: GOOGLE_CLOUD_PROJECT not set, using default project ID")
+		// Check if we have a service account key file
+		serviceAccountKey := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+		if serviceAccountKey != "" {
+			// Use service account key file
+			opts = append(opts, option.WithCredentialsFile(serviceAccountKey))
+		} else {
+			// Use default credentials (for local development)
+			opts = append(opts, option.WithCredentialsFile("serviceAccountKey.json"))
+		}
+
+		// Get project ID from environment or use default
+		if projectID == "" {
+			projectID = "your-project-id" // You'll need to set this
+			log.Println("Warning: GOOGLE_CLOUD_PROJECT not set, using default project ID")
+		}
 	}
 
 	// Initialize Firestore client directly
-	client, err := firestore.NewClient(ctx, projectID, opt)
+	client, err := firestore.NewClient(ctx, projectID, opts...)
 	if err != nil {
 		log.Printf("Error initializing Firestore client: %v", err)
 		return nil, err
@@ -44,32 +66,58 @@ func NewFirebaseService() (*FirebaseService, error) {
 
 	log.Println("Firestore client initialized successfully")
 	return &FirebaseService{
-		client: client,
+		client:  client,
+		timeout: envDuration("FIRESTORE_TIMEOUT", 10*time.Second),
 	}, nil
 }
 
-// GetDocumentByID retrieves a document from Firestore by ID
-func (fs *FirebaseService) GetDocumentByID(collection, docID string) (map[string]interface{}, error) {
-	ctx := context.Background()
+// GetDocumentByID retrieves a document from a Firestore collection by ID
+func (fs *FirebaseService) GetDocumentByID(ctx context.Context, collection, docID string) (map[string]interface{}, error) {
+	ctx, cancel := fs.withTimeout(ctx)
+	defer cancel()
 
 	doc, err := fs.client.Collection(collection).Doc(docID).Get(ctx)
 	if err != nil {
-		return nil, err
+		return nil, documentError(ctx, err)
 	}
 
 	return doc.Data(), nil
 }
 
-// GetDocumentByIDFromCollection retrieves a document from Firestore by ID with custom collection
-func (fs *FirebaseService) GetDocumentByIDFromCollection(collection, docID string) (map[string]interface{}, error) {
-	ctx := context.Background()
+// withTimeout applies the FIRESTORE_TIMEOUT deadline to a single operation
+func (fs *FirebaseService) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if fs.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, fs.timeout)
+}
 
-	doc, err := fs.client.Collection(collection).Doc(docID).Get(ctx)
-	if err != nil {
-		return nil, err
+// documentError reports a missing document as ErrDocumentNotFound and cancellation as the
+// context error
+func documentError(ctx context.Context, err error) error {
+	if status.Code(err) == codes.NotFound {
+		return ErrDocumentNotFound
 	}
+	return contextError(ctx, err)
+}
 
-	return doc.Data(), nil
+// contextError reports cancellation and deadline errors as the plain context error
+// instead of the gRPC status Firestore wraps them in
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// Client returns the underlying Firestore client
+func (fs *FirebaseService) Client() *firestore.Client {
+	return fs.client
+}
+
+// Timeout returns the FIRESTORE_TIMEOUT deadline applied to each operation
+func (fs *FirebaseService) Timeout() time.Duration {
+	return fs.timeout
 }
 
 // Close closes the Firestore client
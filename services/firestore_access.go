@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrCollectionNotAllowed is returned for collections the passthrough endpoints don't expose
+var ErrCollectionNotAllowed = errors.New("collection is not readable through the API")
+
+// DefaultFirestoreCollections exposes the profile fields of the users collection that the
+// app needs, leaving out personal details such as date of birth, gender and location
+const DefaultFirestoreCollections = "users:uid,displayName,fullName,fitnessLevel,activityLevel,goals,equipment,height,weight,preferences,stats,createdAt,updatedAt"
+
+// ownerField is the document field compared to the caller's UID
+const ownerField = "uid"
+
+// FirestoreCollection is a collection readable through the Firestore passthrough endpoints
+type FirestoreCollection struct {
+	Name string
+	// Fields lists the top-level fields returned; nil returns every field
+	Fields []string
+}
+
+// FirestoreAllowlist is the set of collections readable through the passthrough endpoints,
+// by name
+type FirestoreAllowlist map[string]FirestoreCollection
+
+// ParseFirestoreAllowlist parses a spec of semicolon separated collections, each followed by
+// a colon and either a comma separated list of the fields to return or * for all fields,
+// e.g. "users:uid,displayName;badges:*"
+func ParseFirestoreAllowlist(spec string) (FirestoreAllowlist, error) {
+	allowlist := FirestoreAllowlist{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, fieldList, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid collection %q: expected name:field,field or name:*", entry)
+		}
+		if _, dup := allowlist[name]; dup {
+			return nil, fmt.Errorf("collection %q is listed twice", name)
+		}
+
+		collection := FirestoreCollection{Name: name}
+		if fieldList = strings.TrimSpace(fieldList); fieldList != "*" {
+			for _, field := range strings.Split(fieldList, ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					collection.Fields = append(collection.Fields, field)
+				}
+			}
+			if len(collection.Fields) == 0 {
+				return nil, fmt.Errorf("collection %q has no fields; use * to return every field", name)
+			}
+		}
+		allowlist[name] = collection
+	}
+	return allowlist, nil
+}
+
+// FirestoreAllowlistFromEnv reads FIRESTORE_COLLECTIONS, defaulting to
+// DefaultFirestoreCollections. Set it to "none" to expose no collections.
+func FirestoreAllowlistFromEnv() (FirestoreAllowlist, error) {
+	spec := strings.TrimSpace(os.Getenv("FIRESTORE_COLLECTIONS"))
+	switch {
+	case spec == "":
+		spec = DefaultFirestoreCollections
+	case strings.EqualFold(spec, "none"):
+		return FirestoreAllowlist{}, nil
+	}
+	return ParseFirestoreAllowlist(spec)
+}
+
+// Names returns the allowed collection names in order
+func (a FirestoreAllowlist) Names() []string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Collection returns the rules of an allowed collection, or ErrCollectionNotAllowed
+func (a FirestoreAllowlist) Collection(name string) (FirestoreCollection, error) {
+	collection, ok := a[name]
+	if !ok {
+		return FirestoreCollection{}, ErrCollectionNotAllowed
+	}
+	return collection, nil
+}
+
+// Owner returns the UID of the user a document belongs to, or "" when it has none
+func (c FirestoreCollection) Owner(data map[string]interface{}) string {
+	owner, _ := data[ownerField].(string)
+	return owner
+}
+
+// Project returns the fields of data the collection exposes
+func (c FirestoreCollection) Project(data map[string]interface{}) map[string]interface{} {
+	if c.Fields == nil {
+		return data
+	}
+	projected := make(map[string]interface{}, len(c.Fields))
+	for _, field := range c.Fields {
+		if value, ok := data[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
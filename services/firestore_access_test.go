@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFirestoreAllowlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    FirestoreAllowlist
+		wantErr string
+	}{
+		{"fields and wildcard", " users: uid , displayName ;badges:*", FirestoreAllowlist{
+			"users":  {Name: "users", Fields: []string{"uid", "displayName"}},
+			"badges": {Name: "badges"},
+		}, ""},
+		{"empty entries are skipped", ";users:uid;;", FirestoreAllowlist{"users": {Name: "users", Fields: []string{"uid"}}}, ""},
+		{"empty spec", "", FirestoreAllowlist{}, ""},
+		{"duplicate collection", "users:uid;users:*", nil, `collection "users" is listed twice`},
+		{"missing colon", "users", nil, `invalid collection "users"`},
+		{"missing name", ":uid", nil, `invalid collection ":uid"`},
+		{"nested path", "users/abc/workouts:*", nil, `invalid collection "users/abc/workouts:*"`},
+		{"no fields", "users: , ", nil, `collection "users" has no fields`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFirestoreAllowlist(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allowlist = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirestoreAllowlistFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		want    []string
+		wantErr bool
+	}{
+		{"default", "", []string{"users"}, false},
+		{"none", " None ", []string{}, false},
+		{"custom", "badges:*;users:uid", []string{"badges", "users"}, false},
+		{"invalid", "users", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FIRESTORE_COLLECTIONS", tt.env)
+			allowlist, err := FirestoreAllowlistFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(allowlist.Names(), tt.want) {
+				t.Errorf("names = %v, want %v", allowlist.Names(), tt.want)
+			}
+		})
+	}
+
+	// The default leaves out personal details
+	t.Setenv("FIRESTORE_COLLECTIONS", "")
+	allowlist, _ := FirestoreAllowlistFromEnv()
+	users, err := allowlist.Collection("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range users.Fields {
+		if field == "dateOfBirth" || field == "gender" || field == "location" {
+			t.Errorf("default users collection exposes %s", field)
+		}
+	}
+	if _, err := allowlist.Collection("workouts"); !errors.Is(err, ErrCollectionNotAllowed) {
+		t.Errorf("Collection(workouts) error = %v, want ErrCollectionNotAllowed", err)
+	}
+}
+
+func TestFirestoreCollectionProject(t *testing.T) {
+	data := map[string]interface{}{"uid": "user-1", "displayName": "Sam", "dateOfBirth": "1990-01-01"}
+
+	fields := FirestoreCollection{Name: "users", Fields: []string{"uid", "displayName", "stats"}}
+	want := map[string]interface{}{"uid": "user-1", "displayName": "Sam"}
+	if got := fields.Project(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Project = %v, want %v", got, want)
+	}
+
+	all := FirestoreCollection{Name: "users"}
+	if got := all.Project(data); !reflect.DeepEqual(got, data) {
+		t.Errorf("Project with * = %v, want every field", got)
+	}
+
+	if owner := fields.Owner(data); owner != "user-1" {
+		t.Errorf("Owner = %q, want user-1", owner)
+	}
+	if owner := fields.Owner(map[string]interface{}{"uid": 42}); owner != "" {
+		t.Errorf("Owner of a non-string uid = %q, want none", owner)
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"fit-ai-api/llmstub"
+	"fit-ai-api/models"
+)
+
+// testPlan returns the push, pull and legs plan of the openai_plan fixture, so tests that
+// only need a plan don't have to go through the model
+func testPlan() *models.WorkoutPlan {
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(llmstub.MustFixture("openai_plan").Body, &completion); err != nil || len(completion.Choices) == 0 {
+		panic(fmt.Sprintf("openai_plan fixture: no completion (%v)", err))
+	}
+
+	var plan models.WorkoutPlan
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &plan); err != nil {
+		panic(fmt.Sprintf("openai_plan fixture: %v", err))
+	}
+	return &plan
+}
+
+// resolvedTestPlan returns testPlan with its exercises linked to the catalog
+func resolvedTestPlan(t *testing.T, catalog *ExerciseCatalog) *models.WorkoutPlan {
+	t.Helper()
+	plan := testPlan()
+	if _, err := catalog.ResolvePlan(context.Background(), plan.Sessions); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+var (
+	// ErrJobNotFound is returned when a job ID does not exist
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobQueueFull is returned when the worker pool cannot accept more work
+	ErrJobQueueFull = errors.New("job queue is full")
+	// ErrJobAttemptsExceeded fails a job that was interrupted while running too many times,
+	// such as one that crashes the process
+	ErrJobAttemptsExceeded = errors.New("job exceeded its maximum attempts")
+)
+
+// JobHandler performs a job and returns its JSON-encodable result
+type JobHandler func(ctx context.Context, job *models.Job) (interface{}, error)
+
+// JobService runs background jobs on a bounded worker pool. Jobs are stored before they
+// are queued, so work that was queued or running when the process stopped is picked up
+// again by Start.
+type JobService struct {
+	jobs        repositories.JobRepository
+	handlers    map[models.JobType]JobHandler
+	queue       chan string
+	workers     int
+	timeout     time.Duration
+	maxAttempts int
+	once        sync.Once
+	running     sync.WaitGroup
+}
+
+// NewJobService creates a job service storing jobs in jobs. AI_JOB_WORKERS limits how
+// many jobs run at once (protecting provider quotas), AI_JOB_QUEUE_SIZE how many may wait,
+// AI_JOB_TIMEOUT how long a single job may take and AI_JOB_MAX_ATTEMPTS how many times a
+// job may be started before it is failed.
+func NewJobService(jobs repositories.JobRepository) *JobService {
+	workers := envInt("AI_JOB_WORKERS", 2)
+	if workers < 1 {
+		workers = 1
+	}
+	queueSize := envInt("AI_JOB_QUEUE_SIZE", 100)
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	maxAttempts := envInt("AI_JOB_MAX_ATTEMPTS", 3)
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &JobService{
+		jobs:        jobs,
+		handlers:    make(map[models.JobType]JobHandler),
+		queue:       make(chan string, queueSize),
+		workers:     workers,
+		timeout:     envDuration("AI_JOB_TIMEOUT", 5*time.Minute),
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Handle registers the handler for a job type; call it before Start
+func (s *JobService) Handle(jobType models.JobType, handler JobHandler) {
+	s.handlers[jobType] = handler
+}
+
+// Start launches the workers and re-queues jobs left unfinished by a previous run.
+// The workers stop when ctx is cancelled; Wait blocks until they have.
+func (s *JobService) Start(ctx context.Context) error {
+	pending, err := s.jobs.ListUnfinished(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pending jobs: %w", err)
+	}
+
+	s.once.Do(func() {
+		for i := 0; i < s.workers; i++ {
+			s.running.Add(1)
+			go func() {
+				defer s.running.Done()
+				s.worker(ctx)
+			}()
+		}
+	})
+
+	if len(pending) > 0 {
+		log.Printf("Resuming %d unfinished background jobs", len(pending))
+		// Feed from a goroutine so a backlog larger than the queue doesn't block startup
+		go func() {
+			for _, job := range pending {
+				select {
+				case s.queue <- job.ID:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Wait blocks until the workers have stopped after the context passed to Start was
+// cancelled. Jobs interrupted by the shutdown are queued again for the next Start.
+func (s *JobService) Wait() {
+	s.running.Wait()
+}
+
+// Enqueue stores a new job and hands it to the worker pool. payload is encoded as JSON
+// and may be nil.
+func (s *JobService) Enqueue(ctx context.Context, jobType models.JobType, userID string, payload interface{}) (*models.Job, error) {
+	if _, ok := s.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("no handler registered for job type %s", jobType)
+	}
+
+	var data models.JSON
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job payload: %w", err)
+		}
+		data = encoded
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		ID:      id,
+		Type:    jobType,
+		UserID:  userID,
+		Status:  models.JobStatusQueued,
+		Payload: data,
+	}
+	if err := s.jobs.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.queue <- job.ID:
+		return job, nil
+	default:
+		s.finish(context.Background(), job, nil, ErrJobQueueFull)
+		return nil, ErrJobQueueFull
+	}
+}
+
+// Get returns a job by ID
+func (s *JobService) Get(ctx context.Context, id string) (*models.Job, error) {
+	job, err := s.jobs.Get(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// worker processes queued job IDs until ctx is cancelled
+func (s *JobService) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			s.process(ctx, id)
+		}
+	}
+}
+
+// process runs a single job and records its outcome
+func (s *JobService) process(ctx context.Context, id string) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		log.Printf("Failed to load job %s: %v", id, err)
+		return
+	}
+	if job.Status == models.JobStatusSucceeded || job.Status == models.JobStatusFailed {
+		return
+	}
+
+	handler, ok := s.handlers[job.Type]
+	if !ok {
+		s.finish(ctx, job, nil, fmt.Errorf("no handler registered for job type %s", job.Type))
+		return
+	}
+	// Every earlier attempt was cut off while running, e.g. by a crash
+	if job.Attempts >= s.maxAttempts {
+		s.finish(ctx, job, nil, fmt.Errorf("%w (%d)", ErrJobAttemptsExceeded, s.maxAttempts))
+		return
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusRunning
+	job.StartedAt = &now
+	job.Attempts++
+	if err := s.jobs.Save(ctx, job); err != nil {
+		log.Printf("Failed to mark job %s as running: %v", job.ID, err)
+		return
+	}
+
+	jobCtx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	result, err := handler(jobCtx, job)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down: queue the job again for the next Start without counting the
+		// attempt, since the job did not fail
+		job.Status = models.JobStatusQueued
+		job.StartedAt = nil
+		job.Attempts--
+		if err := s.jobs.Save(context.WithoutCancel(ctx), job); err != nil {
+			log.Printf("Failed to requeue job %s: %v", job.ID, err)
+		}
+		return
+	}
+	s.finish(ctx, job, result, err)
+}
+
+// finish stores the result or error of a job
+func (s *JobService) finish(ctx context.Context, job *models.Job, result interface{}, jobErr error) {
+	now := time.Now()
+	job.FinishedAt = &now
+
+	if jobErr != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = jobErr.Error()
+		job.ErrorCode = ErrorCode(jobErr)
+		log.Printf("Job %s (%s) failed: %v", job.ID, job.Type, jobErr)
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			job.Status = models.JobStatusFailed
+			job.Error = "failed to encode job result: " + err.Error()
+			job.ErrorCode = "internal"
+		} else {
+			job.Status = models.JobStatusSucceeded
+			job.Result = data
+		}
+	}
+
+	if err := s.jobs.Save(context.WithoutCancel(ctx), job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+	}
+}
+
+// ErrorCode returns the short machine-readable code for a generation error, matching
+// the codes used in HTTP error responses
+func ErrorCode(err error) string {
+	var providerErr *ProviderError
+	var validationErr *PlanValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return "invalid_plan"
+	case errors.As(err, &providerErr):
+		return string(providerErr.Kind)
+	case errors.Is(err, repositories.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrNoProviderAvailable), errors.Is(err, ErrProviderNotConfigured), errors.Is(err, ErrJobQueueFull):
+		return "unavailable"
+	case errors.Is(err, ErrJobAttemptsExceeded):
+		return "attempts_exceeded"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	default:
+		return "internal"
+	}
+}
+
+// newJobID returns a random 128-bit hex identifier
+func newJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
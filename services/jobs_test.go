@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// newTestJobService creates a job service on an in-memory repository with workers
+// workers, a queue of queueSize and jobs started at most maxAttempts times
+func newTestJobService(t *testing.T, workers, queueSize, maxAttempts int) (*JobService, *repositories.MemoryJobRepository) {
+	t.Helper()
+	t.Setenv("AI_JOB_WORKERS", strconv.Itoa(workers))
+	t.Setenv("AI_JOB_QUEUE_SIZE", strconv.Itoa(queueSize))
+	t.Setenv("AI_JOB_MAX_ATTEMPTS", strconv.Itoa(maxAttempts))
+	t.Setenv("AI_JOB_TIMEOUT", "5s")
+	repo := repositories.NewMemoryJobRepository()
+	return NewJobService(repo), repo
+}
+
+// startJobs starts the workers and stops them when the test ends
+func startJobs(t *testing.T, jobs *JobService) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := jobs.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		jobs.Wait()
+	})
+	return cancel
+}
+
+// waitForStatus polls a job until it reaches status
+func waitForStatus(t *testing.T, jobs *JobService, id string, status models.JobStatus) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := jobs.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, job.Status, status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobServiceTransitions(t *testing.T) {
+	jobs, _ := newTestJobService(t, 1, 10, 3)
+	release := make(chan struct{})
+	// Each handler reports the job as stored while it runs
+	running := make(chan *models.Job, 1)
+	jobs.Handle(models.JobTypePlanGeneration, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		stored, _ := jobs.Get(ctx, job.ID)
+		running <- stored
+		<-release
+		if job.UserID == "user-2" {
+			return nil, &ProviderError{Provider: OpenAI, Kind: ErrorKindRateLimit, StatusCode: 429, Message: "slow down"}
+		}
+		return map[string]string{"plan": "ok"}, nil
+	})
+
+	job, err := jobs.Enqueue(context.Background(), models.JobTypePlanGeneration, "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.JobStatusQueued || job.Attempts != 0 {
+		t.Fatalf("enqueued job = %+v, want queued", job)
+	}
+	startJobs(t, jobs)
+
+	if stored := <-running; stored == nil || stored.Status != models.JobStatusRunning || stored.Attempts != 1 || stored.StartedAt == nil {
+		t.Errorf("running job = %+v", stored)
+	}
+	release <- struct{}{}
+	succeeded := waitForStatus(t, jobs, job.ID, models.JobStatusSucceeded)
+	if string(succeeded.Result) != `{"plan":"ok"}` || succeeded.FinishedAt == nil || succeeded.Error != "" {
+		t.Errorf("succeeded job = %+v", succeeded)
+	}
+
+	job, err = jobs.Enqueue(context.Background(), models.JobTypePlanGeneration, "user-2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-running
+	release <- struct{}{}
+	failed := waitForStatus(t, jobs, job.ID, models.JobStatusFailed)
+	if failed.ErrorCode != string(ErrorKindRateLimit) || failed.Error == "" || failed.Result != nil {
+		t.Errorf("failed job = %+v", failed)
+	}
+
+	if _, err := jobs.Get(context.Background(), "missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrJobNotFound", err)
+	}
+	if _, err := jobs.Enqueue(context.Background(), models.JobTypePlanSuggestion, "user-1", nil); err == nil {
+		t.Error("Enqueue accepted a job type without a handler")
+	}
+}
+
+func TestJobServiceBoundsConcurrency(t *testing.T) {
+	jobs, _ := newTestJobService(t, 2, 10, 3)
+	var current, peak atomic.Int32
+	release := make(chan struct{})
+	jobs.Handle(models.JobTypePlanGeneration, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		<-release
+		return nil, nil
+	})
+	startJobs(t, jobs)
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		job, err := jobs.Enqueue(context.Background(), models.JobTypePlanGeneration, "user-1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.ID)
+	}
+	for current.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	for _, id := range ids {
+		waitForStatus(t, jobs, id, models.JobStatusSucceeded)
+	}
+	if n := peak.Load(); n != 2 {
+		t.Errorf("%d jobs ran at once, want 2", n)
+	}
+}
+
+func TestJobServiceQueueFull(t *testing.T) {
+	jobs, repo := newTestJobService(t, 1, 1, 3)
+	jobs.Handle(models.JobTypePlanGeneration, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		return nil, nil
+	})
+
+	// The workers aren't started, so the second job finds the queue full
+	if _, err := jobs.Enqueue(context.Background(), models.JobTypePlanGeneration, "user-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jobs.Enqueue(context.Background(), models.JobTypePlanGeneration, "user-1", nil); !errors.Is(err, ErrJobQueueFull) {
+		t.Fatalf("error = %v, want ErrJobQueueFull", err)
+	}
+	unfinished, _ := repo.ListUnfinished(context.Background())
+	if len(unfinished) != 1 {
+		t.Errorf("%d unfinished jobs, want the rejected one failed", len(unfinished))
+	}
+}
+
+func TestJobServiceResumesAndCapsAttempts(t *testing.T) {
+	jobs, repo := newTestJobService(t, 1, 10, 2)
+	var ran sync.Map
+	jobs.Handle(models.JobTypePlanGeneration, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		ran.Store(job.ID, true)
+		return "done", nil
+	})
+
+	// Jobs left running by a crashed process, one of them on its last allowed attempt
+	for _, job := range []models.Job{
+		{ID: "interrupted", Type: models.JobTypePlanGeneration, UserID: "user-1", Status: models.JobStatusRunning, Attempts: 1},
+		{ID: "crash-loop", Type: models.JobTypePlanGeneration, UserID: "user-1", Status: models.JobStatusRunning, Attempts: 2},
+		{ID: "queued", Type: models.JobTypePlanGeneration, UserID: "user-1", Status: models.JobStatusQueued},
+	} {
+		if err := repo.Create(context.Background(), &job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	startJobs(t, jobs)
+
+	if job := waitForStatus(t, jobs, "interrupted", models.JobStatusSucceeded); job.Attempts != 2 {
+		t.Errorf("resumed job has %d attempts, want 2", job.Attempts)
+	}
+	waitForStatus(t, jobs, "queued", models.JobStatusSucceeded)
+	failed := waitForStatus(t, jobs, "crash-loop", models.JobStatusFailed)
+	if failed.ErrorCode != "attempts_exceeded" || failed.Attempts != 2 {
+		t.Errorf("capped job = %+v", failed)
+	}
+	if _, ok := ran.Load("crash-loop"); ok {
+		t.Error("the job was run after exceeding its attempts")
+	}
+}
+
+func TestJobServiceShutdownRequeues(t *testing.T) {
+	jobs, _ := newTestJobService(t, 1, 10, 3)
+	started := make(chan struct{})
+	jobs.Handle(models.JobTypePlanGeneration, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	cancel := startJobs(t, jobs)
+
+	job, err := jobs.Enqueue(context.Background(), models.JobTypePlanGeneration, "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	cancel()
+	jobs.Wait()
+
+	stored, err := jobs.Get(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.JobStatusQueued || stored.Attempts != 0 || stored.StartedAt != nil {
+		t.Errorf("interrupted job = %+v, want it queued again without using an attempt", stored)
+	}
+}
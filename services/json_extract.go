@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"strings"
+)
+
+// errNoJSONObject is returned when a model reply contains no JSON object
+var errNoJSONObject = errors.New("no JSON object found in AI response")
+
+// extractJSONPayload pulls the first complete JSON object out of a model reply that may
+// be wrapped in ```json fences or surrounded by prose
+func extractJSONPayload(text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	// Prefer the contents of a fenced block when there is one
+	if start := strings.Index(text, "```"); start >= 0 {
+		body := text[start+3:]
+		if newline := strings.IndexByte(body, '\n'); newline >= 0 {
+			body = body[newline+1:] // drop the language tag line
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			body = body[:end]
+		}
+		if object, ok := scanJSONObject(body); ok {
+			return object, nil
+		}
+	}
+
+	if object, ok := scanJSONObject(text); ok {
+		return object, nil
+	}
+	return "", errNoJSONObject
+}
+
+// scanJSONObject returns the first balanced {...} in text, ignoring braces inside strings
+func scanJSONObject(text string) (string, bool) {
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return "", false
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case ch == '"':
+			inString = !inString
+		case inString:
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OpenAICompatibleConfig configures a provider that speaks the OpenAI chat completions API
+type OpenAICompatibleConfig struct {
+	Name      AIProvider
+	Label     string // human readable name used in error messages
+	BaseURL   string // e.g. https://api.openai.com/v1; /chat/completions is appended
+	APIKey    string
+	APIKeyEnv string // environment variable the key is read from, reported when it is missing
+	Model     string
+	JSONMode  bool // send response_format json_object when the caller asks for JSON
+	// StructuredOutput selects how a requested ResponseSchema is enforced
+	StructuredOutput StructuredOutputMode
+}
+
+// OpenAICompatibleProvider calls any backend exposing the OpenAI chat completions API
+type OpenAICompatibleProvider struct {
+	config OpenAICompatibleConfig
+	client *http.Client
+}
+
+// NewOpenAICompatibleProvider creates a provider using the shared HTTP client
+func NewOpenAICompatibleProvider(config OpenAICompatibleConfig, client *http.Client) *OpenAICompatibleProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if config.Label == "" {
+		config.Label = string(config.Name)
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	return &OpenAICompatibleProvider{
+		config: config,
+		client: client,
+	}
+}
+
+func init() {
+	// JSON mode needs a model that supports response_format json_object
+	RegisterProvider(OpenAI, func(client *http.Client) (Provider, error) {
+		return NewOpenAICompatibleProvider(OpenAICompatibleConfig{
+			Name:      OpenAI,
+			Label:     "OpenAI",
+			BaseURL:   envOrDefault("OPEN_AI_BASE_URL", "https://api.openai.com/v1"),
+			APIKey:    os.Getenv("OPEN_AI_API_KEY"),
+			APIKeyEnv: "OPEN_AI_API_KEY",
+			Model:     envOrDefault("OPEN_AI_MODEL", "gpt-4o"),
+			JSONMode:  true,
+
+			StructuredOutput: StructuredOutputJSONSchema,
+		}, client), nil
+	})
+
+	RegisterProvider(DeepSeek, func(client *http.Client) (Provider, error) {
+		return NewOpenAICompatibleProvider(OpenAICompatibleConfig{
+			Name:      DeepSeek,
+			Label:     "DeepSeek",
+			BaseURL:   envOrDefault("DEEPSEEK_BASE_URL", "https://api.deepseek.com/v1"),
+			APIKey:    os.Getenv("DEEPSEEK_AI_API_KEY"),
+			APIKeyEnv: "DEEPSEEK_AI_API_KEY",
+			Model:     envOrDefault("DEEPSEEK_MODEL", "deepseek-chat"),
+			JSONMode:  true,
+
+			StructuredOutput: StructuredOutputTool,
+		}, client), nil
+	})
+
+	// Self-hosted servers (Ollama, llama.cpp server, vLLM) expose the same API
+	// and usually run without authentication, so the key is optional. Tool support
+	// depends on the model, so OLLAMA_STRUCTURED_OUTPUT can switch it off.
+	RegisterProvider(Ollama, func(client *http.Client) (Provider, error) {
+		return NewOpenAICompatibleProvider(OpenAICompatibleConfig{
+			Name:     Ollama,
+			Label:    "Ollama",
+			BaseURL:  envOrDefault("OLLAMA_BASE_URL", "http://localhost:11434/v1"),
+			APIKey:   os.Getenv("OLLAMA_API_KEY"),
+			Model:    envOrDefault("OLLAMA_MODEL", "llama3.1"),
+			JSONMode: true,
+
+			StructuredOutput: StructuredOutputMode(strings.ToLower(envOrDefault("OLLAMA_STRUCTURED_OUTPUT", string(StructuredOutputTool)))),
+		}, client), nil
+	})
+}
+
+// Name returns the identifier the provider is registered under
+func (p *OpenAICompatibleProvider) Name() AIProvider {
+	return p.config.Name
+}
+
+// BaseURL returns the URL the chat completions path is appended to
+func (p *OpenAICompatibleProvider) BaseURL() string {
+	return p.config.BaseURL
+}
+
+// WithBaseURL returns a copy of the provider that sends its requests to baseURL
+func (p *OpenAICompatibleProvider) WithBaseURL(baseURL string) *OpenAICompatibleProvider {
+	config := p.config
+	config.BaseURL = baseURL
+	return NewOpenAICompatibleProvider(config, p.client)
+}
+
+// ChatCompletion sends a chat completion request and returns the first choice
+func (p *OpenAICompatibleProvider) ChatCompletion(ctx context.Context, chatReq ChatRequest) (*ChatResponse, error) {
+	resp, err := p.send(ctx, p.buildRequestBody(chatReq))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newTransportError(p.config.Name, p.config.Label, err)
+	}
+
+	// Parse chat completion response
+	var completion struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content   string     `json:"content"`
+				ToolCalls []toolCall `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage usagePayload `json:"usage"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, &ProviderError{
+			Provider:   p.config.Name,
+			Kind:       ErrorKindMalformed,
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("failed to parse %s response", p.config.Label),
+			Err:        err,
+		}
+	}
+
+	// Check for API errors in response
+	if completion.Error != nil {
+		return nil, &ProviderError{
+			Provider:   p.config.Name,
+			Kind:       ErrorKindServer,
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("%s API error: %s", p.config.Label, completion.Error.Message),
+		}
+	}
+
+	// Extract the response content
+	if len(completion.Choices) == 0 {
+		return nil, &ProviderError{
+			Provider:   p.config.Name,
+			Kind:       ErrorKindMalformed,
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("no response from %s", p.config.Label),
+		}
+	}
+
+	// Forced tool calls carry the structured reply in the function arguments
+	message := completion.Choices[0].Message
+	content := message.Content
+	if len(message.ToolCalls) > 0 {
+		content = message.ToolCalls[0].Function.Arguments
+	}
+
+	return &ChatResponse{
+		Content: content,
+		Model:   p.modelOr(completion.Model),
+		Usage:   completion.Usage.toUsage(),
+	}, nil
+}
+
+// ChatCompletionStream sends a chat completion request with stream: true and calls
+// onProgress with the accumulated reply after every chunk
+func (p *OpenAICompatibleProvider) ChatCompletionStream(ctx context.Context, chatReq ChatRequest, onProgress func(text string)) (*ChatResponse, error) {
+	requestBody := p.buildRequestBody(chatReq)
+	requestBody["stream"] = true
+	requestBody["stream_options"] = map[string]bool{"include_usage": true}
+
+	resp, err := p.send(ctx, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var (
+		content  strings.Builder
+		model    string
+		usage    Usage
+		received bool
+	)
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue // blank separators, comments and keep-alives
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Delta struct {
+					Content   string     `json:"content"`
+					ToolCalls []toolCall `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *usagePayload `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, &ProviderError{
+				Provider:   p.config.Name,
+				Kind:       ErrorKindMalformed,
+				StatusCode: resp.StatusCode,
+				Message:    fmt.Sprintf("failed to parse %s stream chunk", p.config.Label),
+				Err:        err,
+			}
+		}
+
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.toUsage()
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		received = true
+		delta := chunk.Choices[0].Delta
+		text := delta.Content
+		if len(delta.ToolCalls) > 0 {
+			text = delta.ToolCalls[0].Function.Arguments
+		}
+		if text != "" {
+			content.WriteString(text)
+			if onProgress != nil {
+				onProgress(content.String())
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, newTransportError(p.config.Name, p.config.Label, err)
+	}
+
+	if !received {
+		return nil, &ProviderError{
+			Provider:   p.config.Name,
+			Kind:       ErrorKindMalformed,
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("no response from %s", p.config.Label),
+		}
+	}
+
+	return &ChatResponse{
+		Content: content.String(),
+		Model:   p.modelOr(model),
+		Usage:   usage,
+	}, nil
+}
+
+// toolCall is a function call in a chat completion message or stream delta
+type toolCall struct {
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// usagePayload is the token usage object returned by OpenAI-compatible APIs
+type usagePayload struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u usagePayload) toUsage() Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// modelOr returns the model reported by the API, or the configured one when it is empty
+func (p *OpenAICompatibleProvider) modelOr(model string) string {
+	if model == "" {
+		return p.config.Model
+	}
+	return model
+}
+
+// buildRequestBody creates the chat completion payload, applying the configured
+// structured output mechanism when the request carries a schema
+func (p *OpenAICompatibleProvider) buildRequestBody(chatReq ChatRequest) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"model":       p.config.Model,
+		"messages":    chatReq.Messages,
+		"temperature": chatReq.Temperature,
+		"max_tokens":  chatReq.MaxTokens,
+	}
+
+	schema := chatReq.Schema
+	switch {
+	case schema != nil && p.config.StructuredOutput == StructuredOutputJSONSchema:
+		requestBody["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":        schema.Name,
+				"description": schema.Description,
+				"schema":      schema.Schema,
+				"strict":      true,
+			},
+		}
+	case schema != nil && p.config.StructuredOutput == StructuredOutputTool:
+		requestBody["tools"] = []map[string]interface{}{
+			{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        schema.Name,
+					"description": schema.Description,
+					"parameters":  schema.Schema,
+				},
+			},
+		}
+		requestBody["tool_choice"] = map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": schema.Name},
+		}
+	case (chatReq.JSON || schema != nil) && p.config.JSONMode:
+		requestBody["response_format"] = map[string]string{
+			"type": "json_object",
+		}
+	}
+
+	return requestBody
+}
+
+// send posts the payload to the chat completions endpoint and returns the response
+// when it has a 200 status; the caller must close the body
+func (p *OpenAICompatibleProvider) send(ctx context.Context, requestBody map[string]interface{}) (*http.Response, error) {
+	// Check if API key is available
+	if p.config.APIKeyEnv != "" && p.config.APIKey == "" {
+		return nil, fmt.Errorf("%w: %s environment variable is not set", ErrProviderNotConfigured, p.config.APIKeyEnv)
+	}
+
+	// Convert request to JSON
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request with context
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	// Make the request
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, newTransportError(p.config.Name, p.config.Label, err)
+	}
+
+	// Check for HTTP errors
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(p.config.Name, p.config.Label, resp, body)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"fit-ai-api/llmstub"
+)
+
+// providerCase describes how a registered provider is configured through the environment
+type providerCase struct {
+	name       AIProvider
+	baseURLEnv string
+	apiKeyEnv  string
+	modelEnv   string
+	// mode is the structured output mechanism the provider uses for a schema
+	mode StructuredOutputMode
+}
+
+var providerCases = []providerCase{
+	{OpenAI, "OPEN_AI_BASE_URL", "OPEN_AI_API_KEY", "OPEN_AI_MODEL", StructuredOutputJSONSchema},
+	{DeepSeek, "DEEPSEEK_BASE_URL", "DEEPSEEK_AI_API_KEY", "DEEPSEEK_MODEL", StructuredOutputTool},
+	{Ollama, "OLLAMA_BASE_URL", "OLLAMA_API_KEY", "OLLAMA_MODEL", StructuredOutputTool},
+}
+
+// newStubProvider builds the registered provider pointed at a stub server replying with
+// responses
+func newStubProvider(t *testing.T, pc providerCase, responses ...llmstub.Response) (Provider, *llmstub.Server) {
+	t.Helper()
+	stub := llmstub.NewServer(responses...)
+	t.Cleanup(stub.Close)
+
+	t.Setenv(pc.baseURLEnv, stub.BaseURL())
+	t.Setenv(pc.apiKeyEnv, "test-key")
+	t.Setenv(pc.modelEnv, "test-model")
+	if pc.name == Ollama {
+		t.Setenv("OLLAMA_STRUCTURED_OUTPUT", "")
+	}
+
+	provider, err := NewProvider(pc.name, &http.Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, stub
+}
+
+func planRequest() ChatRequest {
+	return ChatRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: WorkoutPlanPrompt},
+			{Role: "user", Content: "Generate a plan"},
+		},
+		Temperature: 0.7,
+		MaxTokens:   2000,
+		JSON:        true,
+		Schema:      workoutPlanSchema,
+	}
+}
+
+func TestProviderRequest(t *testing.T) {
+	for _, pc := range providerCases {
+		t.Run(string(pc.name), func(t *testing.T) {
+			provider, stub := newStubProvider(t, pc, llmstub.Completion("{}"))
+			if _, err := provider.ChatCompletion(context.Background(), planRequest()); err != nil {
+				t.Fatal(err)
+			}
+
+			req, _ := stub.LastRequest()
+			if req.Path != "/v1/chat/completions" {
+				t.Errorf("path = %s", req.Path)
+			}
+			if got := req.Header.Get("Authorization"); got != "Bearer test-key" {
+				t.Errorf("Authorization = %q", got)
+			}
+			if req.Model != "test-model" || req.MaxTokens != 2000 || len(req.Messages) != 2 {
+				t.Errorf("unexpected request: model %q, max_tokens %d, %d messages", req.Model, req.MaxTokens, len(req.Messages))
+			}
+
+			switch pc.mode {
+			case StructuredOutputJSONSchema:
+				if req.ResponseFormat["type"] != "json_schema" || len(req.Tools) != 0 {
+					t.Errorf("expected a json_schema response_format, got %v and %d tools", req.ResponseFormat, len(req.Tools))
+				}
+			case StructuredOutputTool:
+				if len(req.Tools) != 1 || req.ToolChoice == nil || req.ResponseFormat != nil {
+					t.Errorf("expected a forced tool call, got %d tools and response_format %v", len(req.Tools), req.ResponseFormat)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderParsesReplies(t *testing.T) {
+	for _, pc := range providerCases {
+		t.Run(string(pc.name), func(t *testing.T) {
+			for _, fixture := range []string{"openai_plan", "openai_fenced_plan", "deepseek_tool_call_plan"} {
+				provider, _ := newStubProvider(t, pc, llmstub.MustFixture(fixture))
+				response, err := provider.ChatCompletion(context.Background(), planRequest())
+				if err != nil {
+					t.Fatalf("%s: %v", fixture, err)
+				}
+
+				ai := &AIService{}
+				plan, err := ai.parseAIResponse(response.Content)
+				if err != nil {
+					t.Fatalf("%s: %v", fixture, err)
+				}
+				if violations := ValidateWorkoutPlan(plan); len(violations) > 0 {
+					t.Errorf("%s: recorded plan is invalid: %v", fixture, violations)
+				}
+				if response.Usage.TotalTokens == 0 || response.Model == "" {
+					t.Errorf("%s: missing metadata: %+v", fixture, response)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderStreamsReplies(t *testing.T) {
+	for _, pc := range providerCases {
+		t.Run(string(pc.name), func(t *testing.T) {
+			want := llmstub.MustFixture("deepseek_tool_call_plan")
+			provider, stub := newStubProvider(t, pc, want)
+
+			var updates int
+			response, err := provider.(StreamingProvider).ChatCompletionStream(context.Background(), planRequest(), func(string) { updates++ })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req, _ := stub.LastRequest(); !req.Stream {
+				t.Error("request did not ask to stream")
+			}
+			if updates < 2 {
+				t.Errorf("got %d progress updates, want several", updates)
+			}
+			if _, err := (&AIService{}).parseAIResponse(response.Content); err != nil {
+				t.Errorf("streamed reply does not parse: %v", err)
+			}
+			if response.Usage.TotalTokens != 2378 {
+				t.Errorf("usage = %+v, want the recorded usage", response.Usage)
+			}
+		})
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		kind       ErrorKind
+		status     int
+		retryAfter time.Duration
+		retryable  bool
+	}{
+		{"openai_rate_limited", ErrorKindRateLimit, 429, time.Second, true},
+		{"openai_insufficient_quota", ErrorKindQuota, 429, 0, false},
+		{"openai_invalid_api_key", ErrorKindAuth, 401, 0, false},
+		{"deepseek_insufficient_balance", ErrorKindQuota, 402, 0, false},
+		{"deepseek_server_overloaded", ErrorKindServer, 503, 0, true},
+		{"openai_error_body", ErrorKindServer, 200, 0, true},
+		{"openai_empty_choices", ErrorKindMalformed, 200, 0, false},
+		{"openai_malformed_body", ErrorKindMalformed, 200, 0, false},
+	}
+
+	for _, pc := range providerCases {
+		for _, tt := range tests {
+			t.Run(string(pc.name)+"/"+tt.fixture, func(t *testing.T) {
+				provider, _ := newStubProvider(t, pc, llmstub.MustFixture(tt.fixture))
+				_, err := provider.ChatCompletion(context.Background(), planRequest())
+
+				var providerErr *ProviderError
+				if !errors.As(err, &providerErr) {
+					t.Fatalf("err = %v, want a *ProviderError", err)
+				}
+				if providerErr.Provider != pc.name || providerErr.Kind != tt.kind || providerErr.StatusCode != tt.status {
+					t.Errorf("got provider %s, kind %s, status %d; want %s, %s, %d",
+						providerErr.Provider, providerErr.Kind, providerErr.StatusCode, pc.name, tt.kind, tt.status)
+				}
+				if providerErr.RetryAfter != tt.retryAfter {
+					t.Errorf("RetryAfter = %s, want %s", providerErr.RetryAfter, tt.retryAfter)
+				}
+				if providerErr.Retryable() != tt.retryable {
+					t.Errorf("Retryable() = %t, want %t", providerErr.Retryable(), tt.retryable)
+				}
+			})
+		}
+	}
+}
+
+func TestProviderTimeout(t *testing.T) {
+	for _, pc := range providerCases {
+		t.Run(string(pc.name), func(t *testing.T) {
+			provider, _ := newStubProvider(t, pc, llmstub.MustFixture("openai_slow_plan"))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := provider.ChatCompletion(ctx, planRequest())
+
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) || providerErr.Kind != ErrorKindTimeout {
+				t.Fatalf("err = %v, want a timeout", err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("err = %v, want it to wrap context.DeadlineExceeded", err)
+			}
+		})
+	}
+}
+
+func TestProviderMissingAPIKey(t *testing.T) {
+	for _, pc := range providerCases {
+		t.Run(string(pc.name), func(t *testing.T) {
+			_, stub := newStubProvider(t, pc, llmstub.Completion("{}"))
+			t.Setenv(pc.apiKeyEnv, "")
+			provider, err := NewProvider(pc.name, &http.Client{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = provider.ChatCompletion(context.Background(), planRequest())
+			if pc.name == Ollama {
+				// Self-hosted servers usually run without authentication
+				if err != nil {
+					t.Errorf("err = %v, want the key to be optional", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrProviderNotConfigured) || !strings.Contains(err.Error(), pc.apiKeyEnv) {
+				t.Errorf("err = %v, want ErrProviderNotConfigured naming %s", err, pc.apiKeyEnv)
+			}
+			if len(stub.Requests()) != 0 {
+				t.Error("the request was sent without an API key")
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// ErrNoSuggestion is returned when accepting or rejecting a suggestion on a plan that
+// has none
+var ErrNoSuggestion = errors.New("workout plan has no suggested plan")
+
+// planReviewPayload is the payload of a JobTypePlanSuggestion job
+type planReviewPayload struct {
+	PlanID  uint   `json:"planId"`
+	Trigger string `json:"trigger"`
+}
+
+// SuggestionJobResult is the result of a JobTypePlanSuggestion job
+type SuggestionJobResult struct {
+	PlanID        uint                  `json:"planId"`
+	SuggestedPlan *models.SuggestedPlan `json:"suggestedPlan"`
+	Meta          *GenerationMetadata   `json:"meta,omitempty"`
+}
+
+// PlanReviewService runs the AI feedback cycle: once a plan has been followed for
+// AIFeedbackCycle sessions, or its PlanValidityPeriod has run out, the logged history is
+// sent to the model in a background job and the reply is stored as the plan's
+// SuggestedPlan until the user accepts or rejects it.
+type PlanReviewService struct {
+	plans     repositories.WorkoutPlanRepository
+	logs      repositories.WorkoutLogRepository
+	profiles  repositories.ProfileRepository
+	aiService *AIService
+	catalog   *ExerciseCatalog
+	jobs      *JobService
+	now       func() time.Time
+}
+
+// NewPlanReviewService creates a plan review service. Register RunSuggestionJob with jobs
+// for JobTypePlanSuggestion before starting it. catalog may be nil, in which case the
+// exercises of suggested plans are not resolved.
+func NewPlanReviewService(plans repositories.WorkoutPlanRepository, logs repositories.WorkoutLogRepository, profiles repositories.ProfileRepository, aiService *AIService, catalog *ExerciseCatalog, jobs *JobService) *PlanReviewService {
+	return &PlanReviewService{
+		plans:     plans,
+		logs:      logs,
+		profiles:  profiles,
+		aiService: aiService,
+		catalog:   catalog,
+		jobs:      jobs,
+		now:       time.Now,
+	}
+}
+
+// ScheduleIfDue queues a suggestion job when completing workout made plan due for review.
+// It returns nil when no review is due. history is the user's workouts including workout.
+func (s *PlanReviewService) ScheduleIfDue(ctx context.Context, plan *models.WorkoutPlan, history []models.WorkoutLog, workout *models.WorkoutLog) (*models.Job, error) {
+	trigger := reviewTrigger(plan, history, workout)
+	if trigger == "" {
+		return nil, nil
+	}
+	return s.jobs.Enqueue(ctx, models.JobTypePlanSuggestion, plan.UserID, planReviewPayload{
+		PlanID:  uint(plan.ID),
+		Trigger: trigger,
+	})
+}
+
+// reviewTrigger describes why plan is due for review after workout was completed, or
+// returns "" when it isn't. A plan is reviewed every AIFeedbackCycle sessions and once
+// when the first workout after its validity period is completed; no review is started
+// while a suggestion is waiting for an answer.
+func reviewTrigger(plan *models.WorkoutPlan, history []models.WorkoutLog, workout *models.WorkoutLog) string {
+	if plan.HasNewPlanSuggestion {
+		return ""
+	}
+
+	if cycle := plan.AIFeedbackCycle; cycle > 0 && plan.SessionsCompleted > 0 && plan.SessionsCompleted%cycle == 0 {
+		return fmt.Sprintf("%d sessions completed, reaching the feedback cycle of %d sessions", plan.SessionsCompleted, cycle)
+	}
+
+	if plan.PlanValidityPeriod <= 0 || workout.CompletedAt == nil {
+		return ""
+	}
+	expiry := plan.PlanStartDate.AddDate(0, 0, plan.PlanValidityPeriod)
+	if !workout.CompletedAt.After(expiry) {
+		return ""
+	}
+	// Only the first workout after expiry triggers a review
+	previous := plan.PlanStartDate
+	for _, logged := range history {
+		if logged.PlanID == uint(plan.ID) && logged.ID != workout.ID && logged.CompletedAt != nil && logged.CompletedAt.After(previous) {
+			previous = *logged.CompletedAt
+		}
+	}
+	if previous.After(expiry) {
+		return ""
+	}
+	return fmt.Sprintf("the plan's %d-day validity period ended on %s", plan.PlanValidityPeriod, expiry.UTC().Format(time.DateOnly))
+}
+
+// RunSuggestionJob is the background job handler for JobTypePlanSuggestion
+func (s *PlanReviewService) RunSuggestionJob(ctx context.Context, job *models.Job) (interface{}, error) {
+	var payload planReviewPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("invalid plan suggestion payload: %w", err)
+	}
+
+	plan, err := s.plans.Get(ctx, payload.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.HasNewPlanSuggestion {
+		// Another review already produced a suggestion
+		return SuggestionJobResult{PlanID: payload.PlanID, SuggestedPlan: plan.SuggestedPlan}, nil
+	}
+
+	userData, err := s.profiles.Get(ctx, plan.UserID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.logs.ListByUser(ctx, plan.UserID)
+	if err != nil {
+		return nil, err
+	}
+	planHistory := make([]models.WorkoutLog, 0, len(history))
+	for _, workout := range history {
+		if workout.PlanID == payload.PlanID {
+			planHistory = append(planHistory, workout)
+		}
+	}
+
+	result, err := s.aiService.GenerateSuggestedPlan(ctx, userData, plan, planHistory, payload.Trigger)
+	if err != nil {
+		return nil, err
+	}
+	result.Metadata.Catalog = s.catalog.Annotate(ctx, result.Suggestion.Sessions)
+	result.Metadata.Equipment = s.catalog.Enforce(ctx, result.Suggestion.Sessions, userData.Data.UserProfile)
+	if _, err := s.plans.SetSuggestion(ctx, payload.PlanID, result.Suggestion); err != nil {
+		return nil, err
+	}
+
+	return SuggestionJobResult{
+		PlanID:        payload.PlanID,
+		SuggestedPlan: result.Suggestion,
+		Meta:          &result.Metadata,
+	}, nil
+}
+
+// AcceptSuggestion replaces the plan's sessions, name and description with its suggested
+// plan and starts it afresh: SessionsCompleted is reset and PlanStartDate set to now.
+// The plan keeps its ID, feedback cycle and validity period.
+func (s *PlanReviewService) AcceptSuggestion(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	plan, err := s.plans.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	suggestion := plan.SuggestedPlan
+	if suggestion == nil {
+		return nil, ErrNoSuggestion
+	}
+
+	return s.plans.Replace(ctx, id, &models.WorkoutPlan{
+		Name:               suggestion.Name,
+		Description:        suggestion.Description,
+		AIFeedbackCycle:    plan.AIFeedbackCycle,
+		PlanValidityPeriod: plan.PlanValidityPeriod,
+		PlanStartDate:      s.now().UTC(),
+		Sessions:           suggestion.Sessions,
+	})
+}
+
+// RejectSuggestion dismisses the plan's suggested plan, keeping the current plan. The
+// next review happens after another AIFeedbackCycle sessions.
+func (s *PlanReviewService) RejectSuggestion(ctx context.Context, id uint) (*models.WorkoutPlan, error) {
+	plan, err := s.plans.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.SuggestedPlan == nil && !plan.HasNewPlanSuggestion {
+		return nil, ErrNoSuggestion
+	}
+	return s.plans.SetSuggestion(ctx, id, nil)
+}
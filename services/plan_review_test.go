@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fit-ai-api/llmstub"
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// reviewStart is the start date of the plans reviewed in these tests
+var reviewStart = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+// completedAt returns a workout of plan 1 completed days after reviewStart
+func completedAt(id uint, days int) models.WorkoutLog {
+	at := reviewStart.AddDate(0, 0, days)
+	return models.WorkoutLog{ID: id, PlanID: 1, Status: models.WorkoutLogCompleted, CompletedAt: &at}
+}
+
+func TestReviewTrigger(t *testing.T) {
+	// The plan expires 28 days after reviewStart
+	plan := func(sessions int, pending bool) *models.WorkoutPlan {
+		return &models.WorkoutPlan{
+			ID: 1, AIFeedbackCycle: 12, PlanValidityPeriod: 28, PlanStartDate: reviewStart,
+			SessionsCompleted: sessions, HasNewPlanSuggestion: pending,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		plan    *models.WorkoutPlan
+		history []models.WorkoutLog
+		workout models.WorkoutLog
+		want    string
+	}{
+		{"before the first cycle", plan(11, false), nil, completedAt(11, 20), ""},
+		{"first cycle", plan(12, false), nil, completedAt(12, 20), "12 sessions completed"},
+		{"between cycles", plan(13, false), nil, completedAt(13, 21), ""},
+		{"second cycle", plan(24, false), nil, completedAt(24, 27), "24 sessions completed"},
+		{"no feedback cycle", &models.WorkoutPlan{ID: 1, SessionsCompleted: 12}, nil, completedAt(12, 20), ""},
+		{"cycle with a pending suggestion", plan(12, true), nil, completedAt(12, 20), ""},
+		{"first workout after expiry", plan(5, false), []models.WorkoutLog{completedAt(4, 27)}, completedAt(5, 30), "validity period ended on 2024-05-29"},
+		{"second workout after expiry", plan(6, false), []models.WorkoutLog{completedAt(4, 27), completedAt(5, 30)}, completedAt(6, 31), ""},
+		{"expiry with a pending suggestion", plan(5, true), []models.WorkoutLog{completedAt(4, 27)}, completedAt(5, 30), ""},
+		{"workouts of other plans don't count", plan(5, false), []models.WorkoutLog{{ID: 4, PlanID: 2, Status: models.WorkoutLogCompleted, CompletedAt: completedAt(0, 29).CompletedAt}}, completedAt(5, 30), "validity period ended"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// history includes the workout just completed, as Complete passes it
+			history := append(tt.history, tt.workout)
+			got := reviewTrigger(tt.plan, history, &tt.workout)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("reviewTrigger = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// testSuggestion returns the reply of a model suggesting the next plan
+func testSuggestion(t *testing.T) string {
+	t.Helper()
+	sessions := testPlan().Sessions
+	data, err := json.Marshal(models.SuggestedPlan{
+		Name:         "Push Pull Legs Strength",
+		Reason:       "Bench press stalled at 20 KG for three sessions",
+		Improvements: []string{"Lower reps with heavier compound lifts"},
+		Comparison:   models.PlanComparison{Current: models.PlanDetails{Sessions: 9}, Suggested: models.PlanDetails{Sessions: 9}},
+		Sessions:     sessions,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// newTestReviewService returns a plan review service on in-memory repositories holding
+// testPlan as plan 1 of user-1, with the model answered by stub
+func newTestReviewService(t *testing.T, stub *llmstub.Server) (*PlanReviewService, *repositories.MemoryWorkoutPlanRepository, *repositories.MemoryWorkoutLogRepository) {
+	t.Helper()
+	setAIEnv(t, OpenAI)
+	plans := repositories.NewMemoryWorkoutPlanRepository()
+	if _, err := plans.Create(context.Background(), "user-1", testPlan()); err != nil {
+		t.Fatal(err)
+	}
+	profiles := repositories.NewMemoryProfileRepository()
+	profiles.Put("user-1", testUserData().Data)
+	logs := repositories.NewMemoryWorkoutLogRepository()
+
+	service := NewPlanReviewService(plans, logs, profiles, NewAIServiceWithBaseURL(stub.BaseURL()), nil, nil)
+	service.now = func() time.Time { return workoutNow }
+	return service, plans, logs
+}
+
+func TestGenerateSuggestedPlan(t *testing.T) {
+	setAIEnv(t, OpenAI)
+	stub := stubServer(t, llmstub.Completion(testSuggestion(t)))
+	plan := testPlan()
+	plan.ID = 7
+	history := []models.WorkoutLog{{
+		PlanID: 7, SessionName: "Push", Status: models.WorkoutLogCompleted, CompletedAt: &workoutNow, DurationSeconds: 3000,
+		Sets: []models.SetLog{{ExerciseName: "Barbell Bench Press", SetNumber: 1, Reps: 6, Weight: kg(20)}},
+	}}
+
+	result, err := NewAIServiceWithBaseURL(stub.BaseURL()).GenerateSuggestedPlan(context.Background(), testUserData(), plan, history, "12 sessions completed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suggestion := result.Suggestion
+	if suggestion.Name != "Push Pull Legs Strength" || len(suggestion.Sessions) != 3 || !strings.HasPrefix(suggestion.ID, "suggestion_7_") {
+		t.Errorf("suggestion = %+v", suggestion)
+	}
+	// Session counts come from the plans, not the model
+	if suggestion.Comparison.Current.Sessions != 3 || suggestion.Comparison.Suggested.Sessions != 3 {
+		t.Errorf("comparison = %+v, want 3 current and 3 suggested sessions", suggestion.Comparison)
+	}
+
+	request, _ := stub.LastRequest()
+	prompt := request.Messages[len(request.Messages)-1].Content
+	for _, want := range []string{"REVIEW TRIGGER: 12 sessions completed", "Push Pull Legs Hypertrophy", "Barbell Bench Press", "Ana Tester"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestRunSuggestionJob(t *testing.T) {
+	job := func(payload string) *models.Job {
+		return &models.Job{ID: "job-1", Type: models.JobTypePlanSuggestion, UserID: "user-1", Payload: models.JSON(payload)}
+	}
+
+	t.Run("stores the suggestion", func(t *testing.T) {
+		stub := stubServer(t, llmstub.Completion(testSuggestion(t)))
+		service, plans, logs := newTestReviewService(t, stub)
+		ctx := context.Background()
+		for _, workout := range []*models.WorkoutLog{
+			{UserID: "user-1", PlanID: 1, SessionName: "Push", Status: models.WorkoutLogCompleted, StartedAt: workoutNow, CompletedAt: &workoutNow},
+			{UserID: "user-1", PlanID: 2, SessionName: "Other Plan Session", Status: models.WorkoutLogCompleted, StartedAt: workoutNow, CompletedAt: &workoutNow},
+		} {
+			if err := logs.Create(ctx, workout); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := service.RunSuggestionJob(ctx, job(`{"planId": 1, "trigger": "12 sessions completed"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := result.(SuggestionJobResult)
+		if !ok || got.PlanID != 1 || got.SuggestedPlan == nil || got.SuggestedPlan.Name != "Push Pull Legs Strength" || got.Meta == nil {
+			t.Fatalf("result = %+v", result)
+		}
+		plan, err := plans.Get(ctx, 1)
+		if err != nil || !plan.HasNewPlanSuggestion || plan.SuggestedPlan == nil || plan.SuggestedPlan.Name != "Push Pull Legs Strength" {
+			t.Fatalf("plan after the job = %+v, %v; want the suggestion stored", plan, err)
+		}
+
+		// Only the reviewed plan's workouts are sent to the model
+		request, _ := stub.LastRequest()
+		prompt := request.Messages[len(request.Messages)-1].Content
+		if !strings.Contains(prompt, "12 sessions completed") || strings.Contains(prompt, "Other Plan Session") {
+			t.Errorf("prompt has the wrong trigger or history:\n%s", prompt)
+		}
+	})
+
+	t.Run("keeps a pending suggestion", func(t *testing.T) {
+		stub := stubServer(t)
+		service, plans, _ := newTestReviewService(t, stub)
+		pending := &models.SuggestedPlan{Name: "Pending", Reason: "Earlier review"}
+		if _, err := plans.SetSuggestion(context.Background(), 1, pending); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := service.RunSuggestionJob(context.Background(), job(`{"planId": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := result.(SuggestionJobResult); got.SuggestedPlan == nil || got.SuggestedPlan.Name != "Pending" {
+			t.Errorf("result = %+v, want the pending suggestion", result)
+		}
+		if len(stub.Requests()) != 0 {
+			t.Error("the model was asked for another suggestion")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		stub := stubServer(t)
+		service, _, _ := newTestReviewService(t, stub)
+		if _, err := service.RunSuggestionJob(context.Background(), job(`not json`)); err == nil {
+			t.Error("invalid payload: no error")
+		}
+		if _, err := service.RunSuggestionJob(context.Background(), job(`{"planId": 99}`)); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("unknown plan: error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestPlanReviewServiceAnswerSuggestion(t *testing.T) {
+	ctx := context.Background()
+	suggestion := func() *models.SuggestedPlan {
+		return &models.SuggestedPlan{Name: "Upper Lower", Description: "Two days", Reason: "Stalled", Sessions: testPlan().Sessions[:2]}
+	}
+
+	t.Run("accept", func(t *testing.T) {
+		service, plans, _ := newTestReviewService(t, stubServer(t))
+		if _, err := plans.IncrementSessionsCompleted(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := plans.SetSuggestion(ctx, 1, suggestion()); err != nil {
+			t.Fatal(err)
+		}
+
+		plan, err := service.AcceptSuggestion(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.ID != 1 || plan.Name != "Upper Lower" || len(plan.Sessions) != 2 || plan.AIFeedbackCycle != 12 || plan.PlanValidityPeriod != 28 {
+			t.Errorf("accepted plan = %+v, want plan 1 replaced by the suggestion", plan)
+		}
+		if plan.SessionsCompleted != 0 || !plan.PlanStartDate.Equal(workoutNow) || plan.HasNewPlanSuggestion || plan.SuggestedPlan != nil {
+			t.Errorf("accepted plan = %+v, want it restarted at %v without a suggestion", plan, workoutNow)
+		}
+		if _, err := service.AcceptSuggestion(ctx, 1); !errors.Is(err, ErrNoSuggestion) {
+			t.Errorf("second accept: error = %v, want ErrNoSuggestion", err)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		service, plans, _ := newTestReviewService(t, stubServer(t))
+		if _, err := plans.SetSuggestion(ctx, 1, suggestion()); err != nil {
+			t.Fatal(err)
+		}
+
+		plan, err := service.RejectSuggestion(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.Name != "Push Pull Legs Hypertrophy" || len(plan.Sessions) != 3 || plan.HasNewPlanSuggestion || plan.SuggestedPlan != nil {
+			t.Errorf("rejected plan = %+v, want the current plan without a suggestion", plan)
+		}
+		if _, err := service.RejectSuggestion(ctx, 1); !errors.Is(err, ErrNoSuggestion) {
+			t.Errorf("second reject: error = %v, want ErrNoSuggestion", err)
+		}
+		if _, err := service.AcceptSuggestion(ctx, 99); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("accept on an unknown plan: error = %v, want ErrNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"fit-ai-api/models"
+)
+
+// Domain limits for generated workout plans; session and exercise counts mirror the
+// requirements in WorkoutPlanTemplate
+const (
+	minSessions        = 3
+	maxSessions        = 6
+	minExercisesPerDay = 4
+	maxExercisesPerDay = 8
+	minSets            = 1
+	maxSets            = 10
+	minReps            = 1
+	maxReps            = 100
+	maxWeightValue     = 1000
+)
+
+// Accepted values of WeightInfo.Unit
+const (
+	WeightUnitPounds     = "LB"
+	WeightUnitKilograms  = "KG"
+	WeightUnitBodyweight = "BODYWEIGHT"
+)
+
+// validWeightUnits lists the accepted values of WeightInfo.Unit
+var validWeightUnits = map[string]bool{
+	WeightUnitPounds:     true,
+	WeightUnitKilograms:  true,
+	WeightUnitBodyweight: true,
+}
+
+// PlanViolation is a single domain rule broken by a workout plan
+type PlanViolation struct {
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PlanValidationError is returned when a generated plan breaks one or more domain rules
+type PlanValidationError struct {
+	Violations []PlanViolation
+}
+
+func (e *PlanValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Path+": "+v.Message)
+	}
+	return fmt.Sprintf("workout plan failed validation (%d violations): %s", len(e.Violations), strings.Join(messages, "; "))
+}
+
+// ValidateWorkoutPlan checks a decoded plan against the domain rules and returns every
+// violation found, or nil when the plan is valid
+func ValidateWorkoutPlan(plan *models.WorkoutPlan) []PlanViolation {
+	var violations []PlanViolation
+	add := func(path, rule, format string, args ...interface{}) {
+		violations = append(violations, PlanViolation{
+			Path:    path,
+			Rule:    rule,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if plan == nil {
+		add("plan", "required", "plan is missing")
+		return violations
+	}
+
+	if strings.TrimSpace(plan.Name) == "" {
+		add("name", "required", "plan name is required")
+	}
+
+	if n := len(plan.Sessions); n < minSessions || n > maxSessions {
+		add("sessions", "session_count", "expected %d-%d sessions, got %d", minSessions, maxSessions, n)
+	}
+
+	for i, session := range plan.Sessions {
+		sessionPath := fmt.Sprintf("sessions[%d]", i)
+
+		if strings.TrimSpace(session.Name) == "" {
+			add(sessionPath+".name", "required", "session name is required")
+		}
+
+		if n := len(session.Exercises); n < minExercisesPerDay || n > maxExercisesPerDay {
+			add(sessionPath+".exercises", "exercise_count", "expected %d-%d exercises, got %d", minExercisesPerDay, maxExercisesPerDay, n)
+		}
+
+		for j, exercise := range session.Exercises {
+			exercisePath := fmt.Sprintf("%s.exercises[%d]", sessionPath, j)
+
+			if strings.TrimSpace(exercise.Name) == "" {
+				add(exercisePath+".name", "required", "exercise name is required")
+			}
+			if exercise.Sets < minSets || exercise.Sets > maxSets {
+				add(exercisePath+".sets", "range", "sets must be between %d and %d, got %d", minSets, maxSets, exercise.Sets)
+			}
+			if exercise.Reps < minReps || exercise.Reps > maxReps {
+				add(exercisePath+".reps", "range", "reps must be between %d and %d, got %d", minReps, maxReps, exercise.Reps)
+			}
+
+			weight := exercise.Weight
+			if !validWeightUnits[weight.Unit] {
+				add(exercisePath+".weight.unit", "enum", "unit must be one of LB, KG, BODYWEIGHT, got %q", weight.Unit)
+			}
+			if weight.Value < 0 || weight.Value > maxWeightValue {
+				add(exercisePath+".weight.value", "range", "weight must be between 0 and %d, got %g", maxWeightValue, weight.Value)
+			}
+		}
+	}
+
+	return violations
+}
+
+// ValidateSuggestedPlan checks a suggested plan against the same rules as a workout plan
+// and requires the reason shown to the user
+func ValidateSuggestedPlan(suggestion *models.SuggestedPlan) []PlanViolation {
+	if suggestion == nil {
+		return ValidateWorkoutPlan(nil)
+	}
+
+	violations := ValidateWorkoutPlan(&models.WorkoutPlan{
+		Name:     suggestion.Name,
+		Sessions: suggestion.Sessions,
+	})
+	if strings.TrimSpace(suggestion.Reason) == "" {
+		violations = append(violations, PlanViolation{
+			Path:    "reason",
+			Rule:    "required",
+			Message: "reason is required",
+		})
+	}
+	return violations
+}
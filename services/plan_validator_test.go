@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"fit-ai-api/models"
+)
+
+// validatorPlan returns a plan that passes validation: three sessions of four exercises
+func validatorPlan() *models.WorkoutPlan {
+	plan := &models.WorkoutPlan{Name: "Full Body"}
+	for i := 1; i <= minSessions; i++ {
+		session := models.WorkoutSession{Name: fmt.Sprintf("Day %d", i)}
+		for j := 1; j <= minExercisesPerDay; j++ {
+			session.Exercises = append(session.Exercises, models.Exercise{
+				Name:   fmt.Sprintf("Exercise %d", j),
+				Sets:   3,
+				Reps:   10,
+				Weight: models.WeightInfo{Value: 20, Unit: WeightUnitKilograms},
+				Type:   "weight",
+			})
+		}
+		plan.Sessions = append(plan.Sessions, session)
+	}
+	return plan
+}
+
+// violationKeys returns the "path rule" of each violation
+func violationKeys(violations []PlanViolation) []string {
+	keys := make([]string, len(violations))
+	for i, v := range violations {
+		keys[i] = v.Path + " " + v.Rule
+	}
+	return keys
+}
+
+func TestValidateWorkoutPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(plan *models.WorkoutPlan)
+		want []string
+	}{
+		{"valid", func(plan *models.WorkoutPlan) {}, nil},
+		{"bodyweight at zero weight", func(plan *models.WorkoutPlan) {
+			plan.Sessions[0].Exercises[0].Weight = models.WeightInfo{Unit: WeightUnitBodyweight}
+		}, nil},
+		{"missing name", func(plan *models.WorkoutPlan) { plan.Name = "  " }, []string{"name required"}},
+		{"empty sessions", func(plan *models.WorkoutPlan) { plan.Sessions = nil }, []string{"sessions session_count"}},
+		{"too many sessions", func(plan *models.WorkoutPlan) {
+			for len(plan.Sessions) <= maxSessions {
+				plan.Sessions = append(plan.Sessions, plan.Sessions[0])
+			}
+		}, []string{"sessions session_count"}},
+		{"missing session name", func(plan *models.WorkoutPlan) { plan.Sessions[1].Name = "" }, []string{"sessions[1].name required"}},
+		{"too few exercises", func(plan *models.WorkoutPlan) {
+			plan.Sessions[2].Exercises = plan.Sessions[2].Exercises[:minExercisesPerDay-1]
+		}, []string{"sessions[2].exercises exercise_count"}},
+		{"missing exercise name", func(plan *models.WorkoutPlan) { plan.Sessions[0].Exercises[1].Name = "" }, []string{"sessions[0].exercises[1].name required"}},
+		{"zero sets", func(plan *models.WorkoutPlan) { plan.Sessions[0].Exercises[0].Sets = 0 }, []string{"sessions[0].exercises[0].sets range"}},
+		{"negative sets", func(plan *models.WorkoutPlan) { plan.Sessions[0].Exercises[0].Sets = -3 }, []string{"sessions[0].exercises[0].sets range"}},
+		{"too many sets", func(plan *models.WorkoutPlan) { plan.Sessions[0].Exercises[0].Sets = maxSets + 1 }, []string{"sessions[0].exercises[0].sets range"}},
+		{"zero reps", func(plan *models.WorkoutPlan) { plan.Sessions[1].Exercises[2].Reps = 0 }, []string{"sessions[1].exercises[2].reps range"}},
+		{"negative reps", func(plan *models.WorkoutPlan) { plan.Sessions[1].Exercises[2].Reps = -1 }, []string{"sessions[1].exercises[2].reps range"}},
+		{"negative weight", func(plan *models.WorkoutPlan) { plan.Sessions[2].Exercises[3].Weight.Value = -5 }, []string{"sessions[2].exercises[3].weight.value range"}},
+		{"too heavy", func(plan *models.WorkoutPlan) { plan.Sessions[2].Exercises[3].Weight.Value = maxWeightValue + 1 }, []string{"sessions[2].exercises[3].weight.value range"}},
+		{"unknown unit", func(plan *models.WorkoutPlan) { plan.Sessions[0].Exercises[0].Weight.Unit = "STONE" }, []string{"sessions[0].exercises[0].weight.unit enum"}},
+		{"lowercase unit", func(plan *models.WorkoutPlan) { plan.Sessions[0].Exercises[0].Weight.Unit = "kg" }, []string{"sessions[0].exercises[0].weight.unit enum"}},
+		{"missing unit", func(plan *models.WorkoutPlan) { plan.Sessions[0].Exercises[0].Weight.Unit = "" }, []string{"sessions[0].exercises[0].weight.unit enum"}},
+		{"every violation is reported", func(plan *models.WorkoutPlan) {
+			plan.Name = ""
+			exercise := &plan.Sessions[0].Exercises[0]
+			exercise.Sets = 0
+			exercise.Reps = 0
+			exercise.Weight = models.WeightInfo{Value: -1, Unit: "STONE"}
+		}, []string{
+			"name required",
+			"sessions[0].exercises[0].sets range",
+			"sessions[0].exercises[0].reps range",
+			"sessions[0].exercises[0].weight.unit enum",
+			"sessions[0].exercises[0].weight.value range",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := validatorPlan()
+			tt.edit(plan)
+			got := violationKeys(ValidateWorkoutPlan(plan))
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := violationKeys(ValidateWorkoutPlan(nil)); strings.Join(got, ", ") != "plan required" {
+		t.Errorf("nil plan: violations = %v", got)
+	}
+}
+
+func TestValidateSuggestedPlan(t *testing.T) {
+	suggestion := func() *models.SuggestedPlan {
+		plan := validatorPlan()
+		return &models.SuggestedPlan{Name: "Full Body v2", Reason: "Progress has stalled", Sessions: plan.Sessions}
+	}
+
+	tests := []struct {
+		name string
+		edit func(suggestion *models.SuggestedPlan)
+		want []string
+	}{
+		{"valid", func(s *models.SuggestedPlan) {}, nil},
+		{"missing reason", func(s *models.SuggestedPlan) { s.Reason = " " }, []string{"reason required"}},
+		{"missing name", func(s *models.SuggestedPlan) { s.Name = "" }, []string{"name required"}},
+		{"empty sessions", func(s *models.SuggestedPlan) { s.Sessions = nil }, []string{"sessions session_count"}},
+		{"non-positive sets and reps", func(s *models.SuggestedPlan) {
+			s.Sessions[0].Exercises[0].Sets = 0
+			s.Sessions[0].Exercises[0].Reps = -2
+		}, []string{"sessions[0].exercises[0].sets range", "sessions[0].exercises[0].reps range"}},
+		{"negative weight", func(s *models.SuggestedPlan) { s.Sessions[1].Exercises[0].Weight.Value = -10 }, []string{"sessions[1].exercises[0].weight.value range"}},
+		{"unknown unit", func(s *models.SuggestedPlan) { s.Sessions[1].Exercises[0].Weight.Unit = "KGS" }, []string{"sessions[1].exercises[0].weight.unit enum"}},
+		{"missing name and reason", func(s *models.SuggestedPlan) {
+			s.Name = ""
+			s.Reason = ""
+		}, []string{"name required", "reason required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := suggestion()
+			tt.edit(s)
+			got := violationKeys(ValidateSuggestedPlan(s))
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := violationKeys(ValidateSuggestedPlan(nil)); strings.Join(got, ", ") != "plan required" {
+		t.Errorf("nil suggestion: violations = %v", got)
+	}
+}
+
+func TestPlanValidationErrorMessage(t *testing.T) {
+	plan := validatorPlan()
+	plan.Sessions[0].Exercises[0].Sets = 0
+	err := &PlanValidationError{Violations: ValidateWorkoutPlan(plan)}
+	want := "workout plan failed validation (1 violations): sessions[0].exercises[0].sets: sets must be between 1 and 10, got 0"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"fit-ai-api/models"
+)
+
+// ProgressionScheme selects how next-session targets are computed from logged sets
+type ProgressionScheme string
+
+const (
+	// ProgressionLinear adds one weight increment after every successful session
+	ProgressionLinear ProgressionScheme = "linear"
+	// ProgressionDouble adds reps up to the top of the rep range, then adds weight and
+	// drops back to the bottom of the range
+	ProgressionDouble ProgressionScheme = "double"
+	// ProgressionRPE scales the weight by how far the logged RPE was from the target RPE
+	ProgressionRPE ProgressionScheme = "rpe"
+	// ProgressionAuto uses RPE when the last session logged it and double progression
+	// otherwise
+	ProgressionAuto ProgressionScheme = "auto"
+	// ProgressionOff leaves plans unchanged when workouts are completed
+	ProgressionOff ProgressionScheme = "off"
+)
+
+// Progression actions reported for each exercise
+const (
+	ProgressionIncreaseWeight = "increase_weight"
+	ProgressionDecreaseWeight = "decrease_weight"
+	ProgressionIncreaseReps   = "increase_reps"
+	ProgressionIncreaseSets   = "increase_sets"
+	ProgressionHold           = "hold"
+	ProgressionDeload         = "deload"
+)
+
+// ProgressionConfig tunes the progression engine
+type ProgressionConfig struct {
+	Scheme ProgressionScheme
+	// IncrementKG and IncrementLB are the smallest weight steps; new weights are rounded
+	// to them
+	IncrementKG float64
+	IncrementLB float64
+	// RepRangeMin and RepRangeMax bound double progression. Exercises prescribed outside
+	// the range progress linearly.
+	RepRangeMin int
+	RepRangeMax int
+	// TargetRPE is the effort RPE autoregulation aims for; each point of difference
+	// changes the weight by RPEStepPercent
+	TargetRPE      float64
+	RPEStepPercent float64
+	// DeloadAfter consecutive failed sessions trigger a deload of DeloadPercent
+	DeloadAfter   int
+	DeloadPercent float64
+}
+
+// DefaultProgressionConfig returns the engine defaults
+func DefaultProgressionConfig() ProgressionConfig {
+	return ProgressionConfig{
+		Scheme:         ProgressionAuto,
+		IncrementKG:    2.5,
+		IncrementLB:    5,
+		RepRangeMin:    8,
+		RepRangeMax:    12,
+		TargetRPE:      8,
+		RPEStepPercent: 3,
+		DeloadAfter:    3,
+		DeloadPercent:  10,
+	}
+}
+
+// ProgressionConfigFromEnv reads PROGRESSION_SCHEME, PROGRESSION_INCREMENT_KG,
+// PROGRESSION_INCREMENT_LB, PROGRESSION_REP_RANGE_MIN/MAX, PROGRESSION_TARGET_RPE,
+// PROGRESSION_RPE_STEP_PERCENT, PROGRESSION_DELOAD_AFTER and PROGRESSION_DELOAD_PERCENT
+// over the defaults
+func ProgressionConfigFromEnv() (ProgressionConfig, error) {
+	cfg := DefaultProgressionConfig()
+	if value := os.Getenv("PROGRESSION_SCHEME"); value != "" {
+		scheme, err := ParseProgressionScheme(value)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Scheme = scheme
+	}
+	cfg.IncrementKG = envFloat("PROGRESSION_INCREMENT_KG", cfg.IncrementKG)
+	cfg.IncrementLB = envFloat("PROGRESSION_INCREMENT_LB", cfg.IncrementLB)
+	cfg.RepRangeMin = envInt("PROGRESSION_REP_RANGE_MIN", cfg.RepRangeMin)
+	cfg.RepRangeMax = envInt("PROGRESSION_REP_RANGE_MAX", cfg.RepRangeMax)
+	cfg.TargetRPE = envFloat("PROGRESSION_TARGET_RPE", cfg.TargetRPE)
+	cfg.RPEStepPercent = envFloat("PROGRESSION_RPE_STEP_PERCENT", cfg.RPEStepPercent)
+	cfg.DeloadAfter = envInt("PROGRESSION_DELOAD_AFTER", cfg.DeloadAfter)
+	cfg.DeloadPercent = envFloat("PROGRESSION_DELOAD_PERCENT", cfg.DeloadPercent)
+
+	switch {
+	case cfg.IncrementKG <= 0 || cfg.IncrementLB <= 0:
+		return cfg, fmt.Errorf("progression increments must be positive")
+	case cfg.RepRangeMin < minReps || cfg.RepRangeMax > maxReps || cfg.RepRangeMin >= cfg.RepRangeMax:
+		return cfg, fmt.Errorf("invalid progression rep range %d-%d", cfg.RepRangeMin, cfg.RepRangeMax)
+	case cfg.TargetRPE < 1 || cfg.TargetRPE > 10:
+		return cfg, fmt.Errorf("progression target RPE must be between 1 and 10")
+	case cfg.RPEStepPercent <= 0 || cfg.RPEStepPercent >= 100:
+		return cfg, fmt.Errorf("PROGRESSION_RPE_STEP_PERCENT must be between 0 and 100")
+	case cfg.DeloadAfter < 1:
+		return cfg, fmt.Errorf("PROGRESSION_DELOAD_AFTER must be at least 1")
+	case cfg.DeloadPercent <= 0 || cfg.DeloadPercent >= 100:
+		return cfg, fmt.Errorf("PROGRESSION_DELOAD_PERCENT must be between 0 and 100")
+	}
+	return cfg, nil
+}
+
+// ParseProgressionScheme parses a progression scheme name
+func ParseProgressionScheme(value string) (ProgressionScheme, error) {
+	switch scheme := ProgressionScheme(strings.ToLower(strings.TrimSpace(value))); scheme {
+	case ProgressionLinear, ProgressionDouble, ProgressionRPE, ProgressionAuto, ProgressionOff:
+		return scheme, nil
+	default:
+		return "", fmt.Errorf("unknown progression scheme %q (expected linear, double, rpe, auto or off)", value)
+	}
+}
+
+// ExerciseTarget is the prescription of an exercise for its next session
+type ExerciseTarget struct {
+	Sets   int               `json:"sets"`
+	Reps   int               `json:"reps"`
+	Weight models.WeightInfo `json:"weight"`
+}
+
+// ExerciseProgression is the outcome of the engine for one exercise
+type ExerciseProgression struct {
+	ExerciseID   int               `json:"exerciseId"`
+	ExerciseName string            `json:"exerciseName"`
+	Scheme       ProgressionScheme `json:"scheme"`
+	Action       string            `json:"action"`
+	Reason       string            `json:"reason"`
+	Previous     ExerciseTarget    `json:"previous"`
+	Next         ExerciseTarget    `json:"next"`
+}
+
+// Changed reports whether the next target differs from the previous one
+func (p ExerciseProgression) Changed() bool {
+	return p.Next != p.Previous
+}
+
+// NextTarget computes the next-session target of an exercise from the sets logged for it
+// in each session, most recent session first. It makes no AI calls; the same input
+// always produces the same result.
+func NextTarget(exercise models.Exercise, sessions [][]models.SetLog, cfg ProgressionConfig) ExerciseProgression {
+	current := ExerciseTarget{Sets: exercise.Sets, Reps: exercise.Reps, Weight: exercise.Weight}
+	result := ExerciseProgression{
+		ExerciseID:   exercise.ID,
+		ExerciseName: exercise.Name,
+		Scheme:       cfg.Scheme,
+		Action:       ProgressionHold,
+		Previous:     current,
+		Next:         current,
+	}
+
+	if cfg.Scheme == ProgressionOff {
+		result.Reason = "progression is disabled"
+		return result
+	}
+	if exercise.Type == "cardio" || exercise.Type == "flexibility" {
+		result.Reason = "no progression for " + exercise.Type + " exercises"
+		return result
+	}
+	if len(sessions) == 0 || len(sessions[0]) == 0 {
+		result.Reason = "no sets logged"
+		return result
+	}
+
+	last := sessions[0]
+	if result.Scheme == ProgressionAuto {
+		result.Scheme = ProgressionDouble
+		if _, ok := averageRPE(last); ok {
+			result.Scheme = ProgressionRPE
+		}
+	}
+
+	bodyweight := exercise.Weight.Unit == WeightUnitBodyweight
+	if failures := failedSessions(sessions, exercise.Sets); failures > 0 {
+		if failures%cfg.DeloadAfter == 0 {
+			return deload(result, failures, bodyweight, cfg)
+		}
+		result.Reason = missedReason(failures)
+		if result.Scheme != ProgressionRPE || bodyweight {
+			return result
+		}
+		// Autoregulation may still lighten the load, but never adds to it after a miss
+		if adjusted := progressRPE(result, last, cfg); adjusted.Action == ProgressionDecreaseWeight {
+			return adjusted
+		}
+		return result
+	}
+
+	if bodyweight {
+		return progressBodyweight(result, cfg)
+	}
+
+	switch result.Scheme {
+	case ProgressionRPE:
+		return progressRPE(result, last, cfg)
+	case ProgressionDouble:
+		if current.Reps >= cfg.RepRangeMin && current.Reps <= cfg.RepRangeMax {
+			return progressDouble(result, cfg)
+		}
+		result.Scheme = ProgressionLinear
+	}
+	result.Action = ProgressionIncreaseWeight
+	result.Next.Weight.Value = clampWeight(roundToIncrement(current.Weight.Value+increment(current.Weight.Unit, cfg), current.Weight.Unit, cfg))
+	result.Reason = "all sets completed at the target"
+	return result
+}
+
+// progressDouble adds a rep until the top of the range, then a weight increment
+func progressDouble(result ExerciseProgression, cfg ProgressionConfig) ExerciseProgression {
+	current := result.Previous
+	if current.Reps < cfg.RepRangeMax {
+		result.Action = ProgressionIncreaseReps
+		result.Next.Reps = current.Reps + 1
+		result.Reason = fmt.Sprintf("all sets completed; working up to %d reps", cfg.RepRangeMax)
+		return result
+	}
+	result.Action = ProgressionIncreaseWeight
+	result.Next.Reps = cfg.RepRangeMin
+	result.Next.Weight.Value = clampWeight(roundToIncrement(current.Weight.Value+increment(current.Weight.Unit, cfg), current.Weight.Unit, cfg))
+	result.Reason = fmt.Sprintf("reached %d reps; adding weight and returning to %d reps", cfg.RepRangeMax, cfg.RepRangeMin)
+	return result
+}
+
+// progressRPE moves the weight RPEStepPercent for each point the session's average RPE
+// was below or above the target, by at least one increment
+func progressRPE(result ExerciseProgression, last []models.SetLog, cfg ProgressionConfig) ExerciseProgression {
+	rpe, ok := averageRPE(last)
+	if !ok {
+		result.Reason = "no RPE logged"
+		return result
+	}
+	current := result.Previous
+	diff := cfg.TargetRPE - rpe
+	if math.Abs(diff) < 0.5 {
+		result.Reason = fmt.Sprintf("average RPE %.1f is on target", rpe)
+		return result
+	}
+
+	step := increment(current.Weight.Unit, cfg)
+	change := current.Weight.Value * diff * cfg.RPEStepPercent / 100
+	if math.Abs(change) < step {
+		change = math.Copysign(step, diff)
+	}
+	next := clampWeight(roundToIncrement(current.Weight.Value+change, current.Weight.Unit, cfg))
+	result.Next.Weight.Value = next
+	switch {
+	case next > current.Weight.Value:
+		result.Action = ProgressionIncreaseWeight
+	case next < current.Weight.Value:
+		result.Action = ProgressionDecreaseWeight
+	}
+	result.Reason = fmt.Sprintf("average RPE %.1f against a target of %.1f", rpe, cfg.TargetRPE)
+	return result
+}
+
+// progressBodyweight adds reps up to the top of the range and then a set
+func progressBodyweight(result ExerciseProgression, cfg ProgressionConfig) ExerciseProgression {
+	current := result.Previous
+	switch {
+	case current.Reps < cfg.RepRangeMax:
+		result.Action = ProgressionIncreaseReps
+		result.Next.Reps = current.Reps + 1
+		result.Reason = "all sets completed; adding a rep"
+	case current.Sets < maxSets:
+		result.Action = ProgressionIncreaseSets
+		result.Next.Sets = current.Sets + 1
+		result.Next.Reps = cfg.RepRangeMin
+		result.Reason = fmt.Sprintf("reached %d reps; adding a set and returning to %d reps", cfg.RepRangeMax, cfg.RepRangeMin)
+	default:
+		result.Reason = "already at the maximum sets and reps"
+	}
+	return result
+}
+
+// deload reduces the weight by DeloadPercent, or a set for bodyweight exercises
+func deload(result ExerciseProgression, failures int, bodyweight bool, cfg ProgressionConfig) ExerciseProgression {
+	current := result.Previous
+	result.Action = ProgressionDeload
+	result.Reason = missedReason(failures)
+	if bodyweight {
+		if current.Sets > minSets {
+			result.Next.Sets = current.Sets - 1
+		}
+		return result
+	}
+	result.Next.Weight.Value = math.Max(0, roundToIncrement(current.Weight.Value*(1-cfg.DeloadPercent/100), current.Weight.Unit, cfg))
+	return result
+}
+
+// missedReason explains a run of failed sessions
+func missedReason(failures int) string {
+	if failures == 1 {
+		return "missed the target last session"
+	}
+	return fmt.Sprintf("missed the target in %d consecutive sessions", failures)
+}
+
+// failedSessions counts the consecutive most recent sessions that missed the target:
+// fewer sets than prescribed, or a set with fewer reps or less weight than the
+// prescription it was logged against
+func failedSessions(sessions [][]models.SetLog, sets int) int {
+	failures := 0
+	for _, logged := range sessions {
+		if !sessionFailed(logged, sets) {
+			break
+		}
+		failures++
+	}
+	return failures
+}
+
+func sessionFailed(logged []models.SetLog, sets int) bool {
+	if len(logged) < sets {
+		return true
+	}
+	for _, set := range logged {
+		if set.Reps < set.TargetReps {
+			return true
+		}
+		if set.Weight.Unit == set.TargetWeight.Unit && set.Weight.Value < set.TargetWeight.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// averageRPE returns the mean RPE of the sets that logged one
+func averageRPE(logged []models.SetLog) (float64, bool) {
+	total, count := 0.0, 0
+	for _, set := range logged {
+		if set.RPE != nil {
+			total += *set.RPE
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
+
+// increment returns the weight step for a unit
+func increment(unit string, cfg ProgressionConfig) float64 {
+	if unit == WeightUnitPounds {
+		return cfg.IncrementLB
+	}
+	return cfg.IncrementKG
+}
+
+// roundToIncrement rounds a weight to the nearest step for its unit
+func roundToIncrement(value float64, unit string, cfg ProgressionConfig) float64 {
+	step := increment(unit, cfg)
+	return math.Round(value/step) * step
+}
+
+// clampWeight keeps a weight within the plan validation limits
+func clampWeight(value float64) float64 {
+	return math.Min(math.Max(value, 0), maxWeightValue)
+}
+
+// PlanProgression computes the next targets for every exercise of a plan session from
+// completed workouts, most recent first. Only sessions logged against the exercise's
+// current ID are used.
+func PlanProgression(plan *models.WorkoutPlan, sessionID string, history []models.WorkoutLog, cfg ProgressionConfig) []ExerciseProgression {
+	session := findSession(plan, sessionID)
+	if session == nil {
+		return nil
+	}
+
+	logged := map[int][][]models.SetLog{}
+	for _, workout := range history {
+		if workout.PlanID != uint(plan.ID) || workout.Status != models.WorkoutLogCompleted {
+			continue
+		}
+		byExercise := map[int][]models.SetLog{}
+		for _, set := range workout.Sets {
+			byExercise[set.ExerciseID] = append(byExercise[set.ExerciseID], set)
+		}
+		for id, sets := range byExercise {
+			logged[id] = append(logged[id], sets)
+		}
+	}
+
+	progressions := make([]ExerciseProgression, 0, len(session.Exercises))
+	for _, exercise := range session.Exercises {
+		progressions = append(progressions, NextTarget(exercise, logged[exercise.ID], cfg))
+	}
+	return progressions
+}
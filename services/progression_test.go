@@ -0,0 +1,145 @@
+package services
+
+import (
+	"testing"
+
+	"fit-ai-api/models"
+)
+
+// loggedSets returns n sets of reps at weight, logged against a prescription of
+// targetReps at targetWeight, with rpe when it is positive
+func loggedSets(n, reps int, weight models.WeightInfo, targetReps int, targetWeight models.WeightInfo, rpe float64) []models.SetLog {
+	sets := make([]models.SetLog, n)
+	for i := range sets {
+		sets[i] = models.SetLog{SetNumber: i + 1, Reps: reps, Weight: weight, TargetReps: targetReps, TargetWeight: targetWeight}
+		if rpe > 0 {
+			value := rpe
+			sets[i].RPE = &value
+		}
+	}
+	return sets
+}
+
+func kg(value float64) models.WeightInfo {
+	return models.WeightInfo{Value: value, Unit: WeightUnitKilograms}
+}
+
+func TestNextTarget(t *testing.T) {
+	bodyweight := models.WeightInfo{Unit: WeightUnitBodyweight}
+	lb := func(value float64) models.WeightInfo { return models.WeightInfo{Value: value, Unit: WeightUnitPounds} }
+	hit := func(sets, reps int, weight models.WeightInfo, rpe float64) []models.SetLog {
+		return loggedSets(sets, reps, weight, reps, weight, rpe)
+	}
+	missed := loggedSets(3, 3, kg(100), 5, kg(100), 0)
+
+	tests := []struct {
+		name     string
+		scheme   ProgressionScheme
+		exercise models.Exercise
+		sessions [][]models.SetLog
+		action   string
+		resolved ProgressionScheme
+		next     ExerciseTarget
+	}{
+		{"linear adds an increment", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(3, 5, kg(100), 0)}, ProgressionIncreaseWeight, ProgressionLinear, ExerciseTarget{3, 5, kg(102.5)}},
+		{"linear in pounds", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: lb(135)},
+			[][]models.SetLog{hit(3, 5, lb(135), 0)}, ProgressionIncreaseWeight, ProgressionLinear, ExerciseTarget{3, 5, lb(140)}},
+		{"double adds a rep", ProgressionDouble, models.Exercise{Sets: 3, Reps: 8, Weight: kg(60)},
+			[][]models.SetLog{hit(3, 8, kg(60), 0)}, ProgressionIncreaseReps, ProgressionDouble, ExerciseTarget{3, 9, kg(60)}},
+		{"double adds weight at the top of the range", ProgressionDouble, models.Exercise{Sets: 3, Reps: 12, Weight: kg(60)},
+			[][]models.SetLog{hit(3, 12, kg(60), 0)}, ProgressionIncreaseWeight, ProgressionDouble, ExerciseTarget{3, 8, kg(62.5)}},
+		{"double outside the range is linear", ProgressionDouble, models.Exercise{Sets: 5, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(5, 5, kg(100), 0)}, ProgressionIncreaseWeight, ProgressionLinear, ExerciseTarget{5, 5, kg(102.5)}},
+		{"extra sets still count as a success", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(4, 5, kg(100), 0)}, ProgressionIncreaseWeight, ProgressionLinear, ExerciseTarget{3, 5, kg(102.5)}},
+		{"rpe below target adds weight", ProgressionRPE, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(3, 5, kg(100), 6)}, ProgressionIncreaseWeight, ProgressionRPE, ExerciseTarget{3, 5, kg(105)}},
+		{"rpe above target removes weight", ProgressionRPE, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(3, 5, kg(100), 9.5)}, ProgressionDecreaseWeight, ProgressionRPE, ExerciseTarget{3, 5, kg(95)}},
+		{"rpe on target holds", ProgressionRPE, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(3, 5, kg(100), 8.2)}, ProgressionHold, ProgressionRPE, ExerciseTarget{3, 5, kg(100)}},
+		{"rpe changes by at least an increment", ProgressionRPE, models.Exercise{Sets: 3, Reps: 10, Weight: kg(20)},
+			[][]models.SetLog{hit(3, 10, kg(20), 7)}, ProgressionIncreaseWeight, ProgressionRPE, ExerciseTarget{3, 10, kg(22.5)}},
+		{"rpe without logged RPE holds", ProgressionRPE, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(3, 5, kg(100), 0)}, ProgressionHold, ProgressionRPE, ExerciseTarget{3, 5, kg(100)}},
+		{"auto uses rpe when logged", ProgressionAuto, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(3, 5, kg(100), 6)}, ProgressionIncreaseWeight, ProgressionRPE, ExerciseTarget{3, 5, kg(105)}},
+		{"auto uses double otherwise", ProgressionAuto, models.Exercise{Sets: 3, Reps: 10, Weight: kg(40)},
+			[][]models.SetLog{hit(3, 10, kg(40), 0)}, ProgressionIncreaseReps, ProgressionDouble, ExerciseTarget{3, 11, kg(40)}},
+		{"bodyweight adds a rep", ProgressionLinear, models.Exercise{Sets: 3, Reps: 10, Weight: bodyweight, Type: "bodyweight"},
+			[][]models.SetLog{hit(3, 10, bodyweight, 0)}, ProgressionIncreaseReps, ProgressionLinear, ExerciseTarget{3, 11, bodyweight}},
+		{"bodyweight adds a set at the top of the range", ProgressionRPE, models.Exercise{Sets: 3, Reps: 12, Weight: bodyweight, Type: "bodyweight"},
+			[][]models.SetLog{hit(3, 12, bodyweight, 7)}, ProgressionIncreaseSets, ProgressionRPE, ExerciseTarget{4, 8, bodyweight}},
+		{"bodyweight stops at the maximum", ProgressionDouble, models.Exercise{Sets: maxSets, Reps: 12, Weight: bodyweight, Type: "bodyweight"},
+			[][]models.SetLog{hit(maxSets, 12, bodyweight, 0)}, ProgressionHold, ProgressionDouble, ExerciseTarget{maxSets, 12, bodyweight}},
+		{"missed reps hold", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{missed}, ProgressionHold, ProgressionLinear, ExerciseTarget{3, 5, kg(100)}},
+		{"missed sets hold", ProgressionDouble, models.Exercise{Sets: 3, Reps: 8, Weight: kg(60)},
+			[][]models.SetLog{hit(2, 8, kg(60), 0)}, ProgressionHold, ProgressionDouble, ExerciseTarget{3, 8, kg(60)}},
+		{"lighter weight than prescribed holds", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{loggedSets(3, 5, kg(90), 5, kg(100), 0)}, ProgressionHold, ProgressionLinear, ExerciseTarget{3, 5, kg(100)}},
+		{"two misses hold", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{missed, missed, hit(3, 5, kg(97.5), 0)}, ProgressionHold, ProgressionLinear, ExerciseTarget{3, 5, kg(100)}},
+		{"three misses deload", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{missed, missed, missed}, ProgressionDeload, ProgressionLinear, ExerciseTarget{3, 5, kg(90)}},
+		{"six misses deload again", ProgressionDouble, models.Exercise{Sets: 3, Reps: 5, Weight: kg(90)},
+			[][]models.SetLog{missed, missed, missed, missed, missed, missed}, ProgressionDeload, ProgressionDouble, ExerciseTarget{3, 5, kg(80)}},
+		{"bodyweight deloads a set", ProgressionLinear, models.Exercise{Sets: 3, Reps: 10, Weight: bodyweight, Type: "bodyweight"},
+			[][]models.SetLog{hit(2, 10, bodyweight, 0), hit(1, 10, bodyweight, 0), hit(2, 10, bodyweight, 0)}, ProgressionDeload, ProgressionLinear, ExerciseTarget{2, 10, bodyweight}},
+		{"rpe may lighten the load after a miss", ProgressionRPE, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{loggedSets(3, 4, kg(100), 5, kg(100), 10)}, ProgressionDecreaseWeight, ProgressionRPE, ExerciseTarget{3, 5, kg(95)}},
+		{"rpe never adds weight after a miss", ProgressionRPE, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{loggedSets(3, 4, kg(100), 5, kg(100), 6)}, ProgressionHold, ProgressionRPE, ExerciseTarget{3, 5, kg(100)}},
+		{"cardio is left unchanged", ProgressionLinear, models.Exercise{Sets: 1, Reps: 20, Weight: bodyweight, Type: "cardio"},
+			[][]models.SetLog{hit(1, 20, bodyweight, 0)}, ProgressionHold, ProgressionLinear, ExerciseTarget{1, 20, bodyweight}},
+		{"off is left unchanged", ProgressionOff, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			[][]models.SetLog{hit(3, 5, kg(100), 0)}, ProgressionHold, ProgressionOff, ExerciseTarget{3, 5, kg(100)}},
+		{"no sets logged", ProgressionLinear, models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)},
+			nil, ProgressionHold, ProgressionLinear, ExerciseTarget{3, 5, kg(100)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultProgressionConfig()
+			cfg.Scheme = tt.scheme
+			got := NextTarget(tt.exercise, tt.sessions, cfg)
+			if got.Action != tt.action || got.Scheme != tt.resolved || got.Next != tt.next {
+				t.Errorf("NextTarget = %s (%s) to %+v, want %s (%s) to %+v; reason %q", got.Action, got.Scheme, got.Next, tt.action, tt.resolved, tt.next, got.Reason)
+			}
+			if got.Changed() != (tt.next != got.Previous) {
+				t.Errorf("Changed() = %v", got.Changed())
+			}
+		})
+	}
+}
+
+func TestProgressionConfigFromEnv(t *testing.T) {
+	t.Setenv("PROGRESSION_SCHEME", "RPE")
+	t.Setenv("PROGRESSION_RPE_STEP_PERCENT", "5")
+	cfg, err := ProgressionConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Scheme != ProgressionRPE || cfg.RPEStepPercent != 5 {
+		t.Fatalf("config = %+v", cfg)
+	}
+	// 2 points below the target at 5% per point
+	got := NextTarget(models.Exercise{Sets: 3, Reps: 5, Weight: kg(100)}, [][]models.SetLog{loggedSets(3, 5, kg(100), 5, kg(100), 6)}, cfg)
+	if got.Next.Weight != kg(110) {
+		t.Errorf("next weight = %+v, want 110 KG", got.Next.Weight)
+	}
+
+	for name, value := range map[string]string{
+		"PROGRESSION_RPE_STEP_PERCENT": "0",
+		"PROGRESSION_DELOAD_PERCENT":   "100",
+		"PROGRESSION_REP_RANGE_MIN":    "15",
+		"PROGRESSION_SCHEME":           "wave",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := ProgressionConfigFromEnv(); err == nil {
+				t.Errorf("%s=%s was accepted", name, value)
+			}
+		})
+	}
+}
@@ -11,14 +11,15 @@ CORE PRINCIPLES:
 - Mix of compound and isolation exercises
 - Consider user's equipment and goals`
 
-// WorkoutPlanTemplate is the template for generating workout plans
+// WorkoutPlanTemplate is the template for generating workout plans.
+// The final placeholder receives the JSON Schema derived from models.WorkoutPlan.
 const WorkoutPlanTemplate = `Generate a personalized workout plan for this user:
 PROFILE: %s, %s, %s, Fitness: %s, Goals: %v, Equipment: %v, Units: %s
 STATS: %d workouts, %d streak, %d total time, %d volume
 
 REQUIREMENTS:
 - Create 3-6 workout sessions per week
-- Each session: 4-8 exercises with warmups
+- Each session: 4-8 exercises
 - Balance push/pull movements across the week
 - Include weight, bodyweight, cardio, and flexibility exercises
 - Start with compound movements, then isolation
@@ -34,109 +35,91 @@ REST PERIODS:
 - Compound: 2-4 minutes (strength) or 1-2 minutes (hypertrophy)
 - Isolation: 60-90 seconds
 
-Generate this JSON format:
-
-{
-  "id": 1,
-  "name": "Professional Plan Name",
-  "description": "Comprehensive description of plan approach, methodology, expected results, and timeline.",
-  "createdAt": "2024-01-15T00:00:00.000Z",
-  "aiFeedbackCycle": 12,
-  "planValidityPeriod": 28,
-  "sessionsCompleted": 0,
-  "planStartDate": "2024-01-15T00:00:00.000Z",
-  "hasNewPlanSuggestion": false,
-  "suggestedPlan": null,
-  "sessions": [
-    {
-      "id": "session_1",
-      "name": "Upper Body Power",
-      "note": "Focus on chest, shoulders, triceps. Start compound, then isolation. Rest 2-3 min compound, 60-90 sec isolation.",
-      "warmups": [
-        {
-          "id": "warmup_1",
-          "name": "Arm Circles",
-          "sets": 2,
-          "reps": 10,
-          "duration": 30,
-          "weight": {"value": 0, "unit": "BODYWEIGHT"},
-          "type": "bodyweight",
-          "note": "Forward/backward circles to warm up shoulders",
-          "equipment": "bodyweight"
-        }
-      ],
-      "exercises": [
-        {
-          "id": 1,
-          "name": "Barbell Bench Press",
-          "sets": 4,
-          "reps": 8,
-          "weight": {"value": 185, "unit": "LB"},
-          "type": "weight",
-          "equipment": "barbell",
-          "note": "Compound chest exercise. Controlled descent, explosive press. Keep feet flat, maintain arch."
-        }
-      ]
-    },
-    {
-      "id": "session_2",
-      "name": "Lower Body Strength",
-      "note": "Focus on legs and core. Start squats, then deadlifts. Rest 3-4 min main lifts. Focus form and depth.",
-      "warmups": [
-        {
-          "id": "warmup_2",
-          "name": "Bodyweight Squats",
-          "sets": 2,
-          "reps": 12,
-          "weight": {"value": 0, "unit": "BODYWEIGHT"},
-          "type": "bodyweight",
-          "note": "Focus form and depth to warm up legs",
-          "equipment": "bodyweight"
-        }
-      ],
-      "exercises": [
-        {
-          "id": 2,
-          "name": "Barbell Squat",
-          "sets": 4,
-          "reps": 8,
-          "weight": {"value": 225, "unit": "LB"},
-          "type": "weight",
-          "equipment": "barbell",
-          "note": "Keep chest up, knees in line with toes. Go parallel or below."
-        }
-      ]
-    },
-    {
-      "id": "session_3",
-      "name": "Pull Day",
-      "note": "Focus back and biceps. Start pull-ups, then rows. Control negative portion. Squeeze shoulder blades.",
-      "warmups": [
-        {
-          "id": "warmup_3",
-          "name": "Band Pull-Aparts",
-          "sets": 2,
-          "reps": 12,
-          "weight": {"value": 0, "unit": "BODYWEIGHT"},
-          "type": "bodyweight",
-          "note": "Shoulder blade activation for pulling movements",
-          "equipment": "resistance band"
-        }
-      ],
-      "exercises": [
-        {
-          "id": 3,
-          "name": "Pull-ups",
-          "sets": 4,
-          "reps": 8,
-          "weight": {"value": 0, "unit": "BODYWEIGHT"},
-          "type": "bodyweight",
-          "equipment": "pull-up bar",
-          "note": "Pull chest to bar, control descent. Full range of motion."
-        }
-      ]
-    }
-  ]
-}
-
-IMPORTANT: Create 3-6 sessions (not 1-2). Each session complete with warmups and exercises. Use specific exercise names with equipment. Include detailed form cues and safety notes. Return only JSON.`
+FIELD GUIDANCE:
+- name: professional plan name; description: approach, methodology, expected results and timeline
+- aiFeedbackCycle: sessions before the plan is reviewed (e.g. 12); planValidityPeriod: days the plan runs (e.g. 28)
+- sessionsCompleted: 0; hasNewPlanSuggestion: false; suggestedPlan: null
+- session id: "session_1", "session_2", ...; session note: focus, ordering and rest guidance
+- exercise id: unique integer across the whole plan; name: specific exercise name including equipment
+- weight unit: LB or KG matching the user's units, or BODYWEIGHT with value 0
+
+Return a single JSON object that conforms to this JSON Schema:
+%s
+
+IMPORTANT: Create 3-6 sessions (not 1-2). Each session complete with exercises. Use specific exercise names with equipment. Return only JSON.`
+
+// RepairPromptTemplate asks the model to correct a reply that could not be used
+const RepairPromptTemplate = `Your previous response could not be used because of these problems:
+%s
+
+Fix every problem and return the complete corrected %s as a single JSON object. Do not wrap it in markdown code fences and do not add any text before or after the JSON.`
+
+// SuggestedPlanPrompt is the system prompt for reviewing a plan against training history
+const SuggestedPlanPrompt = `You are an expert fitness trainer with 20+ years experience reviewing a client's progress on their current workout plan. Use the logged training history to decide how the plan should evolve and return the suggested next plan in JSON format only. Return valid JSON matching the exact structure requested.
+
+REVIEW PRINCIPLES:
+- Progress exercises the client completed comfortably (all reps, RPE 8 or lower)
+- Hold or reduce load where reps were missed or RPE was 9-10
+- Replace exercises that were repeatedly skipped
+- Keep the plan consistent with the client's goals, equipment and schedule
+- Explain every change in terms of the logged results`
+
+// SuggestedPlanTemplate is the template for suggesting the next plan. The placeholders
+// are the profile, the review trigger, the current plan, the training history and the
+// JSON Schema derived from models.SuggestedPlan.
+const SuggestedPlanTemplate = `Review this client's current plan and suggest the next one:
+PROFILE: %s, Fitness: %s, Goals: %v, Equipment: %v, Units: %s
+REVIEW TRIGGER: %s
+
+CURRENT PLAN:
+%s
+
+TRAINING HISTORY (most recent first):
+%s
+
+REQUIREMENTS:
+- 3-6 sessions, each with 4-8 exercises
+- reason: why a new plan is suggested now, citing the history
+- improvements: one short sentence per concrete change
+- comparison.current describes the current plan and comparison.suggested the new one;
+  sessions is the number of sessions per week, duration the typical session length
+  (e.g. "45-60 min"), difficulty one of Beginner, Intermediate or Advanced
+- session id: "session_1", "session_2", ...; exercise id: unique integer across the plan
+- weight unit: LB or KG matching the user's units, or BODYWEIGHT with value 0
+
+Return a single JSON object that conforms to this JSON Schema:
+%s
+
+Return only JSON.`
+
+// ExerciseAlternativesPrompt is the system prompt for suggesting substitutes for one
+// exercise of a plan
+const ExerciseAlternativesPrompt = `You are an expert fitness trainer with 20+ years experience. A client needs to swap one exercise of their workout plan for another that trains the same muscles with the same movement pattern. Suggest substitutes in JSON format only. Return valid JSON matching the exact structure requested.
+
+SUBSTITUTION PRINCIPLES:
+- Match the primary muscles and movement pattern of the original exercise
+- Use only the client's equipment, or bodyweight
+- Respect the reason for the swap: avoid the same machine when it is taken, and movements loading the same painful joint when an exercise hurts
+- Do not suggest exercises already in the session`
+
+// ExerciseAlternativesTemplate is the template for suggesting substitutes. The
+// placeholders are the profile, the exercise, what the catalog knows about it, the
+// session, the reason for the swap, the number of suggestions and the JSON Schema of
+// the reply.
+const ExerciseAlternativesTemplate = `Suggest substitutes for this exercise:
+PROFILE: Fitness: %s, Goals: %v, Equipment: %v
+EXERCISE: %s (%s), %d x %d @ %s
+CATALOG: %s
+SESSION: %s
+REASON FOR THE SWAP: %s
+
+REQUIREMENTS:
+- up to %d alternatives, best first, each a different exercise
+- name: the common name of the exercise, including its equipment (e.g. "Dumbbell Bench Press")
+- type: weight for loaded exercises, bodyweight, cardio or flexibility
+- reason: one short sentence on why it is a good substitute
+
+Return a single JSON object that conforms to this JSON Schema:
+%s
+
+Return only JSON.`
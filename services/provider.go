@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ChatMessage is a single message in a chat completion conversation
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is a provider-agnostic chat completion request
+type ChatRequest struct {
+	Messages    []ChatMessage
+	Temperature float64
+	MaxTokens   int
+	// JSON asks the provider to constrain its output to a JSON object when supported
+	JSON bool
+	// Schema asks the provider to constrain its output to this schema using its native
+	// structured output mechanism; providers without one fall back to JSON mode
+	Schema *ResponseSchema
+}
+
+// ResponseSchema names a JSON Schema the reply must conform to
+type ResponseSchema struct {
+	Name        string
+	Description string
+	Schema      map[string]interface{}
+}
+
+// StructuredOutputMode is how a provider enforces a ResponseSchema
+type StructuredOutputMode string
+
+const (
+	// StructuredOutputNone ignores the schema; the prompt alone describes the format
+	StructuredOutputNone StructuredOutputMode = "none"
+	// StructuredOutputJSONSchema uses the response_format json_schema parameter
+	StructuredOutputJSONSchema StructuredOutputMode = "json_schema"
+	// StructuredOutputTool forces a single function call whose parameters are the schema
+	StructuredOutputTool StructuredOutputMode = "tool"
+)
+
+// Usage holds token accounting reported by a provider
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// ChatResponse is the text and metadata returned by a provider
+type ChatResponse struct {
+	Content string
+	Model   string
+	Usage   Usage
+}
+
+// Provider is a chat completion backend used for workout plan generation
+type Provider interface {
+	// Name returns the identifier the provider is registered under
+	Name() AIProvider
+	// ChatCompletion sends the conversation to the model and returns its reply
+	ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+}
+
+// StreamingProvider is a provider that can stream its reply as it is generated
+type StreamingProvider interface {
+	Provider
+	// ChatCompletionStream behaves like ChatCompletion but calls onProgress with the
+	// accumulated reply text each time a new chunk arrives
+	ChatCompletionStream(ctx context.Context, req ChatRequest, onProgress func(text string)) (*ChatResponse, error)
+}
+
+// ProviderFactory builds a provider from the environment, sharing the given HTTP client
+type ProviderFactory func(client *http.Client) (Provider, error)
+
+var (
+	providerMu        sync.RWMutex
+	providerFactories = map[AIProvider]ProviderFactory{}
+)
+
+// RegisterProvider makes a provider available under the given name.
+// Registering the same name twice replaces the previous factory.
+func RegisterProvider(name AIProvider, factory ProviderFactory) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	providerFactories[name] = factory
+}
+
+// NewProvider builds the provider registered under the given name
+func NewProvider(name AIProvider, client *http.Client) (Provider, error) {
+	providerMu.RLock()
+	factory, ok := providerFactories[name]
+	providerMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported AI provider: %s", name)
+	}
+	return factory(client)
+}
+
+// RegisteredProviders returns the names of all registered providers
+func RegisteredProviders() []AIProvider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+
+	names := make([]AIProvider, 0, len(providerFactories))
+	for name := range providerFactories {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// retryPolicy bounds how often a single provider is retried on transient errors
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// newRetryPolicyFromEnv reads AI_MAX_RETRIES, AI_RETRY_BASE_DELAY and AI_RETRY_MAX_DELAY
+func newRetryPolicyFromEnv() retryPolicy {
+	return retryPolicy{
+		maxRetries: envInt("AI_MAX_RETRIES", 2),
+		baseDelay:  envDuration("AI_RETRY_BASE_DELAY", 500*time.Millisecond),
+		maxDelay:   envDuration("AI_RETRY_MAX_DELAY", 10*time.Second),
+	}
+}
+
+// backoff returns the delay before the given retry (0-based) using full jitter.
+// A Retry-After value from the provider takes precedence.
+func (p retryPolicy) backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	ceiling := p.baseDelay << retry
+	if ceiling <= 0 || ceiling > p.maxDelay {
+		ceiling = p.maxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// callWithRetry calls the provider, retrying transient failures with jittered backoff.
+// It gives up early when the provider asks to wait longer than the maximum delay.
+func (ai *AIService) callWithRetry(ctx context.Context, provider Provider, req ChatRequest, onProgress func(text string)) (*ChatResponse, error) {
+	for retry := 0; ; retry++ {
+		response, err := ai.callOnce(ctx, provider, req, onProgress)
+		if err == nil {
+			return response, nil
+		}
+
+		// A cancelled or expired parent context is not a provider failure
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		var providerErr *ProviderError
+		if !errors.As(err, &providerErr) || !providerErr.Retryable() || retry >= ai.retry.maxRetries {
+			return nil, err
+		}
+
+		delay := ai.retry.backoff(retry, providerErr.RetryAfter)
+		if delay > ai.retry.maxDelay {
+			return nil, err
+		}
+
+		log.Printf("AI provider %s failed (%s), retrying in %s: %v", provider.Name(), providerErr.Kind, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// callOnce makes a single provider call bounded by the per-request timeout, streaming
+// when onProgress is set and the provider supports it
+func (ai *AIService) callOnce(ctx context.Context, provider Provider, req ChatRequest, onProgress func(text string)) (*ChatResponse, error) {
+	if ai.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ai.requestTimeout)
+		defer cancel()
+	}
+
+	if streaming, ok := provider.(StreamingProvider); ok && onProgress != nil {
+		return streaming.ChatCompletionStream(ctx, req, onProgress)
+	}
+
+	response, err := provider.ChatCompletion(ctx, req)
+	if err == nil && onProgress != nil {
+		onProgress(response.Content)
+	}
+	return response, err
+}
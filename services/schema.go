@@ -0,0 +1,101 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchemaFor derives a JSON Schema from a Go type using its json tags.
+// Every property is required and objects are closed, which is what OpenAI's strict
+// structured outputs expect. Pointers become nullable and an `enum:"A,B"` tag restricts
+// string values.
+func JSONSchemaFor(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{
+			"anyOf": []interface{}{
+				JSONSchemaFor(t.Elem()),
+				map[string]interface{}{"type": "null"},
+			},
+		}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{
+				"type":        "string",
+				"description": "RFC 3339 timestamp, e.g. 2024-01-15T00:00:00Z",
+			}
+		}
+		return structSchema(t)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": JSONSchemaFor(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": JSONSchemaFor(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema builds an object schema from the exported, json-tagged fields of t
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	addStructFields(t, properties, &required)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// addStructFields collects the properties of t, flattening embedded structs the way
+// encoding/json does
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.Tag.Get("jsonschema") == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := JSONSchemaFor(field.Type)
+		if enum := field.Tag.Get("enum"); enum != "" {
+			schema["enum"] = strings.Split(enum, ",")
+		}
+
+		properties[name] = schema
+		*required = append(*required, name)
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"fit-ai-api/models"
+)
+
+// sessionsArrayPattern finds the start of the "sessions" array in a partial plan
+var sessionsArrayPattern = regexp.MustCompile(`"sessions"\s*:\s*\[`)
+
+// sessionStreamParser picks complete sessions out of a workout plan that is still
+// being streamed, so they can be forwarded before the whole plan has arrived
+type sessionStreamParser struct {
+	emitted int
+}
+
+// streamedSession is a session completed in the stream with its 1-based position in
+// the plan
+type streamedSession struct {
+	Index   int
+	Session models.WorkoutSession
+}
+
+// Feed takes the reply accumulated so far and returns the sessions that became
+// complete since the last call, in plan order
+func (p *sessionStreamParser) Feed(text string) []streamedSession {
+	loc := sessionsArrayPattern.FindStringIndex(text)
+	if loc == nil {
+		return nil
+	}
+
+	var sessions []streamedSession
+	rest := text[loc[1]:]
+	for index := 0; ; index++ {
+		object, end, ok := nextArrayObject(rest)
+		if !ok {
+			break
+		}
+		rest = rest[end:]
+
+		if index < p.emitted {
+			continue
+		}
+
+		var session models.WorkoutSession
+		if err := json.Unmarshal([]byte(object), &session); err != nil {
+			break
+		}
+		sessions = append(sessions, streamedSession{Index: index + 1, Session: session})
+		p.emitted++
+	}
+	return sessions
+}
+
+// Reset forgets the sessions emitted so far, used when the model starts a new reply
+func (p *sessionStreamParser) Reset() {
+	p.emitted = 0
+}
+
+// nextArrayObject returns the next complete object in the remainder of a JSON array
+// along with the offset just past it. It stops at the end of the array or when the
+// next object is still incomplete.
+func nextArrayObject(text string) (string, int, bool) {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case ' ', '\t', '\n', '\r', ',':
+			continue
+		case '{':
+			object, ok := scanJSONObject(text[i:])
+			if !ok {
+				return "", 0, false
+			}
+			return object, i + len(object), true
+		default:
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSessionStreamParser(t *testing.T) {
+	const (
+		push = `{"name": "Push", "exercises": [{"name": "Bench Press", "sets": 3}]}`
+		pull = `{"name": "Pull", "exercises": []}`
+		legs = `{"name": "Legs", "exercises": []}`
+	)
+	prefix := `{"name": "PPL", "sessions": [`
+
+	// Each step feeds the reply accumulated so far
+	steps := []struct {
+		name  string
+		text  string
+		want  []string
+		index []int
+	}{
+		{"before the sessions array", `{"name": "PPL", "sess`, nil, nil},
+		{"incomplete first session", prefix + push[:20], nil, nil},
+		{"two sessions in one chunk", prefix + push + ", " + pull, []string{"Push", "Pull"}, []int{1, 2}},
+		{"no new session", prefix + push + ", " + pull + ", " + legs[:10], nil, nil},
+		{"third session", prefix + push + ", " + pull + ", " + legs + "]}", []string{"Legs"}, []int{3}},
+	}
+
+	parser := &sessionStreamParser{}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			got := parser.Feed(step.text)
+			if len(got) != len(step.want) {
+				t.Fatalf("Feed returned %d sessions, want %d", len(got), len(step.want))
+			}
+			for i, streamed := range got {
+				if streamed.Session.Name != step.want[i] || streamed.Index != step.index[i] {
+					t.Errorf("session %d = %q at index %d, want %q at index %d", i, streamed.Session.Name, streamed.Index, step.want[i], step.index[i])
+				}
+			}
+		})
+	}
+
+	// A new reply after a repair is numbered from the start again
+	parser.Reset()
+	got := parser.Feed(prefix + strings.Join([]string{push, pull}, ","))
+	if len(got) != 2 || got[0].Index != 1 || got[1].Index != 2 {
+		t.Errorf("Feed after Reset = %+v, want sessions 1 and 2", got)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"fit-ai-api/models"
+)
+
+// maxHistoryWorkouts bounds how many logged workouts are sent with a review prompt
+const maxHistoryWorkouts = 30
+
+// suggestedPlanSchema is derived from models.SuggestedPlan like workoutPlanSchema
+var (
+	suggestedPlanSchema = &ResponseSchema{
+		Name:        "suggested_plan",
+		Description: "The next workout plan suggested from the client's training history",
+		Schema:      JSONSchemaFor(reflect.TypeOf(models.SuggestedPlan{})),
+	}
+	suggestedPlanSchemaJSON = mustMarshalIndent(suggestedPlanSchema.Schema)
+)
+
+// SuggestionResult is a suggested plan together with its generation metadata
+type SuggestionResult struct {
+	Suggestion *models.SuggestedPlan
+	Metadata   GenerationMetadata
+}
+
+// GenerateSuggestedPlan asks the model for the plan that should follow plan, based on the
+// user's profile and the workouts logged for it. trigger explains why the plan is being
+// reviewed. Replies are validated and repaired like generated plans.
+func (ai *AIService) GenerateSuggestedPlan(ctx context.Context, userData models.UserData, plan *models.WorkoutPlan, history []models.WorkoutLog, trigger string) (*SuggestionResult, error) {
+	if ai.generationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ai.generationTimeout)
+		defer cancel()
+	}
+
+	user := userData.Data
+	messages := []ChatMessage{
+		{
+			Role:    "system",
+			Content: SuggestedPlanPrompt,
+		},
+		{
+			Role: "user",
+			Content: fmt.Sprintf(SuggestedPlanTemplate,
+				user.FullName,
+				user.FitnessLevel,
+				user.Goals,
+				user.Equipment,
+				user.Preferences.Units,
+				trigger,
+				describePlan(plan),
+				describeHistory(history),
+				suggestedPlanSchemaJSON),
+		},
+	}
+
+	var suggestion *models.SuggestedPlan
+	metadata, err := ai.generateWithRepair(ctx, messages, suggestedPlanSchema, "suggested plan", nil, nil,
+		func(content string) error {
+			payload, err := extractJSONPayload(content)
+			if err != nil {
+				return err
+			}
+			var parsed models.SuggestedPlan
+			if err := json.Unmarshal([]byte(payload), &parsed); err != nil {
+				return fmt.Errorf("failed to unmarshal AI response: %w", err)
+			}
+			if violations := ValidateSuggestedPlan(&parsed); len(violations) > 0 {
+				return &PlanValidationError{Violations: violations}
+			}
+			suggestion = &parsed
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// Session counts are known exactly; don't trust the model with them
+	suggestion.ID = fmt.Sprintf("suggestion_%d_%d", plan.ID, time.Now().Unix())
+	suggestion.Comparison.Current.Sessions = len(plan.Sessions)
+	suggestion.Comparison.Suggested.Sessions = len(suggestion.Sessions)
+
+	return &SuggestionResult{
+		Suggestion: suggestion,
+		Metadata:   metadata,
+	}, nil
+}
+
+// describePlan renders a plan as compact text for a prompt, one line per exercise
+func describePlan(plan *models.WorkoutPlan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: started %s, %d sessions completed, review every %d sessions, valid for %d days\n",
+		plan.Name,
+		plan.PlanStartDate.UTC().Format(time.DateOnly),
+		plan.SessionsCompleted,
+		plan.AIFeedbackCycle,
+		plan.PlanValidityPeriod)
+	for _, session := range plan.Sessions {
+		fmt.Fprintf(&b, "- %s\n", session.Name)
+		for _, exercise := range session.Exercises {
+			fmt.Fprintf(&b, "  - %s: %d x %d @ %s\n", exercise.Name, exercise.Sets, exercise.Reps, formatWeight(exercise.Weight))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// describeHistory renders completed workouts as compact text for a prompt, listing the
+// sets of each exercise in the order they were performed
+func describeHistory(history []models.WorkoutLog) string {
+	var b strings.Builder
+	count := 0
+	for _, workout := range history {
+		if workout.Status != models.WorkoutLogCompleted || workout.CompletedAt == nil {
+			continue
+		}
+		if count == maxHistoryWorkouts {
+			break
+		}
+		count++
+
+		fmt.Fprintf(&b, "%s %s (%d min, volume %.0f KG)\n",
+			workout.CompletedAt.UTC().Format(time.DateOnly),
+			workout.SessionName,
+			workout.DurationSeconds/60,
+			workout.TotalVolume)
+
+		var order []string
+		sets := map[string][]string{}
+		for _, set := range workout.Sets {
+			if _, ok := sets[set.ExerciseName]; !ok {
+				order = append(order, set.ExerciseName)
+			}
+			entry := fmt.Sprintf("%d @ %s", set.Reps, formatWeight(set.Weight))
+			if set.RPE != nil {
+				entry += fmt.Sprintf(" RPE %g", *set.RPE)
+			}
+			sets[set.ExerciseName] = append(sets[set.ExerciseName], entry)
+		}
+		for _, name := range order {
+			fmt.Fprintf(&b, "  - %s: %s\n", name, strings.Join(sets[name], ", "))
+		}
+	}
+
+	if count == 0 {
+		return "No completed workouts logged."
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatWeight renders a weight such as "60 KG" or "bodyweight"
+func formatWeight(weight models.WeightInfo) string {
+	if weight.Unit == WeightUnitBodyweight {
+		return "bodyweight"
+	}
+	return fmt.Sprintf("%g %s", weight.Value, weight.Unit)
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+var (
+	// ErrSessionNotFound is returned when a plan has no session with the requested ID
+	ErrSessionNotFound = errors.New("session not found in workout plan")
+	// ErrExerciseNotFound is returned when a set is logged for an exercise that is not
+	// part of the workout's session
+	ErrExerciseNotFound = errors.New("exercise not found in session")
+	// ErrWorkoutCompleted is returned when a completed workout is changed
+	ErrWorkoutCompleted = errors.New("workout is already completed")
+	// ErrInvalidSet is returned when a logged set has out-of-range values
+	ErrInvalidSet = errors.New("invalid set")
+)
+
+// SetInput is a set performed by the user
+type SetInput struct {
+	ExerciseID      int               `json:"exerciseId"`
+	Reps            int               `json:"reps"`
+	Weight          models.WeightInfo `json:"weight"`
+	RPE             *float64          `json:"rpe,omitempty"`
+	DurationSeconds int               `json:"durationSeconds"`
+}
+
+// CompleteInput holds the optional details sent when finishing a workout
+type CompleteInput struct {
+	// DurationSeconds overrides the time measured since the workout was started
+	DurationSeconds int    `json:"durationSeconds"`
+	Notes           string `json:"notes"`
+}
+
+// CompletionResult is a finished workout with the plan and statistics it updated
+type CompletionResult struct {
+	Workout *models.WorkoutLog  `json:"workout"`
+	Plan    *models.WorkoutPlan `json:"plan"`
+	// Stats is nil when the user's profile could not be updated
+	Stats *models.UserStats `json:"stats,omitempty"`
+	// Progression holds the next targets computed for the session's exercises
+	Progression []ExerciseProgression `json:"progression,omitempty"`
+	// ReviewJobID is the background job generating a suggested plan, when the workout
+	// made the plan due for review
+	ReviewJobID string `json:"reviewJobId,omitempty"`
+}
+
+// WorkoutService records workouts performed from saved plans
+type WorkoutService struct {
+	plans    repositories.WorkoutPlanRepository
+	logs     repositories.WorkoutLogRepository
+	profiles repositories.ProfileRepository
+	reviews  *PlanReviewService
+	// progression computes next-session targets when a workout is completed
+	progression ProgressionConfig
+	now         func() time.Time
+}
+
+// NewWorkoutService creates a workout service. profiles may be nil, in which case user
+// statistics are not updated, and reviews may be nil, in which case completed workouts
+// never trigger a plan review.
+func NewWorkoutService(plans repositories.WorkoutPlanRepository, logs repositories.WorkoutLogRepository, profiles repositories.ProfileRepository, reviews *PlanReviewService, progression ProgressionConfig) *WorkoutService {
+	return &WorkoutService{
+		plans:       plans,
+		logs:        logs,
+		profiles:    profiles,
+		reviews:     reviews,
+		progression: progression,
+		now:         time.Now,
+	}
+}
+
+// Start begins a workout for one session of a plan
+func (s *WorkoutService) Start(ctx context.Context, planID uint, sessionID string) (*models.WorkoutLog, error) {
+	plan, err := s.plans.Get(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	session := findSession(plan, sessionID)
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	workout := &models.WorkoutLog{
+		UserID:      plan.UserID,
+		PlanID:      planID,
+		SessionID:   session.ID,
+		SessionName: session.Name,
+		Status:      models.WorkoutLogInProgress,
+		StartedAt:   s.now().UTC(),
+		Sets:        []models.SetLog{},
+	}
+	if err := s.logs.Create(ctx, workout); err != nil {
+		return nil, err
+	}
+	return workout, nil
+}
+
+// Plan returns the workout plan a workout would be started from
+func (s *WorkoutService) Plan(ctx context.Context, planID uint) (*models.WorkoutPlan, error) {
+	return s.plans.Get(ctx, planID)
+}
+
+// Get returns a workout with its sets
+func (s *WorkoutService) Get(ctx context.Context, id uint) (*models.WorkoutLog, error) {
+	return s.logs.Get(ctx, id)
+}
+
+// ListByUser returns the user's workouts, most recent first
+func (s *WorkoutService) ListByUser(ctx context.Context, userID string) ([]models.WorkoutLog, error) {
+	return s.logs.ListByUser(ctx, userID)
+}
+
+// LogSet records a set performed during a workout. Sets are numbered per exercise in the
+// order they are logged.
+func (s *WorkoutService) LogSet(ctx context.Context, workoutID uint, input SetInput) (*models.SetLog, error) {
+	if err := validateSet(input); err != nil {
+		return nil, err
+	}
+
+	workout, err := s.logs.Get(ctx, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	if workout.Status != models.WorkoutLogInProgress {
+		return nil, ErrWorkoutCompleted
+	}
+
+	plan, err := s.plans.Get(ctx, workout.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	exercise := findExercise(findSession(plan, workout.SessionID), input.ExerciseID)
+	if exercise == nil {
+		return nil, ErrExerciseNotFound
+	}
+
+	setNumber := 1
+	for _, set := range workout.Sets {
+		if set.ExerciseID == input.ExerciseID {
+			setNumber++
+		}
+	}
+
+	weight := input.Weight
+	if weight.Unit == "" {
+		weight.Unit = exercise.Weight.Unit
+	}
+
+	set := &models.SetLog{
+		ExerciseID:      input.ExerciseID,
+		ExerciseName:    exercise.Name,
+		SetNumber:       setNumber,
+		Reps:            input.Reps,
+		Weight:          weight,
+		TargetReps:      exercise.Reps,
+		TargetWeight:    exercise.Weight,
+		RPE:             input.RPE,
+		DurationSeconds: input.DurationSeconds,
+		CreatedAt:       s.now().UTC(),
+	}
+	if err := s.logs.AddSet(ctx, workoutID, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Complete finishes a workout, adds it to the plan's SessionsCompleted and updates the
+// user's TotalWorkouts, TotalVolume, TotalTime (minutes) and streaks. The progression
+// engine then sets the next targets of the session's exercises, and when the plan is due
+// for review a suggested plan is generated in the background.
+func (s *WorkoutService) Complete(ctx context.Context, workoutID uint, input CompleteInput) (*CompletionResult, error) {
+	workout, err := s.logs.Get(ctx, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	if workout.Status != models.WorkoutLogInProgress {
+		return nil, ErrWorkoutCompleted
+	}
+
+	now := s.now().UTC()
+	workout.Status = models.WorkoutLogCompleted
+	workout.CompletedAt = &now
+	workout.Notes = input.Notes
+	workout.DurationSeconds = input.DurationSeconds
+	if workout.DurationSeconds <= 0 {
+		workout.DurationSeconds = int(now.Sub(workout.StartedAt).Seconds())
+	}
+	workout.TotalVolume = 0
+	for _, set := range workout.Sets {
+		workout.TotalVolume += set.Volume()
+	}
+
+	if err := s.logs.Complete(ctx, workout); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return nil, ErrWorkoutCompleted
+		}
+		return nil, err
+	}
+
+	plan, err := s.plans.IncrementSessionsCompleted(ctx, workout.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("workout completed but plan could not be updated: %w", err)
+	}
+
+	// The workout itself is saved; stats can be recomputed from the logs later and a
+	// missed review happens at the next feedback cycle
+	result := &CompletionResult{Workout: workout, Plan: plan}
+	history, err := s.logs.ListByUser(ctx, workout.UserID)
+	if err != nil {
+		log.Printf("Failed to load workout history for user %s: %v", workout.UserID, err)
+		return result, nil
+	}
+
+	if s.progression.Scheme != ProgressionOff {
+		progressed, progressions, err := s.progress(ctx, plan, workout.SessionID, history)
+		if err != nil {
+			log.Printf("Failed to update targets of workout plan %d: %v", plan.ID, err)
+		} else {
+			result.Plan = progressed
+			result.Progression = progressions
+		}
+	}
+
+	stats, err := s.updateStats(ctx, workout, history)
+	if err != nil {
+		log.Printf("Failed to update stats for user %s after workout %d: %v", workout.UserID, workout.ID, err)
+	} else {
+		result.Stats = stats
+	}
+
+	if s.reviews != nil {
+		job, err := s.reviews.ScheduleIfDue(ctx, result.Plan, history, workout)
+		if err != nil {
+			log.Printf("Failed to schedule review of workout plan %d: %v", plan.ID, err)
+		} else if job != nil {
+			result.ReviewJobID = job.ID
+		}
+	}
+	return result, nil
+}
+
+// progress applies the progression engine to the exercises of a plan session, storing
+// the targets that changed
+func (s *WorkoutService) progress(ctx context.Context, plan *models.WorkoutPlan, sessionID string, history []models.WorkoutLog) (*models.WorkoutPlan, []ExerciseProgression, error) {
+	progressions := PlanProgression(plan, sessionID, history, s.progression)
+
+	var targets []models.Exercise
+	for _, p := range progressions {
+		if p.Changed() {
+			targets = append(targets, models.Exercise{
+				ID:     p.ExerciseID,
+				Sets:   p.Next.Sets,
+				Reps:   p.Next.Reps,
+				Weight: p.Next.Weight,
+			})
+		}
+	}
+	if len(targets) == 0 {
+		return plan, progressions, nil
+	}
+
+	updated, err := s.plans.UpdateExerciseTargets(ctx, uint(plan.ID), targets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, progressions, nil
+}
+
+// updateStats adds a completed workout to the user's profile statistics. history is the
+// user's workouts including workout.
+func (s *WorkoutService) updateStats(ctx context.Context, workout *models.WorkoutLog, history []models.WorkoutLog) (*models.UserStats, error) {
+	if s.profiles == nil {
+		return nil, errors.New("no profile repository configured")
+	}
+
+	userData, err := s.profiles.Get(ctx, workout.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := userData.Data.Stats
+	stats.TotalWorkouts++
+	stats.TotalVolume += int(math.Round(workout.TotalVolume))
+	stats.TotalTime += int(math.Round(float64(workout.DurationSeconds) / 60))
+
+	streak := currentStreak(history, *workout.CompletedAt)
+	stats.CurrentStreak = streak
+	if streak > stats.LongestStreak {
+		stats.LongestStreak = streak
+	}
+
+	if err := s.profiles.UpdateStats(ctx, workout.UserID, stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// currentStreak counts the consecutive calendar days (UTC) with a completed workout,
+// ending on the day of asOf
+func currentStreak(history []models.WorkoutLog, asOf time.Time) int {
+	days := map[string]bool{}
+	for _, workout := range history {
+		if workout.Status == models.WorkoutLogCompleted && workout.CompletedAt != nil {
+			days[workout.CompletedAt.UTC().Format(time.DateOnly)] = true
+		}
+	}
+	days[asOf.UTC().Format(time.DateOnly)] = true
+
+	streak := 0
+	for day := asOf.UTC(); days[day.Format(time.DateOnly)]; day = day.AddDate(0, 0, -1) {
+		streak++
+	}
+	return streak
+}
+
+// validateSet checks the ranges of a logged set
+func validateSet(input SetInput) error {
+	switch {
+	case input.ExerciseID <= 0:
+		return fmt.Errorf("%w: exerciseId is required", ErrInvalidSet)
+	case input.Reps < 0 || input.Reps > maxReps:
+		return fmt.Errorf("%w: reps must be between 0 and %d", ErrInvalidSet, maxReps)
+	case input.Weight.Value < 0 || input.Weight.Value > maxWeightValue:
+		return fmt.Errorf("%w: weight must be between 0 and %d", ErrInvalidSet, maxWeightValue)
+	case input.Weight.Unit != "" && !validWeightUnits[input.Weight.Unit]:
+		return fmt.Errorf("%w: unit must be one of LB, KG, BODYWEIGHT", ErrInvalidSet)
+	case input.RPE != nil && (*input.RPE < 1 || *input.RPE > 10):
+		return fmt.Errorf("%w: rpe must be between 1 and 10", ErrInvalidSet)
+	case input.DurationSeconds < 0:
+		return fmt.Errorf("%w: durationSeconds cannot be negative", ErrInvalidSet)
+	}
+	return nil
+}
+
+// findSession returns the session of plan with the given ID, or nil
+func findSession(plan *models.WorkoutPlan, sessionID string) *models.WorkoutSession {
+	for i := range plan.Sessions {
+		if plan.Sessions[i].ID == sessionID {
+			return &plan.Sessions[i]
+		}
+	}
+	return nil
+}
+
+// findExercise returns the exercise of session with the given ID, or nil
+func findExercise(session *models.WorkoutSession, exerciseID int) *models.Exercise {
+	if session == nil {
+		return nil
+	}
+	for i := range session.Exercises {
+		if session.Exercises[i].ID == exerciseID {
+			return &session.Exercises[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"fit-ai-api/models"
+	"fit-ai-api/repositories"
+)
+
+// workoutNow is the fixed clock of the workout services under test
+var workoutNow = time.Date(2024, 6, 10, 18, 0, 0, 0, time.UTC)
+
+// newTestWorkoutService returns a workout service on in-memory repositories holding
+// testPlan for user-1, whose profile has stats, with the clock fixed at workoutNow and
+// progression off
+func newTestWorkoutService(t *testing.T, stats models.UserStats) (*WorkoutService, *models.WorkoutPlan, *repositories.MemoryProfileRepository) {
+	t.Helper()
+	plans := repositories.NewMemoryWorkoutPlanRepository()
+	plan, err := plans.Create(context.Background(), "user-1", testPlan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles := repositories.NewMemoryProfileRepository()
+	profiles.Put("user-1", models.FirestoreUser{UID: "user-1", UserProfile: models.UserProfile{Stats: stats}})
+
+	service := NewWorkoutService(plans, repositories.NewMemoryWorkoutLogRepository(), profiles, nil, ProgressionConfig{Scheme: ProgressionOff})
+	service.now = func() time.Time { return workoutNow }
+	return service, plan, profiles
+}
+
+func TestWorkoutServiceStart(t *testing.T) {
+	service, plan, _ := newTestWorkoutService(t, models.UserStats{})
+	session := plan.Sessions[1]
+
+	tests := []struct {
+		name      string
+		planID    uint
+		sessionID string
+		err       error
+	}{
+		{"plan session", uint(plan.ID), session.ID, nil},
+		{"unknown session", uint(plan.ID), "missing", ErrSessionNotFound},
+		{"unknown plan", 99, session.ID, repositories.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workout, err := service.Start(context.Background(), tt.planID, tt.sessionID)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Start error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if workout.UserID != "user-1" || workout.SessionName != "Pull" || workout.Status != models.WorkoutLogInProgress || !workout.StartedAt.Equal(workoutNow) {
+				t.Errorf("Start = %+v, want user-1's Pull session started at %v", workout, workoutNow)
+			}
+		})
+	}
+}
+
+func TestWorkoutServiceLogSet(t *testing.T) {
+	service, plan, _ := newTestWorkoutService(t, models.UserStats{})
+	push := plan.Sessions[0]
+	workout, err := service.Start(context.Background(), uint(plan.ID), push.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bench, press := push.Exercises[0], push.Exercises[1]
+	row := plan.Sessions[1].Exercises[0]
+
+	// Sets are numbered per exercise in the order they are logged
+	tests := []struct {
+		name      string
+		input     SetInput
+		setNumber int
+		unit      string
+		err       error
+	}{
+		{"first bench set", SetInput{ExerciseID: bench.ID, Reps: 8, Weight: kg(20)}, 1, WeightUnitKilograms, nil},
+		{"first press set", SetInput{ExerciseID: press.ID, Reps: 10, Weight: models.WeightInfo{Value: 55, Unit: WeightUnitPounds}}, 1, WeightUnitPounds, nil},
+		{"second bench set takes the planned unit", SetInput{ExerciseID: bench.ID, Reps: 7, Weight: models.WeightInfo{Value: 20}}, 2, WeightUnitKilograms, nil},
+		{"exercise of another session", SetInput{ExerciseID: row.ID, Reps: 8, Weight: kg(20)}, 0, "", ErrExerciseNotFound},
+		{"unknown exercise", SetInput{ExerciseID: 999, Reps: 8, Weight: kg(20)}, 0, "", ErrExerciseNotFound},
+		{"too many reps", SetInput{ExerciseID: bench.ID, Reps: maxReps + 1, Weight: kg(20)}, 0, "", ErrInvalidSet},
+		{"third bench set", SetInput{ExerciseID: bench.ID, Reps: 6, Weight: kg(20)}, 3, WeightUnitKilograms, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := service.LogSet(context.Background(), workout.ID, tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("LogSet error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if set.SetNumber != tt.setNumber || set.Weight.Unit != tt.unit {
+				t.Errorf("LogSet = set %d in %s, want set %d in %s", set.SetNumber, set.Weight.Unit, tt.setNumber, tt.unit)
+			}
+		})
+	}
+
+	if _, err := service.LogSet(context.Background(), 99, SetInput{ExerciseID: bench.ID, Reps: 8}); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("LogSet on an unknown workout: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestWorkoutServiceComplete(t *testing.T) {
+	service, plan, profiles := newTestWorkoutService(t, models.UserStats{TotalWorkouts: 4, TotalVolume: 1000, TotalTime: 90, LongestStreak: 3})
+	ctx := context.Background()
+	push := plan.Sessions[0]
+	workout, err := service.Start(ctx, uint(plan.ID), push.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := []SetInput{
+		{ExerciseID: push.Exercises[0].ID, Reps: 10, Weight: kg(20)},
+		{ExerciseID: push.Exercises[1].ID, Reps: 10, Weight: models.WeightInfo{Value: 50, Unit: WeightUnitPounds}},
+		{ExerciseID: push.Exercises[2].ID, Reps: 15, Weight: models.WeightInfo{Unit: WeightUnitBodyweight}},
+	}
+	for _, set := range sets {
+		if _, err := service.LogSet(ctx, workout.ID, set); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := service.Complete(ctx, workout.ID, CompleteInput{DurationSeconds: 45 * 60, Notes: "Felt strong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 x 20 KG + 10 x 50 LB (226.8 KG); the bodyweight set has no load
+	wantVolume := 200 + 500*0.45359237
+	if got := result.Workout; got.Status != models.WorkoutLogCompleted || !got.CompletedAt.Equal(workoutNow) || math.Abs(got.TotalVolume-wantVolume) > 1e-9 {
+		t.Errorf("completed workout = %+v, want completed at %v with volume %.2f", got, workoutNow, wantVolume)
+	}
+	if result.Plan.SessionsCompleted != 1 {
+		t.Errorf("plan SessionsCompleted = %d, want 1", result.Plan.SessionsCompleted)
+	}
+	want := models.UserStats{TotalWorkouts: 5, TotalVolume: 1427, TotalTime: 135, CurrentStreak: 1, LongestStreak: 3}
+	if result.Stats == nil || *result.Stats != want {
+		t.Errorf("stats = %+v, want %+v", result.Stats, want)
+	}
+	stored, err := profiles.Get(ctx, "user-1")
+	if err != nil || stored.Data.Stats != want {
+		t.Errorf("stored stats = %+v, %v; want %+v", stored.Data.Stats, err, want)
+	}
+
+	if _, err := service.Complete(ctx, workout.ID, CompleteInput{}); !errors.Is(err, ErrWorkoutCompleted) {
+		t.Errorf("second Complete: error = %v, want ErrWorkoutCompleted", err)
+	}
+	if _, err := service.LogSet(ctx, workout.ID, sets[0]); !errors.Is(err, ErrWorkoutCompleted) {
+		t.Errorf("LogSet after Complete: error = %v, want ErrWorkoutCompleted", err)
+	}
+}
+
+func TestWorkoutServiceCompleteStreaks(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      models.UserStats
+		daysBefore []int
+		current    int
+		longest    int
+	}{
+		{"first workout", models.UserStats{}, nil, 1, 1},
+		{"workout yesterday", models.UserStats{CurrentStreak: 1, LongestStreak: 1}, []int{1}, 2, 2},
+		{"three days running", models.UserStats{CurrentStreak: 2, LongestStreak: 2}, []int{2, 1}, 3, 3},
+		{"missed day resets the streak", models.UserStats{CurrentStreak: 4, LongestStreak: 4}, []int{5, 4, 3, 2}, 1, 4},
+		{"second workout today", models.UserStats{CurrentStreak: 2, LongestStreak: 6}, []int{1, 0}, 2, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, plan, _ := newTestWorkoutService(t, tt.stats)
+			ctx := context.Background()
+			sessionID := plan.Sessions[0].ID
+
+			// Earlier workouts are completed an hour earlier in the day, daysBefore days ago
+			for _, days := range tt.daysBefore {
+				at := workoutNow.AddDate(0, 0, -days).Add(-time.Hour)
+				service.now = func() time.Time { return at }
+				earlier, err := service.Start(ctx, uint(plan.ID), sessionID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := service.Complete(ctx, earlier.ID, CompleteInput{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			service.now = func() time.Time { return workoutNow }
+			workout, err := service.Start(ctx, uint(plan.ID), sessionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := service.Complete(ctx, workout.ID, CompleteInput{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stats == nil || result.Stats.CurrentStreak != tt.current || result.Stats.LongestStreak != tt.longest {
+				t.Errorf("stats = %+v, want current streak %d and longest %d", result.Stats, tt.current, tt.longest)
+			}
+		})
+	}
+}
+
+func TestCurrentStreak(t *testing.T) {
+	completed := func(daysBefore int) models.WorkoutLog {
+		at := workoutNow.AddDate(0, 0, -daysBefore)
+		return models.WorkoutLog{Status: models.WorkoutLogCompleted, CompletedAt: &at}
+	}
+	inProgress := models.WorkoutLog{Status: models.WorkoutLogInProgress, StartedAt: workoutNow.AddDate(0, 0, -1)}
+
+	tests := []struct {
+		name    string
+		history []models.WorkoutLog
+		want    int
+	}{
+		{"no history counts today", nil, 1},
+		{"consecutive days", []models.WorkoutLog{completed(0), completed(1), completed(2)}, 3},
+		{"missed day", []models.WorkoutLog{completed(0), completed(2), completed(3)}, 1},
+		{"several workouts a day count once", []models.WorkoutLog{completed(0), completed(0), completed(1)}, 2},
+		{"unfinished workouts don't count", []models.WorkoutLog{completed(0), inProgress, completed(2)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := currentStreak(tt.history, workoutNow); got != tt.want {
+				t.Errorf("currentStreak = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}